@@ -0,0 +1,167 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package clickhouse provides functions to trace the ClickHouse/clickhouse-go/v2 package
+// (https://github.com/ClickHouse/clickhouse-go).
+package clickhouse // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/ClickHouse/clickhouse-go.v2"
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+const (
+	tagPeerAddress  = "peer.address"
+	tagRowsInserted = "clickhouse.rows_inserted"
+	tagColumns      = "clickhouse.columns"
+)
+
+// Open parses the given DSN and opens a traced connection to ClickHouse. The database
+// and server addresses found in the DSN are added to all spans.
+func Open(dsn string, opts ...Option) (driver.Conn, error) {
+	options, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := clickhouse.Open(options)
+	if err != nil {
+		return nil, err
+	}
+	return WrapConn(conn, options, opts...), nil
+}
+
+// WrapConn wraps conn so that its Query, QueryRow, Exec and batch operations are traced.
+// If options is not nil, it should be the one conn was opened with, and is used to tag
+// spans with the database and server addresses.
+func WrapConn(conn driver.Conn, options *clickhouse.Options, opts ...Option) driver.Conn {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	tc := &tracedConn{Conn: conn, cfg: cfg}
+	if options != nil {
+		tc.database = options.Auth.Database
+		tc.addr = strings.Join(options.Addr, ",")
+	}
+	return tc
+}
+
+// tracedConn is a driver.Conn which traces the queries it runs. Methods which are not
+// overridden are forwarded to the embedded connection.
+type tracedConn struct {
+	driver.Conn
+	cfg      *config
+	database string
+	addr     string
+}
+
+func (c *tracedConn) startSpan(ctx context.Context, name, query string) ddtrace.Span {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(c.cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.ResourceName(query),
+		tracer.Tag(ext.DBType, "clickhouse"),
+	}
+	if c.database != "" {
+		opts = append(opts, tracer.Tag(ext.DBInstance, c.database))
+	}
+	if c.addr != "" {
+		opts = append(opts, tracer.Tag(tagPeerAddress, c.addr))
+	}
+	if !math.IsNaN(c.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, name, opts...)
+	return span
+}
+
+// Query implements driver.Conn.
+func (c *tracedConn) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
+	span := c.startSpan(ctx, "clickhouse.query", query)
+	rows, err := c.Conn.Query(ctx, query, args...)
+	span.Finish(tracer.WithError(err))
+	return rows, err
+}
+
+// QueryRow implements driver.Conn.
+func (c *tracedConn) QueryRow(ctx context.Context, query string, args ...interface{}) driver.Row {
+	span := c.startSpan(ctx, "clickhouse.query", query)
+	row := c.Conn.QueryRow(ctx, query, args...)
+	span.Finish(tracer.WithError(row.Err()))
+	return row
+}
+
+// Exec implements driver.Conn.
+func (c *tracedConn) Exec(ctx context.Context, query string, args ...interface{}) error {
+	span := c.startSpan(ctx, "clickhouse.exec", query)
+	if c.cfg.columnTags {
+		if cols := insertColumns(query); cols != "" {
+			span.SetTag(tagColumns, cols)
+		}
+	}
+	err := c.Conn.Exec(ctx, query, args...)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// PrepareBatch implements driver.Conn. The returned batch is traced when it is sent.
+func (c *tracedConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	b, err := c.Conn.PrepareBatch(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedBatch{Batch: b, conn: c, ctx: ctx, query: query}, nil
+}
+
+// tracedBatch is a driver.Batch whose Send calls are traced.
+type tracedBatch struct {
+	driver.Batch
+	conn  *tracedConn
+	ctx   context.Context
+	query string
+}
+
+// Send implements driver.Batch.
+func (b *tracedBatch) Send() error {
+	span := b.conn.startSpan(b.ctx, "clickhouse.batch", b.query)
+	span.SetTag(tagRowsInserted, b.Batch.Rows())
+	if b.conn.cfg.columnTags {
+		cols := b.Batch.Columns()
+		names := make([]string, len(cols))
+		for i, col := range cols {
+			names[i] = col.Name()
+		}
+		span.SetTag(tagColumns, strings.Join(names, ","))
+	}
+	err := b.Batch.Send()
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+var insertRegexp = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+[^\s(]+\s*\(([^)]*)\)`)
+
+// insertColumns returns the comma separated list of columns named in the given INSERT
+// statement, or an empty string if the statement is not an INSERT or names no columns.
+func insertColumns(query string) string {
+	m := insertRegexp.FindStringSubmatch(query)
+	if m == nil {
+		return ""
+	}
+	cols := strings.Split(m[1], ",")
+	for i, col := range cols {
+		cols[i] = strings.Trim(strings.TrimSpace(col), "`\"")
+	}
+	return strings.Join(cols, ",")
+}
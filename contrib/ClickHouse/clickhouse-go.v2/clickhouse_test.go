@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn is a driver.Conn which returns errFake from Exec for the "FAIL" query.
+type fakeConn struct{ driver.Conn }
+
+var errFake = errors.New("fake error")
+
+func (fakeConn) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
+	return nil, nil
+}
+
+func (fakeConn) QueryRow(ctx context.Context, query string, args ...interface{}) driver.Row {
+	return fakeRow{}
+}
+
+func (fakeConn) Exec(ctx context.Context, query string, args ...interface{}) error {
+	if query == "FAIL" {
+		return errFake
+	}
+	return nil
+}
+
+func (fakeConn) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	return &fakeBatch{}, nil
+}
+
+type fakeRow struct{ driver.Row }
+
+func (fakeRow) Err() error { return errFake }
+
+type fakeBatch struct {
+	driver.Batch
+	rows int
+}
+
+func (b *fakeBatch) Append(v ...interface{}) error {
+	b.rows++
+	return nil
+}
+
+func (b *fakeBatch) Rows() int { return b.rows }
+
+func (*fakeBatch) Send() error { return nil }
+
+func (*fakeBatch) Columns() []column.Interface {
+	return []column.Interface{fakeColumn{name: "id"}, fakeColumn{name: "name"}}
+}
+
+type fakeColumn struct {
+	column.Interface
+	name string
+}
+
+func (c fakeColumn) Name() string { return c.name }
+
+func wrapFake(opts ...Option) driver.Conn {
+	return WrapConn(fakeConn{}, &clickhouse.Options{
+		Addr: []string{"ch1:9000", "ch2:9000"},
+		Auth: clickhouse.Auth{Database: "analytics"},
+	}, opts...)
+}
+
+func TestQuery(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	conn := wrapFake(WithServiceName("my-clickhouse"))
+	_, err := conn.Query(ctx, "SELECT * FROM events")
+	assert.NoError(err)
+	row := conn.QueryRow(ctx, "SELECT count() FROM events")
+	assert.Equal(errFake, row.Err())
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	for i, query := range []string{"SELECT * FROM events", "SELECT count() FROM events"} {
+		s := spans[i]
+		assert.Equal("clickhouse.query", s.OperationName())
+		assert.Equal(query, s.Tag(ext.ResourceName))
+		assert.Equal("my-clickhouse", s.Tag(ext.ServiceName))
+		assert.Equal(ext.SpanTypeSQL, s.Tag(ext.SpanType))
+		assert.Equal("clickhouse", s.Tag(ext.DBType))
+		assert.Equal("analytics", s.Tag(ext.DBInstance))
+		assert.Equal("ch1:9000,ch2:9000", s.Tag(tagPeerAddress))
+		assert.Equal(spans[2].SpanID(), s.ParentID())
+	}
+	assert.Nil(spans[0].Tag(ext.Error))
+	assert.Equal(errFake, spans[1].Tag(ext.Error))
+}
+
+func TestExec(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		conn := wrapFake()
+		assert.NoError(conn.Exec(context.Background(), "INSERT INTO events (id, name) VALUES (1, 'a')"))
+		assert.Equal(errFake, conn.Exec(context.Background(), "FAIL"))
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 2)
+		assert.Equal("clickhouse.exec", spans[0].OperationName())
+		assert.Equal("clickhouse", spans[0].Tag(ext.ServiceName))
+		assert.Nil(spans[0].Tag(tagColumns))
+		assert.Equal(errFake, spans[1].Tag(ext.Error))
+	})
+
+	t.Run("columns", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		conn := wrapFake(WithColumnTags(true))
+		assert.NoError(conn.Exec(context.Background(), "INSERT INTO events (id, `name`) VALUES (1, 'a')"))
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal("id,name", spans[0].Tag(tagColumns))
+	})
+}
+
+func TestBatch(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conn := wrapFake(WithColumnTags(true))
+	b, err := conn.PrepareBatch(context.Background(), "INSERT INTO events")
+	assert.NoError(err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(b.Append(i, "name"))
+	}
+	assert.NoError(b.Send())
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	s := spans[0]
+	assert.Equal("clickhouse.batch", s.OperationName())
+	assert.Equal("INSERT INTO events", s.Tag(ext.ResourceName))
+	assert.Equal(3, s.Tag(tagRowsInserted))
+	assert.Equal("id,name", s.Tag(tagColumns))
+}
+
+func TestInsertColumns(t *testing.T) {
+	for query, cols := range map[string]string{
+		"INSERT INTO events (id, name) VALUES":      "id,name",
+		"insert into db.events(`id`,\"ts\") FORMAT": "id,ts",
+		"INSERT INTO events VALUES":                 "",
+		"SELECT id FROM events":                     "",
+	} {
+		assert.Equal(t, cols, insertColumns(query), query)
+	}
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		assert.NoError(t, wrapFake(opts...).Exec(context.Background(), "SELECT 1"))
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
+
+func TestIntegration(t *testing.T) {
+	if _, ok := os.LookupEnv("INTEGRATION"); !ok {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	conn, err := Open("clickhouse://localhost:9000/default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	assert.NoError(conn.Exec(context.Background(), "SELECT 1"))
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("default", spans[0].Tag(ext.DBInstance))
+	assert.Equal("localhost:9000", spans[0].Tag(tagPeerAddress))
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clickhouse_test
+
+import (
+	"context"
+	"log"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	clickhousetrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/ClickHouse/clickhouse-go.v2"
+)
+
+func ExampleOpen() {
+	conn, err := clickhousetrace.Open("clickhouse://localhost:9000/analytics",
+		clickhousetrace.WithServiceName("my-clickhouse"),
+		clickhousetrace.WithColumnTags(true),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO events (id, name)")
+	if err != nil {
+		log.Fatal(err)
+	}
+	batch.Append(1, "signup")
+	batch.Append(2, "login")
+	// the span of the batch is tagged with the number of rows and the column names
+	batch.Send()
+}
+
+func ExampleWrapConn() {
+	options := &clickhouse.Options{Addr: []string{"localhost:9000"}}
+	c, err := clickhouse.Open(options)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn := clickhousetrace.WrapConn(c, options)
+	defer conn.Close()
+
+	conn.Exec(context.Background(), "CREATE TABLE IF NOT EXISTS events (id UInt64, name String) ENGINE = Memory")
+}
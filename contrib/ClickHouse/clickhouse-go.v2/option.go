@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clickhouse
+
+import (
+	"math"
+)
+
+type config struct {
+	serviceName   string
+	analyticsRate float64
+	columnTags    bool
+}
+
+func defaults(cfg *config) {
+	cfg.serviceName = "clickhouse"
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.analyticsRate = math.NaN()
+}
+
+// An Option is used to customize the config for the clickhouse tracer.
+type Option func(cfg *config)
+
+// WithServiceName sets the given service name for the started spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithColumnTags enables tagging the spans of INSERT statements and batches with the
+// names of the inserted columns, as clickhouse.columns.
+func WithColumnTags(on bool) Option {
+	return func(cfg *config) {
+		cfg.columnTags = on
+	}
+}
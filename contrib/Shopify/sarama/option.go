@@ -12,10 +12,12 @@ import (
 type config struct {
 	serviceName   string
 	analyticsRate float64
+	lagThreshold  int64
 }
 
 func defaults(cfg *config) {
 	cfg.serviceName = "kafka"
+	cfg.lagThreshold = -1
 	// cfg.analyticsRate = globalconfig.AnalyticsRate()
 	cfg.analyticsRate = math.NaN()
 }
@@ -52,3 +54,13 @@ func WithAnalyticsRate(rate float64) Option {
 		}
 	}
 }
+
+// WithLagThreshold sets the sampling priority of the spans of consumed messages to
+// ext.PriorityUserKeep when their consumer lag, which is tagged as kafka.consumer_lag,
+// exceeds n messages. This ensures that traces of high lag processing are always kept.
+// A negative value disables it, which is the default.
+func WithLagThreshold(n int64) Option {
+	return func(cfg *config) {
+		cfg.lagThreshold = n
+	}
+}
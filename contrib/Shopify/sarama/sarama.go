@@ -43,6 +43,11 @@ func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.P
 		msgs := pc.Messages()
 		var prev ddtrace.Span
 		for msg := range msgs {
+			// the high water mark is the offset of the next message to be produced
+			lag := pc.HighWaterMarkOffset() - msg.Offset - 1
+			if lag < 0 {
+				lag = 0
+			}
 			// create the next span from the message
 			opts := []tracer.StartSpanOption{
 				tracer.ServiceName(cfg.serviceName),
@@ -50,6 +55,7 @@ func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.P
 				tracer.SpanType(ext.SpanTypeMessageConsumer),
 				tracer.Tag("partition", msg.Partition),
 				tracer.Tag("offset", msg.Offset),
+				tracer.Tag("kafka.consumer_lag", lag),
 			}
 			if !math.IsNaN(cfg.analyticsRate) {
 				opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
@@ -60,6 +66,9 @@ func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.P
 				opts = append(opts, tracer.ChildOf(spanctx))
 			}
 			next := tracer.StartSpan("kafka.consume", opts...)
+			if cfg.lagThreshold >= 0 && lag > cfg.lagThreshold {
+				next.SetTag(ext.SamplingPriority, ext.PriorityUserKeep)
+			}
 			// reinject the span context so consumers can pick it up
 			tracer.Inject(next.Context(), carrier)
 
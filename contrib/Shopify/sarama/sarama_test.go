@@ -92,6 +92,58 @@ func TestConsumer(t *testing.T) {
 	}
 }
 
+func TestConsumerLag(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	broker := sarama.NewMockBroker(t, 0)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("test-topic", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("test-topic", 0, sarama.OffsetOldest, 0).
+			SetOffset("test-topic", 0, sarama.OffsetNewest, 10),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetMessage("test-topic", 0, 0, sarama.StringEncoder("hello")).
+			SetMessage("test-topic", 0, 1, sarama.StringEncoder("world")).
+			SetHighWaterMark("test-topic", 0, 10),
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+
+	consumer = WrapConsumer(consumer, WithLagThreshold(8))
+
+	partitionConsumer, err := consumer.ConsumePartition("test-topic", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-partitionConsumer.Messages()
+	<-partitionConsumer.Messages()
+	partitionConsumer.Close()
+	// wait for the channel to be closed
+	<-partitionConsumer.Messages()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, int64(9), spans[0].Tag("kafka.consumer_lag"))
+	assert.Equal(t, ext.PriorityUserKeep, spans[0].Tag(ext.SamplingPriority))
+	assert.Equal(t, int64(8), spans[1].Tag("kafka.consumer_lag"))
+	assert.Nil(t, spans[1].Tag(ext.SamplingPriority))
+}
+
 func TestSyncProducer(t *testing.T) {
 	mt := mocktracer.Start()
 	defer mt.Stop()
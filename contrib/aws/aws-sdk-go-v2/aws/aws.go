@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package aws provides functions to trace aws/aws-sdk-go-v2 (https://github.com/aws/aws-sdk-go-v2).
+package aws // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/aws-sdk-go-v2/aws"
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	tagAWSAgent     = "aws.agent"
+	tagAWSOperation = "aws.operation"
+	tagAWSRegion    = "aws.region"
+	tagAWSService   = "aws.service"
+	tagAWSRequestID = "aws.request_id"
+
+	tagTableName  = "aws.dynamodb.table_name"
+	tagQueueURL   = "aws.sqs.queue_url"
+	tagBucketName = "aws.s3.bucket_name"
+	tagObjectKey  = "aws.s3.object_key"
+)
+
+// AppendMiddleware adds the tracing middleware to the given stack, causing the
+// requests and responses of the operation to be traced. It is meant to be used
+// with aws.Config.APIOptions:
+//
+//	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+//		return awstrace.AppendMiddleware(stack)
+//	})
+func AppendMiddleware(stack *middleware.Stack, opts ...Option) error {
+	cfg := new(config)
+	defaults(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	tm := &traceMiddleware{cfg: cfg}
+	if err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("DDTraceInitialize", tm.initialize), middleware.After); err != nil {
+		return err
+	}
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("DDTraceFinalize", tm.finalize), middleware.After)
+}
+
+type traceMiddleware struct {
+	cfg *config
+}
+
+// initialize starts the span of the operation and finishes it once the operation
+// completes, including its retries.
+func (tm *traceMiddleware) initialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	service := awsmiddleware.GetServiceID(ctx)
+	operation := awsmiddleware.GetOperationName(ctx)
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.ServiceName(tm.serviceName(service)),
+		tracer.ResourceName(normalize(service) + "." + operation),
+		tracer.Tag(tagAWSService, service),
+		tracer.Tag(tagAWSOperation, operation),
+		tracer.Tag(tagAWSRegion, awsmiddleware.GetRegion(ctx)),
+	}
+	if !math.IsNaN(tm.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, tm.cfg.analyticsRate))
+	}
+	switch normalize(service) {
+	case "dynamodb":
+		opts = appendFieldTag(opts, in.Parameters, "TableName", tagTableName)
+	case "sqs":
+		opts = appendFieldTag(opts, in.Parameters, "QueueUrl", tagQueueURL)
+	case "s3":
+		opts = appendFieldTag(opts, in.Parameters, "Bucket", tagBucketName)
+		opts = appendFieldTag(opts, in.Parameters, "Key", tagObjectKey)
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, normalize(service)+".command", opts...)
+	sqsPropagation := tm.cfg.sqsPropagation && normalize(service) == "sqs"
+	var finishMessages func(result interface{}, err error)
+	if sqsPropagation {
+		in.Parameters, finishMessages = tm.injectSQS(span, in.Parameters)
+	}
+
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	if finishMessages != nil {
+		finishMessages(out.Result, err)
+	}
+	if sqsPropagation && err == nil {
+		tm.extractSQS(span, out.Result)
+	}
+
+	if id, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		span.SetTag(tagAWSRequestID, id)
+	}
+	if resp, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+		span.SetTag(ext.HTTPCode, strconv.Itoa(resp.StatusCode))
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		span.SetTag(ext.HTTPCode, strconv.Itoa(respErr.HTTPStatusCode()))
+		if id := respErr.ServiceRequestID(); id != "" {
+			span.SetTag(tagAWSRequestID, id)
+		}
+	}
+	span.Finish(tracer.WithError(err))
+	return out, metadata, err
+}
+
+// finalize tags the span with the HTTP request, once it is built.
+func (tm *traceMiddleware) finalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		if req, ok := in.Request.(*smithyhttp.Request); ok {
+			span.SetTag(ext.HTTPMethod, req.Method)
+			span.SetTag(ext.HTTPURL, req.URL.String())
+			if agent := req.Header.Get("User-Agent"); agent != "" {
+				span.SetTag(tagAWSAgent, agent)
+			}
+		}
+	}
+	return next.HandleFinalize(ctx, in)
+}
+
+func (tm *traceMiddleware) serviceName(service string) string {
+	if tm.cfg.serviceName != "" {
+		return tm.cfg.serviceName
+	}
+	return "aws." + normalize(service)
+}
+
+// normalize returns the lower case form of the given service ID, without spaces,
+// e.g. "dynamodb" for "DynamoDB".
+func normalize(service string) string {
+	return strings.ToLower(strings.ReplaceAll(service, " ", ""))
+}
+
+// appendFieldTag appends a tag with the given name to opts if params, the input of
+// the operation, has a set string field with the given name.
+func appendFieldTag(opts []ddtrace.StartSpanOption, params interface{}, field, tag string) []ddtrace.StartSpanOption {
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return opts
+	}
+	f := v.Elem().FieldByName(field)
+	if !f.IsValid() || f.Kind() != reflect.Ptr || f.IsNil() || f.Elem().Kind() != reflect.String {
+		return opts
+	}
+	return append(opts, tracer.Tag(tag, f.Elem().String()))
+}
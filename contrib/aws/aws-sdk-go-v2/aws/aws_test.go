@@ -0,0 +1,275 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// newConfig returns an aws.Config sending requests to a server which responds with
+// the given status code and body.
+func newConfig(t *testing.T, code int, body string, opts ...Option) aws.Config {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Request-Id", "test-request-id")
+		w.Header().Set("X-Amzn-Requestid", "test-request-id")
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return aws.Config{
+		Region:           "us-west-2",
+		Credentials:      aws.AnonymousCredentials{},
+		BaseEndpoint:     aws.String(srv.URL),
+		RetryMaxAttempts: 1,
+		APIOptions: []func(*middleware.Stack) error{
+			func(stack *middleware.Stack) error {
+				return AppendMiddleware(stack, opts...)
+			},
+		},
+	}
+}
+
+func TestS3(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := s3.NewFromConfig(newConfig(t, http.StatusOK, ""), func(o *s3.Options) {
+		o.UsePathStyle = true
+	})
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	_, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String("my-bucket"),
+		Key:    aws.String("my-key"),
+	})
+	assert.NoError(err)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	s := spans[0]
+	assert.Equal(root.Context().SpanID(), s.ParentID())
+	assert.Equal("s3.command", s.OperationName())
+	assert.Equal("aws.s3", s.Tag(ext.ServiceName))
+	assert.Equal("s3.GetObject", s.Tag(ext.ResourceName))
+	assert.Equal("S3", s.Tag(tagAWSService))
+	assert.Equal("GetObject", s.Tag(tagAWSOperation))
+	assert.Equal("us-west-2", s.Tag(tagAWSRegion))
+	assert.Equal("test-request-id", s.Tag(tagAWSRequestID))
+	assert.Equal("200", s.Tag(ext.HTTPCode))
+	assert.Equal("GET", s.Tag(ext.HTTPMethod))
+	assert.Contains(s.Tag(ext.HTTPURL), "/my-bucket/my-key")
+	assert.Contains(s.Tag(tagAWSAgent), "aws-sdk-go-v2")
+	assert.Equal("my-bucket", s.Tag(tagBucketName))
+	assert.Equal("my-key", s.Tag(tagObjectKey))
+}
+
+func TestDynamoDB(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := dynamodb.NewFromConfig(newConfig(t, http.StatusOK, "{}", WithServiceName("my-dynamo")))
+	_, err := client.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String("my-table"),
+		Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}},
+	})
+	assert.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "dynamodb.command", s.OperationName())
+	assert.Equal(t, "my-dynamo", s.Tag(ext.ServiceName))
+	assert.Equal(t, "dynamodb.GetItem", s.Tag(ext.ResourceName))
+	assert.Equal(t, "my-table", s.Tag(tagTableName))
+}
+
+func TestSQSError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := sqs.NewFromConfig(newConfig(t, http.StatusBadRequest,
+		`{"__type":"com.amazonaws.sqs#QueueDoesNotExist","message":"no queue"}`))
+	_, err := client.SendMessage(context.Background(), &sqs.SendMessageInput{
+		QueueUrl:    aws.String("https://sqs.us-west-2.amazonaws.com/123/my-queue"),
+		MessageBody: aws.String("hello"),
+	})
+	assert.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "sqs.SendMessage", s.Tag(ext.ResourceName))
+	assert.Equal(t, "https://sqs.us-west-2.amazonaws.com/123/my-queue", s.Tag(tagQueueURL))
+	assert.Equal(t, "400", s.Tag(ext.HTTPCode))
+	assert.Equal(t, "test-request-id", s.Tag(tagAWSRequestID))
+	assert.NotNil(t, s.Tag(ext.Error))
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, rate interface{}, opts ...Option) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		client := dynamodb.NewFromConfig(newConfig(t, http.StatusOK, "{}", opts...))
+		client.GetItem(context.Background(), &dynamodb.GetItemInput{
+			TableName: aws.String("my-table"),
+			Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}},
+		})
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		assertRate(t, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		assertRate(t, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		assertRate(t, 0.23, WithAnalyticsRate(0.23))
+	})
+}
+
+// captureParams returns an API option recording the input parameters with which
+// the operation is serialized into params.
+func captureParams(params *interface{}) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Serialize.Add(middleware.SerializeMiddlewareFunc("TestCaptureParams",
+			func(ctx context.Context, in middleware.SerializeInput, next middleware.SerializeHandler) (middleware.SerializeOutput, middleware.Metadata, error) {
+				*params = in.Parameters
+				return next.HandleSerialize(ctx, in)
+			}), middleware.After)
+	}
+}
+
+func TestSQSSendMessageBatch(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var sent interface{}
+	cfg := newConfig(t, http.StatusOK,
+		`{"Successful":[{"Id":"1","MessageId":"m1"}],"Failed":[{"Id":"2","Code":"Oops","Message":"failed","SenderFault":true}]}`,
+		WithSQSMessageAttributePropagation(true))
+	cfg.APIOptions = append(cfg.APIOptions, captureParams(&sent))
+	client := sqs.NewFromConfig(cfg)
+	attrs := map[string]sqstypes.MessageAttributeValue{
+		"key": {DataType: aws.String("String"), StringValue: aws.String("value")},
+	}
+	in := &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String("https://sqs.us-west-2.amazonaws.com/123/my-queue"),
+		Entries: []sqstypes.SendMessageBatchRequestEntry{
+			{Id: aws.String("1"), MessageBody: aws.String("one"), MessageAttributes: attrs},
+			{Id: aws.String("2"), MessageBody: aws.String("two")},
+		},
+	}
+	_, err := client.SendMessageBatch(context.Background(), in)
+	assert.NoError(err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	batch := spans[2]
+	assert.Equal("sqs.SendMessageBatch", batch.Tag(ext.ResourceName))
+	messages := map[string]mocktracer.Span{}
+	for _, s := range spans[:2] {
+		assert.Equal("sqs.message", s.OperationName())
+		assert.Equal(batch.SpanID(), s.ParentID())
+		messages[s.Tag(tagMessageID).(string)] = s
+	}
+	assert.Nil(messages["1"].Tag(ext.Error))
+	assert.Equal(true, messages["2"].Tag(ext.Error))
+
+	// the caller's input is left untouched
+	assert.Len(attrs, 1)
+	assert.Nil(in.Entries[1].MessageAttributes)
+
+	// each sent entry carries the context of its own span
+	for _, entry := range sent.(*sqs.SendMessageBatchInput).Entries {
+		spanctx, err := extractAttributes(entry.MessageAttributes)
+		assert.NoError(err)
+		assert.Equal(messages[*entry.Id].SpanID(), spanctx.SpanID())
+	}
+	assert.Equal("value", *sent.(*sqs.SendMessageBatchInput).Entries[0].MessageAttributes["key"].StringValue)
+}
+
+func TestSQSReceiveMessage(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	producer := tracer.StartSpan("producer")
+	attrs := injectAttributes(producer.Context(), nil)
+	producer.Finish()
+	b, err := json.Marshal(map[string]interface{}{
+		"Messages": []map[string]interface{}{
+			{"MessageId": "m1", "Body": "one", "MessageAttributes": map[string]interface{}{
+				sqsAttribute: map[string]string{"DataType": "String", "StringValue": *attrs[sqsAttribute].StringValue},
+			}},
+			{"MessageId": "m2", "Body": "two"},
+		},
+	})
+	assert.NoError(err)
+
+	var sent interface{}
+	cfg := newConfig(t, http.StatusOK, string(b), WithSQSMessageAttributePropagation(true))
+	cfg.APIOptions = append(cfg.APIOptions, captureParams(&sent))
+	client := sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		o.DisableMessageChecksumValidation = true
+	})
+	names := make([]string, 1, 2)
+	names[0] = "key"
+	in := &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String("https://sqs.us-west-2.amazonaws.com/123/my-queue"),
+		MessageAttributeNames: names,
+	}
+	out, err := client.ReceiveMessage(context.Background(), in)
+	assert.NoError(err)
+	assert.Equal([]string{"key"}, in.MessageAttributeNames)
+	assert.Equal([]string{"key", sqsAttribute}, sent.(*sqs.ReceiveMessageInput).MessageAttributeNames)
+	// the spare capacity of the caller's slice is not written to
+	assert.Empty(names[:2][1])
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	receive := spans[1]
+	assert.Equal("sqs.ReceiveMessage", receive.Tag(ext.ResourceName))
+	assert.Equal([]ddtrace.SpanLink{{
+		TraceID:    producer.Context().TraceID(),
+		SpanID:     producer.Context().SpanID(),
+		Attributes: map[string]string{tagMessageID: "m1"},
+	}}, receive.Links())
+
+	assert.Len(out.Messages, 2)
+	spanctx, err := ExtractSQSMessage(out.Messages[0])
+	assert.NoError(err)
+	assert.Equal(producer.Context().TraceID(), spanctx.TraceID())
+	assert.Equal(producer.Context().SpanID(), spanctx.SpanID())
+	_, err = ExtractSQSMessage(out.Messages[1])
+	assert.Equal(tracer.ErrSpanContextNotFound, err)
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aws_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/smithy-go/middleware"
+
+	awstrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/aws-sdk-go-v2/aws"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// To start tracing requests, add the trace middleware to the API options of the config.
+func Example() {
+	cfg := aws.Config{Region: "us-west-2"}
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return awstrace.AppendMiddleware(stack)
+	})
+
+	// all requests made by clients created from the config are traced
+	client := sqs.NewFromConfig(cfg)
+	client.SendMessage(context.Background(), &sqs.SendMessageInput{
+		QueueUrl:    aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/my-queue"),
+		MessageBody: aws.String("hello"),
+	})
+}
+
+// When propagation through SQS message attributes is enabled, the span processing a
+// received message can be started as a child of the span which sent it.
+func ExampleExtractSQSMessage() {
+	cfg := aws.Config{Region: "us-west-2"}
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return awstrace.AppendMiddleware(stack, awstrace.WithSQSMessageAttributePropagation(true))
+	})
+
+	client := sqs.NewFromConfig(cfg)
+	out, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+		QueueUrl: aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/my-queue"),
+	})
+	if err != nil {
+		return
+	}
+	for _, msg := range out.Messages {
+		var opts []tracer.StartSpanOption
+		if spanctx, err := awstrace.ExtractSQSMessage(msg); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+		span := tracer.StartSpan("process.message", opts...)
+		// process the message...
+		span.Finish()
+	}
+}
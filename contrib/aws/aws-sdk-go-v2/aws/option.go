@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aws
+
+import (
+	"math"
+)
+
+type config struct {
+	serviceName    string
+	analyticsRate  float64
+	sqsPropagation bool
+}
+
+// Option represents an option that can be passed to AppendMiddleware.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.analyticsRate = math.NaN()
+}
+
+// WithServiceName sets the given service name for the started spans.
+// When the service name is not explicitly set it will be inferred based on the
+// request to AWS.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithSQSMessageAttributePropagation specifies whether span contexts should be propagated
+// through the attributes of SQS messages. When enabled, each message sent using SendMessageBatch
+// gets a child span of the operation span, and the context of the span which sent a message is
+// injected into its _datadog attribute. ReceiveMessage requests the attribute and links its span
+// to the context propagated by each received message, which ExtractSQSMessage returns so that
+// the processing of the message can continue the trace. It is disabled by default.
+func WithSQSMessageAttributePropagation(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.sqsPropagation = enabled
+	}
+}
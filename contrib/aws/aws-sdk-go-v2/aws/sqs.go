@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aws
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// sqsAttribute is the message attribute holding the propagated span context,
+	// encoded as a JSON object.
+	sqsAttribute = "_datadog"
+	// sqsMaxAttributes is the maximum number of attributes of an SQS message.
+	sqsMaxAttributes = 10
+
+	tagMessageID = "aws.sqs.message_id"
+)
+
+// injectSQS starts a child span of the batch span for each message sent by the given
+// SendMessage or SendMessageBatch input, and injects its context into the attributes
+// of the message. The caller's input is left untouched: the returned parameters hold
+// a copy of it which should be used for the rest of the operation. The returned
+// function, if any, finishes the message spans once the operation completes with the
+// given output and error.
+func (tm *traceMiddleware) injectSQS(batch ddtrace.Span, params interface{}) (interface{}, func(result interface{}, err error)) {
+	switch in := params.(type) {
+	case *sqs.SendMessageInput:
+		cp := *in
+		cp.MessageAttributes = injectAttributes(batch.Context(), in.MessageAttributes)
+		return &cp, nil
+	case *sqs.SendMessageBatchInput:
+		cp := *in
+		cp.Entries = make([]types.SendMessageBatchRequestEntry, len(in.Entries))
+		copy(cp.Entries, in.Entries)
+		spans := make(map[string]ddtrace.Span, len(cp.Entries))
+		for i := range cp.Entries {
+			entry := &cp.Entries[i]
+			span := tm.startMessageSpan(batch.Context(), aws.ToString(entry.Id), ext.SpanTypeMessageProducer)
+			entry.MessageAttributes = injectAttributes(span.Context(), entry.MessageAttributes)
+			spans[aws.ToString(entry.Id)] = span
+		}
+		return &cp, func(result interface{}, err error) {
+			if out, ok := result.(*sqs.SendMessageBatchOutput); ok && err == nil {
+				for _, failed := range out.Failed {
+					if span, ok := spans[aws.ToString(failed.Id)]; ok {
+						span.SetTag(ext.ErrorMsg, aws.ToString(failed.Message))
+						span.SetTag(ext.Error, true)
+					}
+				}
+			}
+			for _, span := range spans {
+				span.Finish(tracer.WithError(err))
+			}
+		}
+	case *sqs.ReceiveMessageInput:
+		// attributes are only received when requested
+		for _, name := range in.MessageAttributeNames {
+			if name == sqsAttribute || name == "All" || name == ".*" {
+				return params, nil
+			}
+		}
+		cp := *in
+		cp.MessageAttributeNames = make([]string, len(in.MessageAttributeNames), len(in.MessageAttributeNames)+1)
+		copy(cp.MessageAttributeNames, in.MessageAttributeNames)
+		cp.MessageAttributeNames = append(cp.MessageAttributeNames, sqsAttribute)
+		return &cp, nil
+	}
+	return params, nil
+}
+
+// extractSQS links the batch span of a ReceiveMessage operation to the span context
+// found in the attributes of each received message. Receiving a message does not
+// process it, so no span is created on behalf of the messages themselves.
+func (tm *traceMiddleware) extractSQS(batch ddtrace.Span, result interface{}) {
+	out, ok := result.(*sqs.ReceiveMessageOutput)
+	if !ok {
+		return
+	}
+	for _, msg := range out.Messages {
+		spanctx, err := extractAttributes(msg.MessageAttributes)
+		if err != nil {
+			continue
+		}
+		batch.AddLink(ddtrace.SpanLink{
+			TraceID:    spanctx.TraceID(),
+			SpanID:     spanctx.SpanID(),
+			Attributes: map[string]string{tagMessageID: aws.ToString(msg.MessageId)},
+		})
+	}
+}
+
+func (tm *traceMiddleware) startMessageSpan(parent ddtrace.SpanContext, id, spanType string) ddtrace.Span {
+	return tracer.StartSpan("sqs.message",
+		tracer.ChildOf(parent),
+		tracer.SpanType(spanType),
+		tracer.ServiceName(tm.serviceName("SQS")),
+		tracer.Tag(tagMessageID, id),
+	)
+}
+
+// injectAttributes returns a copy of attrs with the given span context added. The
+// attributes are returned unchanged if the message has no room left for another attribute.
+func injectAttributes(spanctx ddtrace.SpanContext, attrs map[string]types.MessageAttributeValue) map[string]types.MessageAttributeValue {
+	if _, ok := attrs[sqsAttribute]; !ok && len(attrs) >= sqsMaxAttributes {
+		return attrs
+	}
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(spanctx, carrier); err != nil {
+		return attrs
+	}
+	b, err := json.Marshal(carrier)
+	if err != nil {
+		return attrs
+	}
+	cp := make(map[string]types.MessageAttributeValue, len(attrs)+1)
+	for k, v := range attrs {
+		cp[k] = v
+	}
+	cp[sqsAttribute] = types.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(string(b)),
+	}
+	return cp
+}
+
+// ExtractSQSMessage returns the span context propagated in the attributes of msg
+// when sent with WithSQSMessageAttributePropagation. It allows consumers to start
+// the span processing the message as a child of the span which sent it, e.g.:
+//
+//	spanctx, _ := ExtractSQSMessage(msg)
+//	span := tracer.StartSpan("process.message", tracer.ChildOf(spanctx))
+//
+// The message attribute is only received when WithSQSMessageAttributePropagation is
+// also enabled for the ReceiveMessage call, or when requested explicitly.
+func ExtractSQSMessage(msg types.Message) (ddtrace.SpanContext, error) {
+	return extractAttributes(msg.MessageAttributes)
+}
+
+// extractAttributes returns the span context propagated in the given attributes.
+func extractAttributes(attrs map[string]types.MessageAttributeValue) (ddtrace.SpanContext, error) {
+	attr, ok := attrs[sqsAttribute]
+	if !ok || attr.StringValue == nil {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	carrier := tracer.TextMapCarrier{}
+	if err := json.Unmarshal([]byte(*attr.StringValue), &carrier); err != nil {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	return tracer.Extract(carrier)
+}
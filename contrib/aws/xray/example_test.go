@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package xray_test
+
+import (
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/xray"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	// Start the tracer with X-Ray propagation, so that requests coming through
+	// AWS Lambda, Application Load Balancers or API Gateway continue the trace
+	// started upstream.
+	tracer.Start(xray.WithPropagation())
+	defer tracer.Stop()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		var opts []tracer.StartSpanOption
+		if ctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header)); err == nil {
+			opts = append(opts, tracer.ChildOf(ctx))
+		}
+		span := tracer.StartSpan("web.request", opts...)
+		defer span.Finish()
+	})
+}
@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package xray provides a propagator for the AWS X-Ray trace header format,
+// as injected by AWS Lambda, Application Load Balancers and API Gateway
+// (https://docs.aws.amazon.com/xray/latest/devguide/xray-concepts.html#xray-concepts-tracingheader).
+package xray // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/xray"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// TraceHeader is the header used by AWS to propagate X-Ray trace contexts.
+	TraceHeader = "X-Amzn-Trace-Id"
+
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+// w3c is used to convert Datadog span contexts to X-Ray headers. X-Ray trace
+// IDs are 96 bits long and fit into a W3C trace ID, allowing them to be passed
+// along unchanged.
+var w3c = &tracer.W3CTraceContextPropagator{}
+
+// AWSXRayPropagator implements tracer.Propagator and injects/extracts span
+// contexts using the X-Ray trace header, e.g.:
+//
+//	X-Amzn-Trace-Id: Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+//
+// When extracting, the lower 64 bits of the root ID become the trace ID and
+// the parent segment ID, when present, becomes the parent span ID. The remaining bits are
+// carried along, so that injecting a span context extracted from X-Ray writes
+// back the same root ID. Span contexts started by Datadog are injected using
+// a root ID with a zero timestamp. Only TextMap carriers are supported.
+type AWSXRayPropagator struct{}
+
+var _ tracer.Propagator = (*AWSXRayPropagator)(nil)
+
+// Inject implements tracer.Propagator.
+func (p *AWSXRayPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(tracer.TextMapWriter)
+	if !ok {
+		return tracer.ErrInvalidCarrier
+	}
+	tm := tracer.TextMapCarrier{}
+	if err := w3c.Inject(spanCtx, tm); err != nil {
+		return err
+	}
+	// traceparent is formatted as "00-{trace-id}-{parent-id}-{flags}"
+	tp := tm[traceparentHeader]
+	if len(tp) != 55 {
+		return tracer.ErrInvalidSpanContext
+	}
+	traceID, spanID := tp[3:35], tp[36:52]
+	sampled := "0"
+	switch {
+	case tp[53:55] == "01":
+		sampled = "1"
+	case !strings.Contains(tm[tracestateHeader], "dd=s:"):
+		// no sampling decision was made yet
+		sampled = "?"
+	}
+	writer.Set(TraceHeader, fmt.Sprintf("Root=1-%s-%s;Parent=%s;Sampled=%s", traceID[:8], traceID[8:], spanID, sampled))
+	return nil
+}
+
+// Extract implements tracer.Propagator. When the header has no parent segment
+// ID, such as when it was created by an Application Load Balancer, spans started
+// from the extracted context are roots of the X-Ray trace. A sampling decision is
+// only extracted from "Sampled=1" and "Sampled=0": when it is missing or deferred
+// ("?"), the decision is left to the tracer.
+func (p *AWSXRayPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	reader, ok := carrier.(tracer.TextMapReader)
+	if !ok {
+		return nil, tracer.ErrInvalidCarrier
+	}
+	var header string
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.EqualFold(k, TraceHeader) {
+			header = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if header == "" {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	var root, parent, sampled string
+	for _, field := range strings.Split(header, ";") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Root":
+			root = kv[1]
+		case "Parent":
+			parent = kv[1]
+		case "Sampled":
+			sampled = kv[1]
+		}
+	}
+	if root == "" {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	// the root is formatted as "1-{8 hex digits epoch}-{24 hex digits identifier}"
+	parts := strings.Split(root, "-")
+	if len(parts) != 3 || parts[0] != "1" || len(parts[1]) != 8 || len(parts[2]) != 24 {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	high, err := strconv.ParseUint(parts[1]+parts[2][:8], 16, 64)
+	if err != nil {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	traceID, err := strconv.ParseUint(parts[2][8:], 16, 64)
+	if err != nil {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	if traceID == 0 {
+		// can not be represented as a Datadog trace ID
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	var spanID uint64
+	if parent != "" {
+		if len(parent) != 16 {
+			return nil, tracer.ErrSpanContextCorrupted
+		}
+		if spanID, err = strconv.ParseUint(parent, 16, 64); err != nil || spanID == 0 {
+			return nil, tracer.ErrSpanContextCorrupted
+		}
+	}
+	var priority *int
+	switch sampled {
+	case "1":
+		keep := ext.PriorityAutoKeep
+		priority = &keep
+	case "0":
+		reject := ext.PriorityAutoReject
+		priority = &reject
+	}
+	return tracer.RemoteSpanContext(high, traceID, spanID, priority), nil
+}
+
+// WithPropagation returns a tracer.StartOption which registers a propagator
+// that handles X-Ray headers along with the default Datadog ones. Datadog
+// headers take precedence when extracting, and both formats are injected.
+func WithPropagation() tracer.StartOption {
+	return tracer.WithPropagator(tracer.CompositePropagator(
+		tracer.NewPropagator(nil),
+		&AWSXRayPropagator{},
+	))
+}
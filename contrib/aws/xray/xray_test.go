@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package xray
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const header = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+func TestExtract(t *testing.T) {
+	assert := assert.New(t)
+	p := &AWSXRayPropagator{}
+
+	h := http.Header{}
+	h.Set(TraceHeader, header)
+	ctx, err := p.Extract(tracer.HTTPHeadersCarrier(h))
+	assert.Nil(err)
+	assert.Equal(uint64(0xe1be46a994272793), ctx.TraceID())
+	assert.Equal(uint64(0x53995c3f42cd8ad8), ctx.SpanID())
+
+	// the root ID is written back unchanged
+	out := tracer.TextMapCarrier{}
+	assert.Nil(p.Inject(ctx, out))
+	assert.Equal(header, out[TraceHeader])
+
+	t.Run("not-sampled", func(t *testing.T) {
+		ctx, err := p.Extract(tracer.TextMapCarrier{
+			TraceHeader: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=0",
+		})
+		assert.Nil(err)
+		out := tracer.TextMapCarrier{}
+		assert.Nil(p.Inject(ctx, out))
+		assert.Contains(out[TraceHeader], "Sampled=0")
+	})
+
+	t.Run("sampling-decision", func(t *testing.T) {
+		for in, want := range map[string]string{
+			"Sampled=1": "Sampled=1",
+			"Sampled=0": "Sampled=0",
+			"Sampled=?": "Sampled=?",
+			"Self=1":    "Sampled=?",
+		} {
+			ctx, err := p.Extract(tracer.TextMapCarrier{
+				TraceHeader: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;" + in,
+			})
+			assert.Nil(err)
+			out := tracer.TextMapCarrier{}
+			assert.Nil(p.Inject(ctx, out))
+			assert.Contains(out[TraceHeader], want, in)
+		}
+	})
+
+	t.Run("no-parent", func(t *testing.T) {
+		// as sent by Application Load Balancers
+		ctx, err := p.Extract(tracer.TextMapCarrier{
+			TraceHeader: "Self=1-67891234-12456789abcdef012345678;Root=1-5759e988-bd862e3fe1be46a994272793",
+		})
+		assert.Nil(err)
+		assert.Equal(uint64(0xe1be46a994272793), ctx.TraceID())
+		assert.Equal(uint64(0), ctx.SpanID())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for v, want := range map[string]error{
+			"":                        tracer.ErrSpanContextNotFound,
+			"Parent=53995c3f42cd8ad8": tracer.ErrSpanContextNotFound,
+			"Root=1-5759e988-bd862e3f0000000000000000":                         tracer.ErrSpanContextNotFound,
+			"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=5399":             tracer.ErrSpanContextCorrupted,
+			"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=0000000000000000": tracer.ErrSpanContextCorrupted,
+			"Root=1-5759e988-bd86;Parent=53995c3f42cd8ad8":                     tracer.ErrSpanContextCorrupted,
+			"Root=2-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8": tracer.ErrSpanContextCorrupted,
+			"Root=1-5759e988-bd862e3fe1be46a99427279x;Parent=53995c3f42cd8ad8": tracer.ErrSpanContextCorrupted,
+		} {
+			_, err := p.Extract(tracer.TextMapCarrier{TraceHeader: v})
+			assert.Equal(want, err, v)
+		}
+		_, err := p.Extract(nil)
+		assert.Equal(tracer.ErrInvalidCarrier, err)
+	})
+}
+
+func TestInject(t *testing.T) {
+	assert := assert.New(t)
+	ctx, err := tracer.NewPropagator(nil).Extract(tracer.TextMapCarrier{
+		tracer.DefaultTraceIDHeader:  "1",
+		tracer.DefaultParentIDHeader: "2",
+		tracer.DefaultPriorityHeader: "2",
+	})
+	assert.Nil(err)
+	out := tracer.TextMapCarrier{}
+	assert.Nil((&AWSXRayPropagator{}).Inject(ctx, out))
+	assert.Equal("Root=1-00000000-000000000000000000000001;Parent=0000000000000002;Sampled=1", out[TraceHeader])
+
+	ctx, err = tracer.NewPropagator(nil).Extract(tracer.TextMapCarrier{
+		tracer.DefaultTraceIDHeader:  "1",
+		tracer.DefaultParentIDHeader: "2",
+	})
+	assert.Nil(err)
+	assert.Nil((&AWSXRayPropagator{}).Inject(ctx, out))
+	assert.Contains(out[TraceHeader], "Sampled=?")
+}
+
+func TestWithPropagation(t *testing.T) {
+	assert := assert.New(t)
+	tracer.Start(WithPropagation())
+	defer tracer.Stop()
+
+	ctx, err := tracer.Extract(tracer.TextMapCarrier{TraceHeader: header})
+	assert.Nil(err)
+	span := tracer.StartSpan("lambda", tracer.ChildOf(ctx))
+	defer span.Finish()
+	assert.Equal(uint64(0xe1be46a994272793), span.Context().TraceID())
+
+	out := tracer.TextMapCarrier{}
+	assert.Nil(tracer.Inject(span.Context(), out))
+	assert.Equal(strconv.FormatUint(0xe1be46a994272793, 10), out[tracer.DefaultTraceIDHeader])
+	assert.Contains(out[TraceHeader], "Root=1-5759e988-bd862e3fe1be46a994272793;")
+
+	// spans started from a root-only header are roots of the X-Ray trace
+	root := tracer.StartSpan("alb", tracer.ChildOf(mustExtract(t, "Root=1-5759e988-bd862e3fe1be46a994272793")))
+	defer root.Finish()
+	assert.Equal(uint64(0xe1be46a994272793), root.Context().TraceID())
+	out = tracer.TextMapCarrier{}
+	assert.Nil(tracer.Inject(root.Context(), out))
+	assert.Contains(out[TraceHeader], "Root=1-5759e988-bd862e3fe1be46a994272793;")
+
+	// Datadog headers take precedence
+	ctx, err = tracer.Extract(tracer.TextMapCarrier{
+		TraceHeader:                  header,
+		tracer.DefaultTraceIDHeader:  "1",
+		tracer.DefaultParentIDHeader: "2",
+	})
+	assert.Nil(err)
+	assert.Equal(uint64(1), ctx.TraceID())
+}
+
+func mustExtract(t *testing.T, header string) ddtrace.SpanContext {
+	ctx, err := tracer.Extract(tracer.TextMapCarrier{TraceHeader: header})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
@@ -25,3 +25,10 @@ func Example() {
 	mc.WithContext(ctx).Set(&memcache.Item{Key: "my key", Value: []byte("my value")})
 
 }
+
+func ExampleNewClient() {
+	// NewClient knows the servers of the client, so spans are also tagged with
+	// the address of the server holding each key
+	mc := memcachetrace.NewClient("10.0.0.1:11211", "10.0.0.2:11211")
+	mc.Get("my key")
+}
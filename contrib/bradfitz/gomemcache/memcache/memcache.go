@@ -6,7 +6,8 @@
 // Package memcache provides functions to trace the bradfitz/gomemcache package (https://github.com/bradfitz/gomemcache).
 //
 // `WrapClient` will wrap a memcache `Client` and return a new struct with all
-// the same methods, so should be seamless for existing applications. It also
+// the same methods, so should be seamless for existing applications. `NewClient`
+// creates and wraps a client for the given servers, as `memcache.New` does. It also
 // has an additional `WithContext` method which can be used to connect a span
 // to an existing trace.
 package memcache // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/bradfitz/gomemcache/memcache"
@@ -14,13 +15,34 @@ package memcache // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/bradfitz/gom
 import (
 	"context"
 	"math"
+	"strconv"
 
 	"github.com/bradfitz/gomemcache/memcache"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
 
+const (
+	tagCommand   = "memcached.command"
+	tagKey       = "memcached.key"
+	tagKeysCount = "memcached.keys_count"
+	tagHit       = "memcached.hit"
+	tagPeerAddr  = "peer.address"
+)
+
+// NewClient returns a traced client for the given memcached servers, as memcache.New
+// does. Spans are tagged with the address of the server each key maps to.
+func NewClient(server ...string) *Client {
+	ss := new(memcache.ServerList)
+	if err := ss.SetServers(server...); err != nil {
+		// memcache.New ignores this error too, the client then fails on use
+		log.Error("memcache: invalid servers: %v", err)
+	}
+	return WrapClient(memcache.NewFromSelector(ss), WithServerSelector(ss))
+}
+
 // WrapClient wraps a memcache.Client so that all requests are traced using the
 // default tracer with the service name "memcached".
 func WrapClient(client *memcache.Client, opts ...ClientOption) *Client {
@@ -60,16 +82,35 @@ func (c *Client) WithContext(ctx context.Context) *Client {
 	}
 }
 
-// startSpan starts a span from the context set with WithContext.
-func (c *Client) startSpan(resourceName string) ddtrace.Span {
+// startSpan starts a span from the context set with WithContext. If keys holds a
+// single key, the span is tagged with it and the address of its server, otherwise
+// with the number of keys.
+func (c *Client) startSpan(resourceName string, keys ...string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeMemcached),
 		tracer.ServiceName(c.cfg.serviceName),
 		tracer.ResourceName(resourceName),
+		tracer.Tag(tagCommand, resourceName),
 	}
 	if !math.IsNaN(c.cfg.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
 	}
+	switch {
+	case len(keys) == 1:
+		key := keys[0]
+		if c.cfg.scrubKeys {
+			opts = append(opts, tracer.Tag(tagKey, "?"))
+		} else {
+			opts = append(opts, tracer.Tag(tagKey, key))
+		}
+		if c.cfg.selector != nil {
+			if addr, err := c.cfg.selector.PickServer(key); err == nil {
+				opts = append(opts, tracer.Tag(tagPeerAddr, addr.String()))
+			}
+		}
+	case len(keys) > 1:
+		opts = append(opts, tracer.Tag(tagKeysCount, strconv.Itoa(len(keys))))
+	}
 	span, _ := tracer.StartSpanFromContext(c.context, operationName, opts...)
 	return span
 }
@@ -78,7 +119,7 @@ func (c *Client) startSpan(resourceName string) ddtrace.Span {
 
 // Add invokes and traces Client.Add.
 func (c *Client) Add(item *memcache.Item) error {
-	span := c.startSpan("Add")
+	span := c.startSpan("Add", item.Key)
 	err := c.Client.Add(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -86,7 +127,7 @@ func (c *Client) Add(item *memcache.Item) error {
 
 // CompareAndSwap invokes and traces Client.CompareAndSwap.
 func (c *Client) CompareAndSwap(item *memcache.Item) error {
-	span := c.startSpan("CompareAndSwap")
+	span := c.startSpan("CompareAndSwap", item.Key)
 	err := c.Client.CompareAndSwap(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -94,7 +135,7 @@ func (c *Client) CompareAndSwap(item *memcache.Item) error {
 
 // Decrement invokes and traces Client.Decrement.
 func (c *Client) Decrement(key string, delta uint64) (newValue uint64, err error) {
-	span := c.startSpan("Decrement")
+	span := c.startSpan("Decrement", key)
 	newValue, err = c.Client.Decrement(key, delta)
 	span.Finish(tracer.WithError(err))
 	return newValue, err
@@ -102,7 +143,7 @@ func (c *Client) Decrement(key string, delta uint64) (newValue uint64, err error
 
 // Delete invokes and traces Client.Delete.
 func (c *Client) Delete(key string) error {
-	span := c.startSpan("Delete")
+	span := c.startSpan("Delete", key)
 	err := c.Client.Delete(key)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -124,17 +165,24 @@ func (c *Client) FlushAll() error {
 	return err
 }
 
-// Get invokes and traces Client.Get.
+// Get invokes and traces Client.Get. Its span is tagged with whether the key was found;
+// cache misses are not reported as errors.
 func (c *Client) Get(key string) (item *memcache.Item, err error) {
-	span := c.startSpan("Get")
+	span := c.startSpan("Get", key)
 	item, err = c.Client.Get(key)
-	span.Finish(tracer.WithError(err))
+	span.SetTag(tagHit, err == nil)
+	if err == memcache.ErrCacheMiss {
+		span.Finish()
+	} else {
+		span.Finish(tracer.WithError(err))
+	}
 	return item, err
 }
 
 // GetMulti invokes and traces Client.GetMulti.
 func (c *Client) GetMulti(keys []string) (map[string]*memcache.Item, error) {
 	span := c.startSpan("GetMulti")
+	span.SetTag(tagKeysCount, strconv.Itoa(len(keys)))
 	items, err := c.Client.GetMulti(keys)
 	span.Finish(tracer.WithError(err))
 	return items, err
@@ -142,7 +190,7 @@ func (c *Client) GetMulti(keys []string) (map[string]*memcache.Item, error) {
 
 // Increment invokes and traces Client.Increment.
 func (c *Client) Increment(key string, delta uint64) (newValue uint64, err error) {
-	span := c.startSpan("Increment")
+	span := c.startSpan("Increment", key)
 	newValue, err = c.Client.Increment(key, delta)
 	span.Finish(tracer.WithError(err))
 	return newValue, err
@@ -150,7 +198,7 @@ func (c *Client) Increment(key string, delta uint64) (newValue uint64, err error
 
 // Replace invokes and traces Client.Replace.
 func (c *Client) Replace(item *memcache.Item) error {
-	span := c.startSpan("Replace")
+	span := c.startSpan("Replace", item.Key)
 	err := c.Client.Replace(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -158,7 +206,7 @@ func (c *Client) Replace(item *memcache.Item) error {
 
 // Set invokes and traces Client.Set.
 func (c *Client) Set(item *memcache.Item) error {
-	span := c.startSpan("Set")
+	span := c.startSpan("Set", item.Key)
 	err := c.Client.Set(item)
 	span.Finish(tracer.WithError(err))
 	return err
@@ -166,7 +214,7 @@ func (c *Client) Set(item *memcache.Item) error {
 
 // Touch invokes and traces Client.Touch.
 func (c *Client) Touch(key string, seconds int32) error {
-	span := c.startSpan("Touch")
+	span := c.startSpan("Touch", key)
 	err := c.Client.Touch(key, seconds)
 	span.Finish(tracer.WithError(err))
 	return err
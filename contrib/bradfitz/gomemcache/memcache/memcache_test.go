@@ -92,6 +92,74 @@ func testMemcache(t *testing.T, addr string) {
 	})
 }
 
+func TestTags(t *testing.T) {
+	li := makeFakeServer(t)
+	defer li.Close()
+	addr := li.Addr().String()
+
+	t.Run("keys", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		client := NewClient(addr)
+		assert.NoError(client.Set(&memcache.Item{Key: "user:42", Value: []byte("value")}))
+		_, err := client.Get("hit:1")
+		assert.NoError(err)
+		_, err = client.Get("miss:1")
+		assert.Equal(memcache.ErrCacheMiss, err)
+		_, err = client.GetMulti([]string{"hit:1", "hit:2", "miss:1"})
+		assert.NoError(err)
+		assert.NoError(client.Delete("user:42"))
+		_, err = client.Increment("counter", 1)
+		assert.NoError(err)
+		_, err = client.Decrement("counter", 1)
+		assert.NoError(err)
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 7)
+		for i, want := range []struct{ command, key string }{
+			{"Set", "user:42"},
+			{"Get", "hit:1"},
+			{"Get", "miss:1"},
+			{"GetMulti", ""},
+			{"Delete", "user:42"},
+			{"Increment", "counter"},
+			{"Decrement", "counter"},
+		} {
+			s := spans[i]
+			assert.Equal(want.command, s.Tag(ext.ResourceName))
+			assert.Equal(want.command, s.Tag(tagCommand))
+			assert.Nil(s.Tag(ext.Error))
+			if want.key == "" {
+				continue
+			}
+			assert.Equal(want.key, s.Tag(tagKey))
+			assert.Equal(addr, s.Tag(tagPeerAddr))
+		}
+		assert.Equal(true, spans[1].Tag(tagHit))
+		assert.Equal(false, spans[2].Tag(tagHit))
+		assert.Equal("3", spans[3].Tag(tagKeysCount))
+		assert.Nil(spans[3].Tag(tagKey))
+	})
+
+	t.Run("scrub", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		client := WrapClient(memcache.New(addr), WithScrubKeys(true))
+		_, err := client.Get("hit:user@example.com")
+		assert.NoError(err)
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal("?", spans[0].Tag(tagKey))
+		// the server of the key is unknown without a selector
+		assert.Nil(spans[0].Tag(tagPeerAddr))
+	})
+}
+
 func TestFakeServer(t *testing.T) {
 	li := makeFakeServer(t)
 	defer li.Close()
@@ -194,11 +262,23 @@ func makeFakeServer(t *testing.T) net.Listener {
 				for s.Scan() {
 					args := strings.Split(s.Text(), " ")
 					switch args[0] {
-					case "add":
+					case "add", "set":
 						if !s.Scan() {
 							return
 						}
 						fmt.Fprintf(c, "STORED\r\n")
+					case "gets":
+						// keys starting with "hit" are found
+						for _, key := range args[1:] {
+							if strings.HasPrefix(key, "hit") {
+								fmt.Fprintf(c, "VALUE %s 0 5 1\r\nvalue\r\n", key)
+							}
+						}
+						fmt.Fprintf(c, "END\r\n")
+					case "delete":
+						fmt.Fprintf(c, "DELETED\r\n")
+					case "incr", "decr":
+						fmt.Fprintf(c, "2\r\n")
 					default:
 						fmt.Fprintf(c, "SERVER ERROR unknown command: %v \r\n", args[0])
 						return
@@ -7,6 +7,8 @@ package memcache
 
 import (
 	"math"
+
+	"github.com/bradfitz/gomemcache/memcache"
 )
 
 const (
@@ -17,6 +19,9 @@ const (
 type clientConfig struct {
 	serviceName   string
 	analyticsRate float64
+	scrubKeys     bool
+	// selector is used to find the server of keys, when known.
+	selector memcache.ServerSelector
 }
 
 // ClientOption represents an option that can be passed to Dial.
@@ -57,3 +62,20 @@ func WithAnalyticsRate(rate float64) ClientOption {
 		}
 	}
 }
+
+// WithScrubKeys replaces the keys set as the memcached.key tag with "?", for when keys
+// contain user identifiers or other sensitive data.
+func WithScrubKeys(enabled bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.scrubKeys = enabled
+	}
+}
+
+// WithServerSelector sets the selector the wrapped client was created with, using
+// memcache.NewFromSelector. It is used to tag spans with the address of the server
+// that a key maps to, as peer.address. NewClient sets it automatically.
+func WithServerSelector(ss memcache.ServerSelector) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.selector = ss
+	}
+}
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package pubsub_test
+
+import (
+	"context"
+	"log"
+
+	"cloud.google.com/go/pubsub"
+
+	pubsubtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/cloud.google.com/go/pubsub"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func ExamplePublish() {
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, "my-project")
+	if err != nil {
+		log.Fatal(err)
+	}
+	topic := client.Topic("my-topic")
+
+	// the publish span is finished once the message is published, even if Get is never called
+	id, err := pubsubtrace.Publish(ctx, topic, &pubsub.Message{Data: []byte("hello")}).Get(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("published message %s", id)
+}
+
+func ExampleReceive() {
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, "my-project")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sub := client.Subscription("my-subscription")
+
+	err = pubsubtrace.Receive(ctx, sub, func(ctx context.Context, msg *pubsub.Message) error {
+		// ctx carries the consumer span; returning an error nacks the message
+		span, _ := tracer.StartSpanFromContext(ctx, "process.message")
+		defer span.Finish()
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package pubsub provides functions to trace the cloud.google.com/go/pubsub package
+// (https://pkg.go.dev/cloud.google.com/go/pubsub). Trace contexts are propagated
+// through the attributes of the published messages.
+package pubsub // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/cloud.google.com/go/pubsub"
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"cloud.google.com/go/pubsub"
+)
+
+const (
+	tagTopic           = "pubsub.topic"
+	tagSubscription    = "pubsub.subscription"
+	tagMessageID       = "pubsub.message_id"
+	tagOrderingKey     = "pubsub.ordering_key"
+	tagPublishTime     = "pubsub.publish_time"
+	tagDeliveryAttempt = "pubsub.delivery_attempt"
+	tagAckResult       = "pubsub.ack_result"
+)
+
+// Publish publishes msg on the given topic, starting a producer span and injecting its
+// context into the attributes of the message. The span is finished, and tagged with the
+// server-generated message ID, once the result is ready, whether or not Get is called.
+func Publish(ctx context.Context, t *pubsub.Topic, msg *pubsub.Message, opts ...Option) *pubsub.PublishResult {
+	cfg := newConfig(opts...)
+	spanOpts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName(t.String()),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(tagTopic, t.String()),
+	}
+	if msg.OrderingKey != "" {
+		spanOpts = append(spanOpts, tracer.Tag(tagOrderingKey, msg.OrderingKey))
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, "pubsub.publish", spanOpts...)
+	if msg.Attributes == nil {
+		msg.Attributes = make(map[string]string)
+	}
+	tracer.Inject(span.Context(), tracer.TextMapCarrier(msg.Attributes))
+	res := t.Publish(ctx, msg)
+	go func() {
+		<-res.Ready()
+		id, err := res.Get(context.Background())
+		if err == nil {
+			span.SetTag(tagMessageID, id)
+		}
+		span.Finish(tracer.WithError(err))
+	}()
+	return res
+}
+
+// Receive calls s.Receive, starting a consumer span for each message as a child of the
+// span context found in its attributes. The span covers the call to f, which is given a
+// context containing it. The message is acknowledged when f returns nil, and negatively
+// acknowledged otherwise.
+func Receive(ctx context.Context, s *pubsub.Subscription, f func(context.Context, *pubsub.Message) error, opts ...Option) error {
+	return s.Receive(ctx, WrapReceiveHandler(s, f, opts...))
+}
+
+// WrapReceiveHandler returns a handler for s.Receive which traces each call to f, as
+// described in Receive.
+func WrapReceiveHandler(s *pubsub.Subscription, f func(context.Context, *pubsub.Message) error, opts ...Option) func(context.Context, *pubsub.Message) {
+	cfg := newConfig(opts...)
+	return func(ctx context.Context, msg *pubsub.Message) {
+		spanOpts := []ddtrace.StartSpanOption{
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(s.String()),
+			tracer.SpanType(ext.SpanTypeMessageConsumer),
+			tracer.Tag(tagSubscription, s.String()),
+			tracer.Tag(tagMessageID, msg.ID),
+			tracer.Tag(tagPublishTime, msg.PublishTime.String()),
+		}
+		if msg.OrderingKey != "" {
+			spanOpts = append(spanOpts, tracer.Tag(tagOrderingKey, msg.OrderingKey))
+		}
+		if msg.DeliveryAttempt != nil {
+			spanOpts = append(spanOpts, tracer.Tag(tagDeliveryAttempt, *msg.DeliveryAttempt))
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			spanOpts = append(spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		if spanctx, err := tracer.Extract(tracer.TextMapCarrier(msg.Attributes)); err == nil {
+			spanOpts = append(spanOpts, tracer.ChildOf(spanctx))
+		}
+		span := tracer.StartSpan("pubsub.receive", spanOpts...)
+		err := f(tracer.ContextWithSpan(ctx, span), msg)
+		if err != nil {
+			msg.Nack()
+			span.SetTag(tagAckResult, "nack")
+		} else {
+			msg.Ack()
+			span.SetTag(tagAckResult, "ack")
+		}
+		span.Finish(tracer.WithError(err))
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	cfg := new(config)
+	defaults(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func setup(t *testing.T) (*pubsub.Topic, *pubsub.Subscription) {
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, "project", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	topic, err := client.CreateTopic(ctx, "topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(topic.Stop)
+	sub, err := client.CreateSubscription(ctx, "subscription", pubsub.SubscriptionConfig{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return topic, sub
+}
+
+// receiveOne receives a single message from sub, handling it with f.
+func receiveOne(t *testing.T, sub *pubsub.Subscription, f func(context.Context, *pubsub.Message) error, opts ...Option) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Receive(ctx, sub, func(ctx context.Context, msg *pubsub.Message) error {
+		defer cancel()
+		return f(ctx, msg)
+	}, opts...)
+	assert.NoError(t, err)
+}
+
+// finishedSpans waits for mt to have n finished spans, since publish spans are
+// finished asynchronously, and returns them.
+func finishedSpans(t *testing.T, mt mocktracer.Tracer, n int) []mocktracer.Span {
+	deadline := time.Now().Add(5 * time.Second)
+	for len(mt.FinishedSpans()) < n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, n)
+	return spans
+}
+
+// spanNamed returns the first span in spans with the given operation name.
+func spanNamed(t *testing.T, spans []mocktracer.Span, name string) mocktracer.Span {
+	for _, s := range spans {
+		if s.OperationName() == name {
+			return s
+		}
+	}
+	t.Fatalf("no %q span", name)
+	return nil
+}
+
+func TestPublishReceive(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	topic, sub := setup(t)
+	ctx := context.Background()
+
+	parent, pctx := tracer.StartSpanFromContext(ctx, "parent")
+	id, err := Publish(pctx, topic, &pubsub.Message{Data: []byte("hello")}).Get(ctx)
+	assert.NoError(err)
+	parent.Finish()
+
+	var received *pubsub.Message
+	receiveOne(t, sub, func(ctx context.Context, msg *pubsub.Message) error {
+		received = msg
+		span, ok := tracer.SpanFromContext(ctx)
+		assert.True(ok)
+		assert.NotNil(span)
+		return nil
+	}, WithServiceName("my-pubsub"))
+	assert.Equal("hello", string(received.Data))
+
+	spans := finishedSpans(t, mt, 3)
+	pub, par, recv := spanNamed(t, spans, "pubsub.publish"), spanNamed(t, spans, "parent"), spanNamed(t, spans, "pubsub.receive")
+
+	assert.Equal("pubsub.publish", pub.OperationName())
+	assert.Equal("projects/project/topics/topic", pub.Tag(ext.ResourceName))
+	assert.Equal("projects/project/topics/topic", pub.Tag(tagTopic))
+	assert.Equal("pubsub", pub.Tag(ext.ServiceName))
+	assert.Equal(ext.SpanTypeMessageProducer, pub.Tag(ext.SpanType))
+	assert.Equal(id, pub.Tag(tagMessageID))
+	assert.Equal(par.SpanID(), pub.ParentID())
+
+	assert.Equal("pubsub.receive", recv.OperationName())
+	assert.Equal("projects/project/subscriptions/subscription", recv.Tag(ext.ResourceName))
+	assert.Equal("projects/project/subscriptions/subscription", recv.Tag(tagSubscription))
+	assert.Equal("my-pubsub", recv.Tag(ext.ServiceName))
+	assert.Equal(ext.SpanTypeMessageConsumer, recv.Tag(ext.SpanType))
+	assert.Equal(id, recv.Tag(tagMessageID))
+	assert.Equal("ack", recv.Tag(tagAckResult))
+	assert.Equal(pub.SpanID(), recv.ParentID())
+	assert.Equal(pub.TraceID(), recv.TraceID())
+}
+
+func TestReceiveNack(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	topic, sub := setup(t)
+	ctx := context.Background()
+
+	_, err := Publish(ctx, topic, &pubsub.Message{Data: []byte("hello")}).Get(ctx)
+	assert.NoError(err)
+
+	fail := errors.New("processing failed")
+	receiveOne(t, sub, func(context.Context, *pubsub.Message) error {
+		return fail
+	})
+
+	spans := finishedSpans(t, mt, 2)
+	recv := spanNamed(t, spans, "pubsub.receive")
+	assert.Equal("nack", recv.Tag(tagAckResult))
+	assert.Equal(fail, recv.Tag(ext.Error))
+}
+
+func TestPublishWithoutGet(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	topic, sub := setup(t)
+	ctx := context.Background()
+
+	Publish(ctx, topic, &pubsub.Message{Data: []byte("hello")})
+	var id string
+	receiveOne(t, sub, func(_ context.Context, msg *pubsub.Message) error {
+		id = msg.ID
+		return nil
+	})
+
+	spans := finishedSpans(t, mt, 2)
+	pub := spanNamed(t, spans, "pubsub.publish")
+	assert.Equal(id, pub.Tag(tagMessageID))
+	assert.Nil(pub.Tag(ext.Error))
+}
+
+func TestPublishError(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	topic, _ := setup(t)
+	topic.Stop()
+
+	_, err := Publish(context.Background(), topic, &pubsub.Message{Data: []byte("hello")}).Get(context.Background())
+	assert.Error(err)
+
+	spans := finishedSpans(t, mt, 1)
+	assert.Equal(err, spans[0].Tag(ext.Error))
+	assert.Nil(spans[0].Tag(tagMessageID))
+}
+
+func TestPublishUntraced(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	topic, sub := setup(t)
+	ctx := context.Background()
+
+	_, err := topic.Publish(ctx, &pubsub.Message{Data: []byte("hello")}).Get(ctx)
+	assert.NoError(err)
+	receiveOne(t, sub, func(context.Context, *pubsub.Message) error { return nil })
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("pubsub.receive", spans[0].OperationName())
+	assert.Equal(uint64(0), spans[0].ParentID())
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		topic, sub := setup(t)
+		ctx := context.Background()
+
+		_, err := Publish(ctx, topic, &pubsub.Message{Data: []byte("hello")}, opts...).Get(ctx)
+		assert.NoError(t, err)
+		receiveOne(t, sub, func(context.Context, *pubsub.Message) error { return nil }, opts...)
+
+		spans := finishedSpans(t, mt, 2)
+		for _, s := range spans {
+			assert.Equal(t, rate, s.Tag(ext.EventSampleRate))
+		}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package trace_test
+
+import (
+	gcptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/cloud.google.com/go/trace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	// Continue traces started by Google Cloud load balancers or Cloud Run,
+	// while still propagating Datadog headers.
+	tracer.Start(tracer.WithPropagator(tracer.CompositePropagator(
+		tracer.NewPropagator(nil),
+		&gcptrace.GCPPropagator{},
+	)))
+	defer tracer.Stop()
+}
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package trace provides a propagator for the Google Cloud Trace header format,
+// as injected by Google Cloud load balancers and Cloud Run
+// (https://cloud.google.com/trace/docs/setup#force-trace).
+package trace // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/cloud.google.com/go/trace"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// TraceHeader is the header used by Google Cloud to propagate trace contexts.
+	TraceHeader = "X-Cloud-Trace-Context"
+
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+// w3c is used to convert Datadog span contexts to Google Cloud trace headers,
+// since both formats use 128-bit trace IDs.
+var w3c = &tracer.W3CTraceContextPropagator{}
+
+// GCPPropagator implements tracer.Propagator and injects/extracts span contexts
+// using the Google Cloud Trace header, e.g.:
+//
+//	X-Cloud-Trace-Context: 105445aa7843bc8bf206b12000100000/1;o=1
+//
+// The trace ID is 32 hex digits long, of which the lower 64 bits become the
+// Datadog trace ID; the remaining bits are carried along so that injecting an
+// extracted span context writes back the same trace ID. The span ID is decimal.
+// The "o=1" option, set when the request is traced by Google Cloud, maps to
+// ext.PriorityAutoKeep. "o=0" only means that Google Cloud didn't trace the
+// request, so it is extracted without a sampling priority, as are missing or
+// unknown options, leaving the decision to the Datadog sampler. When injecting,
+// "o=1" or "o=0" is written once a sampling decision was made, and the option
+// is omitted otherwise.
+//
+// The propagator only handles this header. It may be used standalone with
+// tracer.WithPropagator, or combined with the default one using
+// tracer.CompositePropagator. Only TextMap carriers are supported.
+type GCPPropagator struct{}
+
+var _ tracer.Propagator = (*GCPPropagator)(nil)
+
+// Inject implements tracer.Propagator.
+func (p *GCPPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(tracer.TextMapWriter)
+	if !ok {
+		return tracer.ErrInvalidCarrier
+	}
+	tm := tracer.TextMapCarrier{}
+	if err := w3c.Inject(spanCtx, tm); err != nil {
+		return err
+	}
+	// traceparent is formatted as "00-{trace-id}-{parent-id}-{flags}"
+	tp := tm[traceparentHeader]
+	if len(tp) != 55 {
+		return tracer.ErrInvalidSpanContext
+	}
+	options := ";o=0"
+	switch {
+	case tp[53:55] == "01":
+		options = ";o=1"
+	case !strings.Contains(tm[tracestateHeader], "dd=s:"):
+		// no sampling decision was made yet
+		options = ""
+	}
+	writer.Set(TraceHeader, fmt.Sprintf("%s/%d%s", tp[3:35], spanCtx.SpanID(), options))
+	return nil
+}
+
+// Extract implements tracer.Propagator.
+func (p *GCPPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	reader, ok := carrier.(tracer.TextMapReader)
+	if !ok {
+		return nil, tracer.ErrInvalidCarrier
+	}
+	var header string
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.EqualFold(k, TraceHeader) {
+			header = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if header == "" {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	// {trace-id}/{span-id};o={options}
+	ids, options := header, ""
+	if i := strings.IndexByte(header, ';'); i >= 0 {
+		ids, options = header[:i], header[i+1:]
+	}
+	parts := strings.Split(ids, "/")
+	if len(parts) != 2 || len(parts[0]) != 32 {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	high, err := strconv.ParseUint(parts[0][:16], 16, 64)
+	if err != nil {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	traceID, err := strconv.ParseUint(parts[0][16:], 16, 64)
+	if err != nil {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	spanID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || spanID == 0 || (high == 0 && traceID == 0) {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	if traceID == 0 {
+		// can not be represented as a Datadog trace ID
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	var priority *int
+	if options == "o=1" {
+		keep := ext.PriorityAutoKeep
+		priority = &keep
+	}
+	return tracer.RemoteSpanContext(high, traceID, spanID, priority), nil
+}
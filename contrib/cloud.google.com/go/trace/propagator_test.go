@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package trace
+
+import (
+	"net/http"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	assert := assert.New(t)
+	p := &GCPPropagator{}
+
+	h := http.Header{}
+	h.Set(TraceHeader, "105445aa7843bc8bf206b12000100000/123;o=1")
+	ctx, err := p.Extract(tracer.HTTPHeadersCarrier(h))
+	assert.Nil(err)
+	assert.Equal(uint64(0xf206b12000100000), ctx.TraceID())
+	assert.Equal(uint64(123), ctx.SpanID())
+
+	// the trace ID is written back unchanged
+	out := tracer.TextMapCarrier{}
+	assert.Nil(p.Inject(ctx, out))
+	assert.Equal("105445aa7843bc8bf206b12000100000/123;o=1", out[TraceHeader])
+
+	// only "o=1" carries a sampling decision
+	for _, header := range []string{
+		"105445aa7843bc8bf206b12000100000/1;o=0",
+		"105445aa7843bc8bf206b12000100000/1;o=2",
+		"105445aa7843bc8bf206b12000100000/1",
+	} {
+		ctx, err := p.Extract(tracer.TextMapCarrier{TraceHeader: header})
+		assert.Nil(err)
+		out := tracer.TextMapCarrier{}
+		assert.Nil(p.Inject(ctx, out))
+		assert.Equal("105445aa7843bc8bf206b12000100000/1", out[TraceHeader], header)
+		assert.Nil(tracer.NewPropagator(nil).Inject(ctx, out))
+		assert.NotContains(out, tracer.DefaultPriorityHeader, header)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		for v, want := range map[string]error{
+			"":                                       tracer.ErrSpanContextNotFound,
+			"105445aa7843bc8bf206b12000100000":       tracer.ErrSpanContextCorrupted,
+			"105445aa/1;o=1":                         tracer.ErrSpanContextCorrupted,
+			"105445aa7843bc8bf206b12000100000/x":     tracer.ErrSpanContextCorrupted,
+			"105445aa7843bc8bf206b1200010000x/1":     tracer.ErrSpanContextCorrupted,
+			"00000000000000000000000000000000/1;o=1": tracer.ErrSpanContextCorrupted,
+			"105445aa7843bc8bf206b12000100000/0;o=1": tracer.ErrSpanContextCorrupted,
+			"105445aa7843bc8b0000000000000000/1;o=1": tracer.ErrSpanContextNotFound,
+		} {
+			_, err := p.Extract(tracer.TextMapCarrier{TraceHeader: v})
+			assert.Equal(want, err, v)
+		}
+	})
+}
+
+func TestInject(t *testing.T) {
+	assert := assert.New(t)
+	ctx, err := tracer.NewPropagator(nil).Extract(tracer.TextMapCarrier{
+		tracer.DefaultTraceIDHeader:  "1",
+		tracer.DefaultParentIDHeader: "2",
+		tracer.DefaultPriorityHeader: "1",
+	})
+	assert.Nil(err)
+	out := tracer.TextMapCarrier{}
+	assert.Nil((&GCPPropagator{}).Inject(ctx, out))
+	assert.Equal("00000000000000000000000000000001/2;o=1", out[TraceHeader])
+
+	ctx, err = tracer.NewPropagator(nil).Extract(tracer.TextMapCarrier{
+		tracer.DefaultTraceIDHeader:  "1",
+		tracer.DefaultParentIDHeader: "2",
+		tracer.DefaultPriorityHeader: "0",
+	})
+	assert.Nil(err)
+	assert.Nil((&GCPPropagator{}).Inject(ctx, out))
+	assert.Equal("00000000000000000000000000000001/2;o=0", out[TraceHeader])
+}
+
+func TestWithPropagator(t *testing.T) {
+	assert := assert.New(t)
+	tracer.Start(tracer.WithPropagator(&GCPPropagator{}))
+	defer tracer.Stop()
+
+	ctx, err := tracer.Extract(tracer.TextMapCarrier{TraceHeader: "105445aa7843bc8bf206b12000100000/1;o=1"})
+	assert.Nil(err)
+	span := tracer.StartSpan("web.request", tracer.ChildOf(ctx))
+	defer span.Finish()
+	assert.Equal(uint64(0xf206b12000100000), span.Context().TraceID())
+
+	out := tracer.TextMapCarrier{}
+	assert.Nil(tracer.Inject(span.Context(), out))
+	assert.Regexp("^105445aa7843bc8bf206b12000100000/[0-9]+;o=1$", out[TraceHeader])
+	assert.NotContains(out, tracer.DefaultTraceIDHeader)
+}
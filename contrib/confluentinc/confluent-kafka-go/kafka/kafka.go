@@ -8,6 +8,7 @@ package kafka // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/confluentinc/co
 
 import (
 	"math"
+	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -142,6 +143,29 @@ func (c *Consumer) Poll(timeoutMS int) (event kafka.Event) {
 	return evt
 }
 
+// ReadMessage polls the consumer for a message. Message events will be traced.
+func (c *Consumer) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	if c.prev != nil {
+		c.prev.Finish()
+		c.prev = nil
+	}
+	return c.traceReadMessage(c.Consumer.ReadMessage(timeout))
+}
+
+// traceReadMessage starts the span of a message returned by ReadMessage. Like
+// the wrapped consumer, it returns the message along with the error when the
+// message holds a partition error, in which case the span is tagged with it.
+func (c *Consumer) traceReadMessage(msg *kafka.Message, err error) (*kafka.Message, error) {
+	if msg == nil {
+		return nil, err
+	}
+	c.prev = c.startSpan(msg)
+	if err != nil {
+		c.prev.SetTag(ext.Error, err)
+	}
+	return msg, err
+}
+
 // A Producer wraps a kafka.Producer.
 type Producer struct {
 	*kafka.Producer
@@ -8,9 +8,11 @@ package kafka
 import (
 	"os"
 	"testing"
+	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
 	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
 
@@ -84,6 +86,44 @@ func TestConsumerChannel(t *testing.T) {
 	}
 }
 
+func TestConsumerChannelParent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c, err := NewConsumer(&kafka.ConfigMap{
+		"go.events.channel.enable": true, // required for the events channel to be turned on
+		"group.id":                 testGroupID,
+		"socket.timeout.ms":        10,
+		"session.timeout.ms":       10,
+		"enable.auto.offset.store": false,
+	})
+	assert.NoError(t, err)
+
+	// the message carries the context of the span which produced it
+	producer := tracer.StartSpan("kafka.produce")
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &testTopic, Partition: 1, Offset: 1},
+	}
+	err = tracer.Inject(producer.Context(), NewMessageCarrier(msg))
+	assert.NoError(t, err)
+	producer.Finish()
+
+	go func() {
+		c.Consumer.Events() <- msg
+	}()
+	<-c.Events()
+	c.Close()
+	// wait for the events channel to be closed
+	<-c.Events()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 2)
+	s := spans[1]
+	assert.Equal(t, "kafka.consume", s.OperationName())
+	assert.Equal(t, producer.Context().TraceID(), s.TraceID())
+	assert.Equal(t, producer.Context().SpanID(), s.ParentID())
+}
+
 /*
 to run the integration test locally:
 
@@ -180,3 +220,86 @@ func TestConsumerPoll(t *testing.T) {
 	assert.Equal(t, "queue", s1.Tag(ext.SpanType))
 	assert.Equal(t, int32(0), s1.Tag("partition"))
 }
+
+func TestConsumerReadMessageError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c := &Consumer{cfg: newConfig()}
+	partErr := kafka.NewError(kafka.ErrUnknownPartition, "unknown partition", false)
+	in := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &testTopic,
+			Partition: 1,
+			Offset:    1,
+			Error:     partErr,
+		},
+	}
+	msg, err := c.traceReadMessage(in, partErr)
+	assert.Equal(t, in, msg)
+	assert.Equal(t, partErr, err)
+	c.prev.Finish()
+
+	msg, err = c.traceReadMessage(nil, kafka.NewError(kafka.ErrTimedOut, "timed out", false))
+	assert.Nil(t, msg)
+	assert.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "kafka.consume", spans[0].OperationName())
+	assert.Equal(t, partErr, spans[0].Tag(ext.Error))
+}
+
+func TestConsumerReadMessage(t *testing.T) {
+	if _, ok := os.LookupEnv("INTEGRATION"); !ok {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	p, err := NewProducer(&kafka.ConfigMap{
+		"group.id":            testGroupID,
+		"bootstrap.servers":   "127.0.0.1:9092",
+		"go.delivery.reports": true,
+	})
+	assert.NoError(t, err)
+	delivery := make(chan kafka.Event, 1)
+	err = p.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &testTopic,
+			Partition: 0,
+		},
+		Key:   []byte("key3"),
+		Value: []byte("value3"),
+	}, delivery)
+	assert.NoError(t, err)
+	msg1, _ := (<-delivery).(*kafka.Message)
+	p.Close()
+
+	c, err := NewConsumer(&kafka.ConfigMap{
+		"group.id":                 testGroupID,
+		"bootstrap.servers":        "127.0.0.1:9092",
+		"socket.timeout.ms":        1000,
+		"session.timeout.ms":       1000,
+		"enable.auto.offset.store": false,
+	})
+	assert.NoError(t, err)
+
+	err = c.Assign([]kafka.TopicPartition{
+		{Topic: &testTopic, Partition: 0, Offset: msg1.TopicPartition.Offset},
+	})
+	assert.NoError(t, err)
+
+	msg2, err := c.ReadMessage(3 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, msg1.String(), msg2.String())
+
+	c.Close()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "kafka.produce", spans[0].OperationName())
+	assert.Equal(t, "kafka.consume", spans[1].OperationName())
+	assert.Equal(t, spans[0].SpanID(), spans[1].ParentID())
+}
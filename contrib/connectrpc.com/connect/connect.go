@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package connect provides functions to trace the connectrpc.com/connect package (https://connectrpc.com).
+package connect // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/connectrpc.com/connect"
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"connectrpc.com/connect"
+)
+
+// Tags used for Connect
+const (
+	tagRPCSystem  = "rpc.system"
+	tagRPCService = "rpc.service"
+	tagRPCMethod  = "rpc.method"
+	tagCode       = "connect.code"
+)
+
+// NewUnaryServerInterceptor returns an interceptor which traces the unary RPCs
+// served by a Connect handler. The span context is extracted from the request
+// headers.
+func NewUnaryServerInterceptor(opts ...Option) connect.UnaryInterceptorFunc {
+	cfg := newConfig(opts, "connect.server")
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if req.Spec().IsClient {
+				return next(ctx, req)
+			}
+			spanopts := spanOptions(cfg, req)
+			if sctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(req.Header())); err == nil {
+				spanopts = append(spanopts, tracer.ChildOf(sctx))
+			}
+			span, ctx := tracer.StartSpanFromContext(ctx, "connect.server", spanopts...)
+			res, err := next(ctx, req)
+			finishWithError(span, err, cfg)
+			return res, err
+		}
+	}
+}
+
+// NewUnaryClientInterceptor returns an interceptor which traces the unary RPCs
+// made by a Connect client. The span context is injected into the request
+// headers.
+func NewUnaryClientInterceptor(opts ...Option) connect.UnaryInterceptorFunc {
+	cfg := newConfig(opts, "connect.client")
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if !req.Spec().IsClient {
+				return next(ctx, req)
+			}
+			span, ctx := tracer.StartSpanFromContext(ctx, "connect.client", spanOptions(cfg, req)...)
+			// inject the span context into the request headers
+			tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(req.Header()))
+			res, err := next(ctx, req)
+			finishWithError(span, err, cfg)
+			return res, err
+		}
+	}
+}
+
+func newConfig(opts []Option, serviceName string) *config {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if cfg.serviceName == "" {
+		cfg.serviceName = serviceName
+	}
+	return cfg
+}
+
+// spanOptions returns the options of the span of the RPC made by req.
+func spanOptions(cfg *config, req connect.AnyRequest) []ddtrace.StartSpanOption {
+	procedure := req.Spec().Procedure
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName(procedure),
+		tracer.SpanType(ext.AppTypeRPC),
+		tracer.Tag(tagRPCSystem, "connect"),
+	}
+	// procedures are of the form "/acme.foo.v1.FooService/Bar"
+	if i := strings.LastIndexByte(procedure, '/'); i > 0 {
+		opts = append(opts,
+			tracer.Tag(tagRPCService, strings.TrimPrefix(procedure[:i], "/")),
+			tracer.Tag(tagRPCMethod, procedure[i+1:]),
+		)
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return opts
+}
+
+// finishWithError finishes span, tagging it with the code of err and marking it
+// as an error if the error mapper of cfg reports so.
+func finishWithError(span ddtrace.Span, err error, cfg *config) {
+	if err != nil {
+		code := connect.CodeOf(err)
+		span.SetTag(tagCode, code.String())
+		if !cfg.errorMapper(code) {
+			err = nil
+		}
+	}
+	span.Finish(tracer.WithError(err))
+}
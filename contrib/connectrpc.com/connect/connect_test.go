@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package connect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const procedure = "/acme.greet.v1.GreetService/Greet"
+
+// newClient starts a server serving procedure with the given handler, returning
+// a client for it. The returned function stops the server.
+func newClient(handler func(context.Context, *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error), opts ...Option) (*connect.Client[wrapperspb.StringValue, wrapperspb.StringValue], func()) {
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, handler,
+		connect.WithInterceptors(NewUnaryServerInterceptor(opts...)),
+	))
+	srv := httptest.NewServer(mux)
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		srv.Client(),
+		srv.URL+procedure,
+		connect.WithInterceptors(NewUnaryClientInterceptor(opts...)),
+	)
+	return client, srv.Close
+}
+
+func greet(_ context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+	switch req.Msg.Value {
+	case "error":
+		return nil, connect.NewError(connect.CodeInternal, errors.New("oops"))
+	case "notfound":
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("no such user"))
+	}
+	return connect.NewResponse(wrapperspb.String("hello " + req.Msg.Value)), nil
+}
+
+func TestUnary(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var serverSpan tracer.Span
+	client, stop := newClient(func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+		serverSpan, _ = tracer.SpanFromContext(ctx)
+		return greet(ctx, req)
+	})
+	defer stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	res, err := client.CallUnary(ctx, connect.NewRequest(wrapperspb.String("lucy")))
+	assert.NoError(err)
+	assert.Equal("hello lucy", res.Msg.Value)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	server, clientSpan := spans[0], spans[1]
+	assert.Equal(serverSpan, server)
+
+	assert.Equal("connect.client", clientSpan.OperationName())
+	assert.Equal("connect.client", clientSpan.Tag(ext.ServiceName))
+	assert.Equal(root.Context().SpanID(), clientSpan.ParentID())
+
+	assert.Equal("connect.server", server.OperationName())
+	assert.Equal("connect.server", server.Tag(ext.ServiceName))
+	assert.Equal(clientSpan.SpanID(), server.ParentID())
+
+	for _, span := range []mocktracer.Span{server, clientSpan} {
+		assert.Equal(procedure, span.Tag(ext.ResourceName))
+		assert.Equal(ext.AppTypeRPC, span.Tag(ext.SpanType))
+		assert.Equal("connect", span.Tag(tagRPCSystem))
+		assert.Equal("acme.greet.v1.GreetService", span.Tag(tagRPCService))
+		assert.Equal("Greet", span.Tag(tagRPCMethod))
+		assert.Nil(span.Tag(tagCode))
+		assert.Nil(span.Tag(ext.Error))
+	}
+}
+
+func TestError(t *testing.T) {
+	for name, tt := range map[string]struct {
+		msg   string
+		code  connect.Code
+		opts  []Option
+		error bool
+	}{
+		"internal": {msg: "error", code: connect.CodeInternal, error: true},
+		"notfound": {msg: "notfound", code: connect.CodeNotFound, error: true},
+		"mapper": {
+			msg:  "notfound",
+			code: connect.CodeNotFound,
+			opts: []Option{WithErrorMapper(func(c connect.Code) bool { return c == connect.CodeInternal })},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			client, stop := newClient(greet, tt.opts...)
+			defer stop()
+			_, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String(tt.msg)))
+			assert.Equal(tt.code, connect.CodeOf(err))
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 2)
+			for _, span := range spans {
+				assert.Equal(tt.code.String(), span.Tag(tagCode))
+				if tt.error {
+					assert.Equal(tt.code, connect.CodeOf(span.Tag(ext.Error).(error)))
+				} else {
+					assert.Nil(span.Tag(ext.Error))
+				}
+			}
+		})
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client, stop := newClient(greet, WithServiceName("greeter"), WithAnalytics(true))
+	defer stop()
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("lucy")))
+	assert.NoError(err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	for _, span := range spans {
+		assert.Equal("greeter", span.Tag(ext.ServiceName))
+		assert.Equal(1.0, span.Tag(ext.EventSampleRate))
+	}
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package connect_test
+
+import (
+	"context"
+	"net/http"
+
+	connecttrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/connectrpc.com/connect"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func greet(_ context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+	return connect.NewResponse(wrapperspb.String("hello " + req.Msg.Value)), nil
+}
+
+func Example_server() {
+	// Add the server interceptor to the options of your handlers.
+	const procedure = "/acme.greet.v1.GreetService/Greet"
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure, greet,
+		connect.WithInterceptors(connecttrace.NewUnaryServerInterceptor(connecttrace.WithServiceName("greeter"))),
+	))
+	http.ListenAndServe(":8080", mux)
+}
+
+func Example_client() {
+	// Add the client interceptor to the options of your clients.
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		http.DefaultClient,
+		"http://localhost:8080/acme.greet.v1.GreetService/Greet",
+		connect.WithInterceptors(connecttrace.NewUnaryClientInterceptor()),
+	)
+	client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("lucy")))
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package connect
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"connectrpc.com/connect"
+)
+
+// Option specifies a configuration option for the interceptors.
+type Option func(*config)
+
+type config struct {
+	serviceName   string
+	errorMapper   func(connect.Code) bool
+	analyticsRate float64
+}
+
+func defaults(cfg *config) {
+	// cfg.serviceName defaults are set in interceptors
+	cfg.errorMapper = DefaultErrorMapper
+	cfg.analyticsRate = globalconfig.AnalyticsRate()
+}
+
+// WithServiceName sets the given service name for the intercepted server or client.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithErrorMapper sets the function used to determine whether the code of an error
+// returned by an RPC should mark its span as an error; fn reports true if it should.
+// The code is tagged on the span regardless. The default is DefaultErrorMapper.
+func WithErrorMapper(fn func(connect.Code) bool) Option {
+	return func(cfg *config) {
+		cfg.errorMapper = fn
+	}
+}
+
+// DefaultErrorMapper is the default error mapper. It treats all codes as errors
+// except connect.CodeCanceled, which usually results from the client giving up.
+func DefaultErrorMapper(c connect.Code) bool {
+	return c != connect.CodeCanceled
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
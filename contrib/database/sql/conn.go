@@ -12,6 +12,7 @@ import (
 	"math"
 	"time"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -130,6 +131,7 @@ func (tc *tracedConn) QueryContext(ctx context.Context, query string, args []dri
 type traceParams struct {
 	cfg        *config
 	driverName string
+	dialect    internal.Dialect
 	meta       map[string]string
 }
 
@@ -164,6 +166,9 @@ func (tp *traceParams) tryTrace(ctx context.Context, resource string, query stri
 	span, _ := tracer.StartSpanFromContext(ctx, name, opts...)
 	if query != "" {
 		resource = query
+		if tp.cfg.obfuscate != nil && *tp.cfg.obfuscate {
+			resource = internal.ObfuscateSQL(tp.dialect, query)
+		}
 	}
 	span.SetTag(ext.ResourceName, resource)
 	for k, v := range tp.meta {
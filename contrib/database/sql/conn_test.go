@@ -11,6 +11,7 @@ import (
 	"log"
 	"testing"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 
 	"github.com/go-sql-driver/mysql"
@@ -100,3 +101,23 @@ func TestWithSpanTags(t *testing.T) {
 		})
 	}
 }
+
+func TestWithSQLObfuscation(t *testing.T) {
+	Register("mysql", &mysql.MySQLDriver{})
+	db, err := Open("mysql", "test:test@tcp(127.0.0.1:3306)/test", WithSQLObfuscation(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rows, err := db.QueryContext(context.Background(), "SELECT 1 WHERE 'secret' = ?", "secret")
+	assert.NoError(t, err)
+	rows.Close()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "SELECT ? WHERE ? = ?", spans[0].Tag(ext.ResourceName))
+}
@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package internal
+
+import (
+	"strings"
+)
+
+// Dialect specifies the SQL dialect used when obfuscating queries.
+type Dialect int
+
+const (
+	// DialectANSI is the dialect used for unknown drivers. Double quotes
+	// delimit identifiers.
+	DialectANSI Dialect = iota
+	// DialectMySQL is the MySQL dialect, where double quotes may delimit
+	// strings and backticks delimit identifiers.
+	DialectMySQL
+	// DialectPostgres is the PostgreSQL dialect, which supports dollar-quoted
+	// strings.
+	DialectPostgres
+	// DialectSQLite is the SQLite dialect, where identifiers may also be
+	// delimited using backticks and square brackets.
+	DialectSQLite
+)
+
+// DialectFromDriver returns the dialect matching the given driver name, as
+// passed to Register.
+func DialectFromDriver(driverName string) Dialect {
+	name := strings.ToLower(driverName)
+	switch {
+	case strings.Contains(name, "mysql"):
+		return DialectMySQL
+	case strings.Contains(name, "postgres"), name == "pq", strings.Contains(name, "pgx"):
+		return DialectPostgres
+	case strings.Contains(name, "sqlite"):
+		return DialectSQLite
+	default:
+		return DialectANSI
+	}
+}
+
+// ObfuscateSQL returns query with its string and numeric literals replaced by
+// a "?" and its comments removed, so that it can be used as a resource name
+// without leaking the values it contains. Identifiers, keywords, placeholders
+// and the layout of the query are kept as they are.
+func ObfuscateSQL(d Dialect, query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#' && d == DialectMySQL:
+			// line comment
+			n := strings.IndexByte(query[i:], '\n')
+			if n < 0 {
+				return strings.TrimRight(b.String(), " \t")
+			}
+			i += n
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			// block comment
+			n := strings.Index(query[i+2:], "*/")
+			if n < 0 {
+				return strings.TrimRight(b.String(), " \t")
+			}
+			i += n + 4
+		case c == '\'', c == '"' && d == DialectMySQL:
+			b.WriteByte('?')
+			i = skipQuoted(query, i, c, backslashEscapes(d, query, i))
+		case c == '"', c == '`' && (d == DialectMySQL || d == DialectSQLite):
+			// quoted identifier
+			end := skipQuoted(query, i, c, false)
+			b.WriteString(query[i:end])
+			i = end
+		case c == '[' && d == DialectSQLite:
+			end := strings.IndexByte(query[i:], ']')
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			b.WriteString(query[i : i+end+1])
+			i += end + 1
+		case c == '$' && d == DialectPostgres && !isDigit(peek(query, i+1)):
+			// dollar-quoted string, e.g. $$text$$ or $tag$text$tag$
+			tag := dollarTag(query[i:])
+			if tag == "" {
+				b.WriteByte(c)
+				i++
+				break
+			}
+			b.WriteByte('?')
+			n := strings.Index(query[i+len(tag):], tag)
+			if n < 0 {
+				return b.String()
+			}
+			i += len(tag) + n + len(tag)
+		case c == '$' || c == ':' || c == '@' || c == '?':
+			// placeholders, such as $1, :name or @p1, are kept
+			b.WriteByte(c)
+			i++
+			for i < len(query) && isIdent(query[i]) {
+				b.WriteByte(query[i])
+				i++
+			}
+		case isDigit(c) || (c == '.' && isDigit(peek(query, i+1))):
+			b.WriteByte('?')
+			i = skipNumber(query, i)
+		case isIdent(c):
+			// keywords and identifiers, which may contain digits
+			for i < len(query) && isIdent(query[i]) {
+				b.WriteByte(query[i])
+				i++
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipQuoted returns the index following the quoted literal or identifier
+// starting at query[start]. Doubled quotes are always treated as escaped quotes;
+// backslashes escape the next character if backslash is true.
+func skipQuoted(query string, start int, quote byte, backslash bool) int {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if peek(query, i+1) == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// backslashEscapes reports whether backslashes escape characters in the string
+// starting at query[i]. PostgreSQL only supports them in escape strings, and
+// SQLite not at all: only doubled quotes are escaped quotes there.
+func backslashEscapes(d Dialect, query string, i int) bool {
+	switch d {
+	case DialectPostgres:
+		return isEscapeString(query, i)
+	case DialectSQLite:
+		return false
+	default:
+		return true
+	}
+}
+
+// isEscapeString reports whether the PostgreSQL string starting at query[i] is an
+// escape string constant (E'...'), in which backslashes escape characters.
+func isEscapeString(query string, i int) bool {
+	return i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i == 1 || !isIdent(query[i-2]))
+}
+
+// dollarTag returns the dollar-quote tag found at the start of s, such as "$$"
+// or "$tag$", or an empty string if there is none.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1]
+		}
+		if !isIdent(s[i]) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// skipNumber returns the index following the numeric literal starting at query[start].
+func skipNumber(query string, start int) int {
+	i := start
+	if strings.HasPrefix(query[i:], "0x") || strings.HasPrefix(query[i:], "0X") {
+		i += 2
+		for i < len(query) && isHex(query[i]) {
+			i++
+		}
+		return i
+	}
+	for i < len(query) && (isDigit(query[i]) || query[i] == '.') {
+		i++
+	}
+	if c := peek(query, i); c == 'e' || c == 'E' {
+		j := i + 1
+		if c := peek(query, j); c == '+' || c == '-' {
+			j++
+		}
+		if isDigit(peek(query, j)) {
+			i = j
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+// peek returns query[i], or 0 if i is out of range.
+func peek(query string, i int) byte {
+	if i < len(query) {
+		return query[i]
+	}
+	return 0
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHex(c byte) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+
+func isIdent(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
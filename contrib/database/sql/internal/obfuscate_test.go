@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateSQL(t *testing.T) {
+	for _, tt := range []struct {
+		dialect Dialect
+		in, out string
+	}{
+		{DialectANSI, "SELECT * FROM users WHERE id = ?", "SELECT * FROM users WHERE id = ?"},
+		{DialectANSI, "SELECT * FROM users WHERE id = 42 AND name = 'bob'", "SELECT * FROM users WHERE id = ? AND name = ?"},
+		{DialectANSI, "SELECT * FROM t1 WHERE x > -1.5e10 AND y = .5 AND z = 0xFF", "SELECT * FROM t1 WHERE x > -? AND y = ? AND z = ?"},
+		{DialectANSI, "SELECT 'it''s', \"Col1\" FROM t LIMIT 5", "SELECT ?, \"Col1\" FROM t LIMIT ?"},
+		{DialectANSI, "SELECT a -- user 42\nFROM t /* secret 'x' */ WHERE b = 1", "SELECT a \nFROM t  WHERE b = ?"},
+		{DialectANSI, "SELECT a FROM t -- trailing", "SELECT a FROM t"},
+		{DialectANSI, "UPDATE t SET a = :a, b = @b2 WHERE id IN (1, 2, 3)", "UPDATE t SET a = :a, b = @b2 WHERE id IN (?, ?, ?)"},
+		{DialectMySQL, "SELECT `col1` FROM t WHERE a = \"secret\" AND b = 'x\\'y' # comment", "SELECT `col1` FROM t WHERE a = ? AND b = ?"},
+		{DialectPostgres, "SELECT \"col\" FROM t WHERE a = $1 AND b = 'c:\\' AND c = 2", "SELECT \"col\" FROM t WHERE a = $1 AND b = ? AND c = ?"},
+		{DialectPostgres, "SELECT id::int, E'a\\'b', $$dollar 'quoted'$$, $tag$x$tag$ FROM t", "SELECT id::int, E?, ?, ? FROM t"},
+		{DialectSQLite, "SELECT [my col], `c2` FROM t WHERE x = 'y'", "SELECT [my col], `c2` FROM t WHERE x = ?"},
+		{DialectSQLite, "SELECT * FROM t WHERE a = \"ident\"", "SELECT * FROM t WHERE a = \"ident\""},
+		{DialectSQLite, "SELECT * FROM t WHERE a = 'C:\\' AND b = 'it''s' AND c = 2", "SELECT * FROM t WHERE a = ? AND b = ? AND c = ?"},
+	} {
+		assert.Equal(t, tt.out, ObfuscateSQL(tt.dialect, tt.in), tt.in)
+	}
+}
+
+func TestDialectFromDriver(t *testing.T) {
+	for name, want := range map[string]Dialect{
+		"mysql":     DialectMySQL,
+		"postgres":  DialectPostgres,
+		"pq":        DialectPostgres,
+		"pgx":       DialectPostgres,
+		"sqlite3":   DialectSQLite,
+		"sqlserver": DialectANSI,
+	} {
+		assert.Equal(t, want, DialectFromDriver(name), name)
+	}
+}
@@ -13,6 +13,12 @@ type config struct {
 	serviceName   string
 	analyticsRate float64
 	dsn           string
+	// obfuscate specifies whether queries are obfuscated. It is nil when unset,
+	// in which case it defaults to the registered driver's setting.
+	obfuscate *bool
+	// poolMetrics specifies whether connection pool metrics are reported. It is nil
+	// when unset, in which case it defaults to the registered driver's setting.
+	poolMetrics *bool
 }
 
 // Option represents an option that can be passed to Register, Open or OpenDB.
@@ -68,3 +74,25 @@ func WithDSN(name string) Option {
 		cfg.dsn = name
 	}
 }
+
+// WithSQLObfuscation specifies whether the literal values found in queries, such as
+// strings and numbers, should be replaced with "?" before the queries are used as
+// span resources. Comments are also removed. The SQL dialect is inferred from the
+// name of the driver: MySQL, PostgreSQL and SQLite are supported, and ANSI SQL is
+// used for other drivers. It is disabled by default.
+func WithSQLObfuscation(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.obfuscate = &enabled
+	}
+}
+
+// WithPoolMetrics specifies whether the statistics of the database connection pool
+// should be reported periodically using the tracer's statsd client. The metrics
+// db.pool.open_connections and db.pool.idle_connections are sent as gauges, while
+// db.pool.wait_count and db.pool.wait_duration (in nanoseconds) are sent as counts.
+// They are tagged with the driver and service names. It is disabled by default.
+func WithPoolMetrics(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.poolMetrics = &enabled
+	}
+}
@@ -6,7 +6,9 @@
 package sql
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
 
@@ -43,3 +45,41 @@ func TestAnalyticsSettings(t *testing.T) {
 		assert.Equal(t, 0.2, cfg.analyticsRate)
 	})
 }
+
+type testStatsdClient struct {
+	counts map[string]int64
+	gauges map[string]float64
+	tags   []string
+}
+
+func (c *testStatsdClient) Count(name string, value int64, tags []string, _ float64) error {
+	c.counts[name] += value
+	c.tags = tags
+	return nil
+}
+
+func (c *testStatsdClient) Gauge(name string, value float64, tags []string, _ float64) error {
+	c.gauges[name] = value
+	c.tags = tags
+	return nil
+}
+
+func (c *testStatsdClient) Distribution(name string, value float64, tags []string, _ float64) error {
+	return nil
+}
+
+func TestPoolMetrics(t *testing.T) {
+	statsd := &testStatsdClient{counts: map[string]int64{}, gauges: map[string]float64{}}
+	globalconfig.SetStatsd(statsd)
+	defer globalconfig.SetStatsd(nil)
+
+	p := newPoolMetrics(nil, "postgres", "postgres.db")
+	p.report(sql.DBStats{OpenConnections: 3, Idle: 1, WaitCount: 2, WaitDuration: time.Second})
+	p.report(sql.DBStats{OpenConnections: 4, Idle: 2, WaitCount: 5, WaitDuration: 3 * time.Second})
+
+	assert.Equal(t, 4.0, statsd.gauges["db.pool.open_connections"])
+	assert.Equal(t, 2.0, statsd.gauges["db.pool.idle_connections"])
+	assert.Equal(t, int64(5), statsd.counts["db.pool.wait_count"])
+	assert.Equal(t, int64(3*time.Second), statsd.counts["db.pool.wait_duration"])
+	assert.Equal(t, []string{"db.driver:postgres", "db.service:postgres.db"}, statsd.tags)
+}
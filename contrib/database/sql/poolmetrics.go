@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+)
+
+// poolMetricsInterval specifies the interval at which connection pool metrics are reported.
+var poolMetricsInterval = 10 * time.Second
+
+// poolMetrics periodically reports the connection pool statistics of a database
+// using the statsd client of the running tracer.
+type poolMetrics struct {
+	db   *sql.DB
+	tags []string
+	stop chan struct{}
+
+	// last holds the previously reported statistics, used to compute the
+	// deltas of cumulative counters.
+	last sql.DBStats
+}
+
+func newPoolMetrics(db *sql.DB, driverName, serviceName string) *poolMetrics {
+	return &poolMetrics{
+		db:   db,
+		tags: []string{"db.driver:" + driverName, "db.service:" + serviceName},
+		stop: make(chan struct{}),
+	}
+}
+
+// run reports the metrics every poolMetricsInterval until the stop channel is closed.
+func (p *poolMetrics) run() {
+	tick := time.NewTicker(poolMetricsInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			p.report(p.db.Stats())
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// report sends the given statistics. Nothing is sent when the tracer isn't running,
+// but the statistics are still recorded so that the next deltas remain accurate.
+func (p *poolMetrics) report(stats sql.DBStats) {
+	last := p.last
+	p.last = stats
+	statsd := globalconfig.Statsd()
+	if statsd == nil {
+		return
+	}
+	statsd.Gauge("db.pool.open_connections", float64(stats.OpenConnections), p.tags, 1)
+	statsd.Gauge("db.pool.idle_connections", float64(stats.Idle), p.tags, 1)
+	statsd.Count("db.pool.wait_count", stats.WaitCount-last.WaitCount, p.tags, 1)
+	statsd.Count("db.pool.wait_duration", int64(stats.WaitDuration-last.WaitDuration), p.tags, 1)
+}
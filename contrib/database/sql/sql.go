@@ -21,6 +21,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"io"
 	"math"
 	"reflect"
 
@@ -108,7 +109,10 @@ var errNotRegistered = errors.New("sqltrace: Register must be called before Open
 type tracedConnector struct {
 	connector  driver.Connector
 	driverName string
+	dialect    internal.Dialect
 	cfg        *config
+	// metrics is set when connection pool metrics are enabled.
+	metrics *poolMetrics
 }
 
 func (t *tracedConnector) Connect(c context.Context) (driver.Conn, error) {
@@ -118,6 +122,7 @@ func (t *tracedConnector) Connect(c context.Context) (driver.Conn, error) {
 	}
 	tp := &traceParams{
 		driverName: t.driverName,
+		dialect:    t.dialect,
 		cfg:        t.cfg,
 	}
 	if dc, ok := t.connector.(*dsnConnector); ok {
@@ -132,6 +137,18 @@ func (t *tracedConnector) Driver() driver.Driver {
 	return t.connector.Driver()
 }
 
+// Close is called by (*sql.DB).Close. It stops reporting connection pool metrics
+// and closes the underlying connector if it implements io.Closer.
+func (t *tracedConnector) Close() error {
+	if t.metrics != nil {
+		close(t.metrics.stop)
+	}
+	if c, ok := t.connector.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // from Go stdlib implementation of sql.Open
 type dsnConnector struct {
 	dsn    string
@@ -166,12 +183,24 @@ func OpenDB(c driver.Connector, opts ...Option) *sql.DB {
 	if math.IsNaN(cfg.analyticsRate) {
 		cfg.analyticsRate = rc.analyticsRate
 	}
+	if cfg.obfuscate == nil {
+		cfg.obfuscate = rc.obfuscate
+	}
+	if cfg.poolMetrics == nil {
+		cfg.poolMetrics = rc.poolMetrics
+	}
 	tc := &tracedConnector{
 		connector:  c,
 		driverName: name,
+		dialect:    internal.DialectFromDriver(name),
 		cfg:        cfg,
 	}
-	return sql.OpenDB(tc)
+	db := sql.OpenDB(tc)
+	if cfg.poolMetrics != nil && *cfg.poolMetrics {
+		tc.metrics = newPoolMetrics(db, name, cfg.serviceName)
+		go tc.metrics.run()
+	}
+	return db
 }
 
 // Open returns connection to a DB using a the traced version of the given driver. In order for Open
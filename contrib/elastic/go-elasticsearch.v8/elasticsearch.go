@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package elasticsearch provides functions to trace the github.com/elastic/go-elasticsearch/v8 package.
+package elasticsearch // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/elastic/go-elasticsearch.v8"
+
+import (
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/elasticutil"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// NewInstrumentedTransport returns a new http.RoundTripper which traces the requests
+// sent to Elasticsearch. It is meant to be set as the Transport of an
+// elasticsearch.Config.
+func NewInstrumentedTransport(opts ...Option) http.RoundTripper {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return &transport{config: cfg}
+}
+
+// transport is a traced HTTP transport that captures Elasticsearch spans.
+type transport struct{ config *config }
+
+// bodyCutoff specifies the maximum number of bytes that will be stored as a tag
+// value obtained from an HTTP response body.
+var bodyCutoff = 5 * 1024
+
+// RoundTrip satisfies the RoundTripper interface, wraps the sub Transport and
+// captures a span of the Elasticsearch request.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.Path
+	method := req.Method
+	resource, index := t.quantize(url)
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(t.config.serviceName),
+		tracer.SpanType(ext.SpanTypeElasticSearch),
+		tracer.ResourceName(method + " " + resource),
+		tracer.Tag("elasticsearch.method", method),
+		tracer.Tag("elasticsearch.url", url),
+		tracer.Tag("elasticsearch.params", req.URL.Query().Encode()),
+	}
+	if index != "" {
+		opts = append(opts, tracer.Tag("elasticsearch.index", index))
+	}
+	if !math.IsNaN(t.config.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, t.config.analyticsRate))
+	}
+	span, _ := tracer.StartSpanFromContext(req.Context(), "elasticsearch.query", opts...)
+	defer span.Finish()
+
+	res, err := t.config.transport.RoundTrip(req)
+	elasticutil.TagResponse(span, res, err, bodyCutoff)
+	return res, err
+}
+
+// documentAPIs holds the endpoints which are followed by the ID of a document.
+var documentAPIs = map[string]bool{
+	"_doc":         true,
+	"_create":      true,
+	"_update":      true,
+	"_source":      true,
+	"_explain":     true,
+	"_termvectors": true,
+}
+
+// quantize returns the resource of a request to the given URL path along with the
+// index it targets, if any. Endpoints (segments starting with "_") are kept, document
+// IDs are replaced with "?" and index names are normalized, which gives resources
+// such as "/logs-*/_search" or "/users/_doc/?".
+func (t *transport) quantize(url string) (resource, index string) {
+	segments := strings.Split(strings.Trim(url, "/"), "/")
+	if segments[0] != "" && !strings.HasPrefix(segments[0], "_") {
+		index = segments[0]
+	}
+	for i, s := range segments {
+		switch {
+		case s == "", strings.HasPrefix(s, "_"):
+			// endpoint
+		case i > 0 && documentAPIs[segments[i-1]]:
+			segments[i] = "?"
+		default:
+			segments[i] = t.normalizeIndices(s)
+		}
+	}
+	return "/" + strings.Join(segments, "/"), index
+}
+
+// normalizeIndices normalizes each index of the comma-separated list s, removing
+// the duplicates which may result from it.
+func (t *transport) normalizeIndices(s string) string {
+	indices := strings.Split(s, ",")
+	out := indices[:0]
+	seen := make(map[string]bool, len(indices))
+	for _, idx := range indices {
+		idx = t.config.indexNormalizer(idx)
+		if !seen[idx] {
+			seen[idx] = true
+			out = append(out, idx)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// rolloverSuffix matches the date suffixes (e.g. "-2024.01.01" or "-2024-01") and
+// rollover counters (e.g. "-000001") of time-bucketed indices.
+var rolloverSuffix = regexp.MustCompile(`([-_.](\d{4}([-_.]\d{2}){1,2}|\d{6,}))+$`)
+
+// normalizeIndex is the default index normalizer. It replaces the rollover suffix
+// of the given index name with a wildcard, keeping its first separator.
+func normalizeIndex(index string) string {
+	loc := rolloverSuffix.FindStringIndex(index)
+	if loc == nil || loc[0] == 0 {
+		return index
+	}
+	return index[:loc[0]+1] + "*"
+}
@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// newClient returns a client for a fake Elasticsearch server replying to all requests
+// with the given status code and body. The returned function stops the server.
+func newClient(t *testing.T, status int, body string, opts ...Option) (*elasticsearch.Client, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{srv.URL},
+		Transport: NewInstrumentedTransport(opts...),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, srv.Close
+}
+
+func TestSearch(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client, stop := newClient(t, 200, `{"hits":{"hits":[]}}`, WithServiceName("my-es"))
+	defer stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "root")
+	res, err := client.Search(
+		client.Search.WithContext(ctx),
+		client.Search.WithIndex("logs-2024.01.01"),
+		client.Search.WithBody(strings.NewReader(`{"query":{"match_all":{}}}`)),
+		client.Search.WithSize(10),
+	)
+	assert.NoError(err)
+	res.Body.Close()
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	span := spans[0]
+	assert.Equal("elasticsearch.query", span.OperationName())
+	assert.Equal(ext.SpanTypeElasticSearch, span.Tag(ext.SpanType))
+	assert.Equal("my-es", span.Tag(ext.ServiceName))
+	assert.Equal("POST /logs-*/_search", span.Tag(ext.ResourceName))
+	assert.Equal("POST", span.Tag("elasticsearch.method"))
+	assert.Equal("/logs-2024.01.01/_search", span.Tag("elasticsearch.url"))
+	assert.Equal("size=10", span.Tag("elasticsearch.params"))
+	assert.Equal("logs-2024.01.01", span.Tag("elasticsearch.index"))
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal(root.Context().SpanID(), span.ParentID())
+	assert.Nil(span.Tag(ext.Error))
+}
+
+func TestDocument(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client, stop := newClient(t, 404, `{"found":false}`)
+	defer stop()
+
+	res, err := client.Get("users", "123")
+	assert.NoError(err)
+	res.Body.Close()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("elastic.client", span.Tag(ext.ServiceName))
+	assert.Equal("GET /users/_doc/?", span.Tag(ext.ResourceName))
+	assert.Equal("/users/_doc/123", span.Tag("elasticsearch.url"))
+	assert.Equal("404", span.Tag(ext.HTTPCode))
+	assert.Equal(`{"found":false}`, span.Tag(ext.Error).(error).Error())
+}
+
+func TestIndexNormalizer(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client, stop := newClient(t, 200, `{}`, WithIndexNormalizer(func(index string) string {
+		if strings.HasPrefix(index, "tenant-") {
+			return "tenant-?"
+		}
+		return index
+	}))
+	defer stop()
+
+	res, err := client.Count(client.Count.WithIndex("tenant-1", "tenant-2", "users"))
+	assert.NoError(err)
+	res.Body.Close()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("POST /tenant-?,users/_count", spans[0].Tag(ext.ResourceName))
+}
+
+func TestQuantize(t *testing.T) {
+	tr := NewInstrumentedTransport().(*transport)
+	for url, expected := range map[string]string{
+		"/":                             "/",
+		"/_search":                      "/_search",
+		"/_cluster/health":              "/_cluster/health",
+		"/_cat/indices/logs-2024.01.01": "/_cat/indices/logs-*",
+		"/users":                        "/users",
+		"/users/_doc/abc-123":           "/users/_doc/?",
+		"/users/_update/1":              "/users/_update/?",
+		"/users/_mapping":               "/users/_mapping",
+		"/logs-2024.01.01/_search":      "/logs-*/_search",
+		"/logs-2024.01.01,logs-2024.01.02/_search": "/logs-*/_search",
+		"/.ds-logs-2024.01.01-000001/_doc/1":       "/.ds-logs-*/_doc/?",
+	} {
+		resource, _ := tr.quantize(url)
+		assert.Equal(t, expected, resource, "quantizing %v", url)
+	}
+}
+
+func TestNormalizeIndex(t *testing.T) {
+	for index, expected := range map[string]string{
+		"logs-2024.01.01":   "logs-*",
+		"logs-2024-01-01":   "logs-*",
+		"logs_2024_01":      "logs_*",
+		"logs.2024.01.01":   "logs.*",
+		"metrics-000042":    "metrics-*",
+		"logs-2024.01.01-1": "logs-2024.01.01-1",
+		"logs-2024":         "logs-2024",
+		"users":             "users",
+		"2024.01.01":        "2024.01.01",
+	} {
+		assert.Equal(t, expected, normalizeIndex(index), "normalizing %v", index)
+	}
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		client, stop := newClient(t, 200, `{}`, opts...)
+		defer stop()
+		res, err := client.Info()
+		assert.NoError(t, err)
+		res.Body.Close()
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package elasticsearch_test
+
+import (
+	"context"
+	"strings"
+
+	elasticsearchtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/elastic/go-elasticsearch.v8"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+func Example() {
+	// Set the instrumented transport as the transport of the client.
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{"http://127.0.0.1:9200"},
+		Transport: elasticsearchtrace.NewInstrumentedTransport(elasticsearchtrace.WithServiceName("my-es-service")),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Use a context to pass information down the call chain
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "parent.request",
+		tracer.ServiceName("web"),
+		tracer.ResourceName("/tweet/city"),
+	)
+	defer root.Finish()
+
+	// Requests made with this context are children of the parent span
+	res, err := client.Search(
+		client.Search.WithContext(ctx),
+		client.Search.WithIndex("logs-2024.01.01"),
+		client.Search.WithBody(strings.NewReader(`{"query":{"match":{"city":"paris"}}}`)),
+	)
+	if err != nil {
+		panic(err)
+	}
+	res.Body.Close()
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package elasticsearch
+
+import (
+	"math"
+	"net/http"
+)
+
+type config struct {
+	serviceName     string
+	transport       http.RoundTripper
+	analyticsRate   float64
+	indexNormalizer func(string) string
+}
+
+// Option represents an option that can be passed to NewInstrumentedTransport.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = "elastic.client"
+	cfg.transport = http.DefaultTransport
+	cfg.indexNormalizer = normalizeIndex
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.analyticsRate = math.NaN()
+}
+
+// WithServiceName sets the given service name for the transport.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithTransport sets the transport wrapped by the instrumented transport. It
+// defaults to http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(cfg *config) {
+		cfg.transport = rt
+	}
+}
+
+// WithIndexNormalizer sets the function used to normalize the index names found in
+// request paths before they are used in resource names. It is called once for each
+// index of a comma-separated list. The default normalizer replaces the date and
+// rollover counter suffixes of time-bucketed indices with a wildcard, turning
+// "logs-2024.01.01" into "logs-*".
+func WithIndexNormalizer(fn func(string) string) Option {
+	return func(cfg *config) {
+		cfg.indexNormalizer = fn
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
@@ -50,6 +50,14 @@ func (m *monitor) Started(ctx context.Context, evt *event.CommandStartedEvent) {
 	if !math.IsNaN(m.cfg.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, m.cfg.analyticsRate))
 	}
+	if m.cfg.pipelineTag && evt.CommandName == "aggregate" {
+		if pipeline, lookups, ok := pipelineInfo(evt.Command); ok {
+			opts = append(opts, tracer.Tag("mongodb.pipeline", pipeline))
+			if len(lookups) > 0 {
+				opts = append(opts, tracer.Tag("mongodb.lookup_from", strings.Join(lookups, ",")))
+			}
+		}
+	}
 	span, _ := tracer.StartSpanFromContext(ctx, "mongodb.query", opts...)
 	key := spanKey{
 		ConnectionID: evt.ConnectionID,
@@ -130,3 +130,77 @@ func TestAnalyticsSettings(t *testing.T) {
 		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
 	})
 }
+
+func TestPipelineInfo(t *testing.T) {
+	cmd, err := bson.Marshal(bson.D{
+		{Key: "aggregate", Value: "orders"},
+		{Key: "pipeline", Value: bson.A{
+			bson.D{{Key: "$match", Value: bson.D{
+				{Key: "status", Value: "A"},
+				{Key: "qty", Value: bson.D{{Key: "$gt", Value: 10}}},
+			}}},
+			bson.D{{Key: "$lookup", Value: bson.D{
+				{Key: "from", Value: "inventory"},
+				{Key: "localField", Value: "item"},
+				{Key: "foreignField", Value: "sku"},
+				{Key: "as", Value: "stock"},
+			}}},
+			bson.D{{Key: "$project", Value: bson.D{
+				{Key: "secret", Value: bson.D{{Key: "$literal", Value: "s3cr3t"}}},
+				{Key: "user", Value: "$$CURRENT.user"},
+				{Key: "total", Value: "$amount"},
+			}}},
+		}},
+	})
+	assert.NoError(t, err)
+
+	pipeline, lookups, ok := pipelineInfo(cmd)
+	assert.True(t, ok)
+	assert.Equal(t, `[{"$match":{"status":"A","qty":{"$gt":"?"}}},`+
+		`{"$lookup":{"from":"inventory","localField":"item","foreignField":"sku","as":"stock"}},`+
+		`{"$project":{"secret":{"$literal":"?"},"user":"?","total":"$amount"}}]`, pipeline)
+	assert.Equal(t, []string{"inventory"}, lookups)
+
+	cmd, err = bson.Marshal(bson.D{{Key: "find", Value: "orders"}})
+	assert.NoError(t, err)
+	_, _, ok = pipelineInfo(cmd)
+	assert.False(t, ok)
+}
+
+func TestAggregatePipelineTag(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	opts := options.Client()
+	opts.Monitor = NewMonitor(WithAggregatePipelineTag(true))
+	opts.ApplyURI("mongodb://localhost:27017/?connect=direct")
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := client.
+		Database("test-database").
+		Collection("test-collection").
+		Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.D{{Key: "test-item", Value: "test-value"}}}},
+			{{Key: "$lookup", Value: bson.D{
+				{Key: "from", Value: "other-collection"},
+				{Key: "localField", Value: "test-item"},
+				{Key: "foreignField", Value: "test-item"},
+				{Key: "as", Value: "joined"},
+			}}},
+		})
+	if err == nil {
+		cur.Close(ctx)
+	}
+
+	spans := mt.FinishedSpans()
+	assert.True(t, len(spans) >= 1)
+	s := spans[0]
+	assert.Equal(t, "mongo.aggregate", s.Tag(ext.ResourceName))
+	assert.Contains(t, s.Tag("mongodb.pipeline"), `{"$match":{"test-item":"test-value"}}`)
+	assert.Equal(t, "other-collection", s.Tag("mongodb.lookup_from"))
+}
@@ -12,6 +12,7 @@ import (
 type config struct {
 	serviceName   string
 	analyticsRate float64
+	pipelineTag   bool
 }
 
 // Option represents an option that can be passed to Dial.
@@ -54,3 +55,14 @@ func WithAnalyticsRate(rate float64) Option {
 		}
 	}
 }
+
+// WithAggregatePipelineTag specifies whether the pipeline of aggregate commands should be
+// serialized as JSON in the mongodb.pipeline tag. Only the keys and string values of the
+// stages are kept: numbers and other literals, $literal expressions and $$variable
+// references are replaced with "?". When enabled, the collections joined by $lookup
+// stages are also set in the mongodb.lookup_from tag. It is disabled by default.
+func WithAggregatePipelineTag(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.pipelineTag = enabled
+	}
+}
@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package mongo
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// redacted replaces the values which are removed from serialized pipelines.
+const redacted = `"?"`
+
+// pipelineInfo returns the JSON serialization of the pipeline of the given aggregate
+// command, along with the collections it looks up. Only document keys and string
+// values are kept: numeric and other literals, $literal expressions and $$variable
+// references are replaced with "?". It reports false if the command has no pipeline.
+func pipelineInfo(cmd bson.Raw) (pipeline string, lookups []string, ok bool) {
+	v, err := cmd.LookupErr("pipeline")
+	if err != nil || v.Type != bsontype.Array {
+		return "", nil, false
+	}
+	var sb strings.Builder
+	writeValue(&sb, v, &lookups)
+	return sb.String(), lookups, true
+}
+
+// writeValue writes the redacted JSON serialization of v to sb, appending the targets
+// of any $lookup stage it contains to lookups.
+func writeValue(sb *strings.Builder, v bson.RawValue, lookups *[]string) {
+	switch v.Type {
+	case bsontype.EmbeddedDocument:
+		elems, err := v.Document().Elements()
+		if err != nil {
+			sb.WriteString(redacted)
+			return
+		}
+		sb.WriteByte('{')
+		for i, e := range elems {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			key := e.Key()
+			writeString(sb, key)
+			sb.WriteByte(':')
+			switch key {
+			case "$literal":
+				sb.WriteString(redacted)
+				continue
+			case "$lookup":
+				if doc, ok := e.Value().DocumentOK(); ok {
+					if from, ok := doc.Lookup("from").StringValueOK(); ok {
+						*lookups = append(*lookups, from)
+					}
+				}
+			}
+			writeValue(sb, e.Value(), lookups)
+		}
+		sb.WriteByte('}')
+	case bsontype.Array:
+		vals, err := v.Array().Values()
+		if err != nil {
+			sb.WriteString(redacted)
+			return
+		}
+		sb.WriteByte('[')
+		for i, e := range vals {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeValue(sb, e, lookups)
+		}
+		sb.WriteByte(']')
+	case bsontype.String:
+		s := v.StringValue()
+		if strings.HasPrefix(s, "$$") {
+			sb.WriteString(redacted)
+			return
+		}
+		writeString(sb, s)
+	default:
+		sb.WriteString(redacted)
+	}
+}
+
+// writeString writes s to sb as a JSON string.
+func writeString(sb *strings.Builder, s string) {
+	b, _ := json.Marshal(s)
+	sb.Write(b)
+}
@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package otel_test
+
+import (
+	"context"
+
+	ddotel "gopkg.in/DataDog/dd-trace-go.v1/contrib/go.opentelemetry.io/otel"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"go.opentelemetry.io/otel"
+)
+
+func Example() {
+	// Start the Datadog tracer and register it as the OpenTelemetry
+	// tracer provider.
+	provider := ddotel.NewTracerProvider(tracer.WithServiceName("my-service"))
+	defer provider.Shutdown()
+	otel.SetTracerProvider(provider)
+
+	// Spans started using the OpenTelemetry API are now sent to Datadog.
+	ctx, span := otel.Tracer("my-library").Start(context.Background(), "web.request")
+	defer span.End()
+
+	// Datadog spans started from ctx are children of the OpenTelemetry span.
+	child, _ := tracer.StartSpanFromContext(ctx, "db.query")
+	child.Finish()
+}
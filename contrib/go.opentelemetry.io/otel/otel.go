@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package otel provides an implementation of the OpenTelemetry tracing API
+// (go.opentelemetry.io/otel/trace) on top of the Datadog tracer. Spans created
+// using the returned TracerProvider are regular Datadog spans and are sent to
+// the Datadog Agent, allowing libraries instrumented with OpenTelemetry to show
+// up in Datadog traces. To use it, call NewTracerProvider and register it with
+// otel.SetTracerProvider.
+//
+// Spans created through the OpenTelemetry API and spans created by Datadog
+// integrations may be freely mixed: parent-child relationships are preserved
+// in both directions using the given context.Context.
+//
+// OpenTelemetry trace IDs are 128 bits long, while Datadog trace IDs are 64 bits
+// long. The Datadog trace ID is always the lower 64 bits of the OpenTelemetry
+// trace ID; the upper 64 bits are kept alongside it and propagated, so that the
+// full trace ID is preserved across services.
+package otel // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/go.opentelemetry.io/otel"
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+var _ oteltrace.TracerProvider = (*TracerProvider)(nil)
+
+// TracerProvider implements oteltrace.TracerProvider on top of the Datadog tracer.
+type TracerProvider struct {
+	embedded.TracerProvider
+}
+
+// NewTracerProvider starts the Datadog tracer using the given options and
+// returns an OpenTelemetry compatible TracerProvider creating spans through it.
+func NewTracerProvider(opts ...tracer.StartOption) *TracerProvider {
+	tracer.Start(opts...)
+	return &TracerProvider{}
+}
+
+// Tracer implements oteltrace.TracerProvider. The instrumentation name is
+// recorded on spans as the "otel.library.name" tag.
+func (p *TracerProvider) Tracer(name string, opts ...oteltrace.TracerOption) oteltrace.Tracer {
+	cfg := oteltrace.NewTracerConfig(opts...)
+	return &otelTracer{
+		provider: p,
+		name:     name,
+		version:  cfg.InstrumentationVersion(),
+	}
+}
+
+// Shutdown stops the Datadog tracer, flushing any remaining spans.
+func (p *TracerProvider) Shutdown() {
+	tracer.Stop()
+}
+
+var _ oteltrace.Tracer = (*otelTracer)(nil)
+
+// otelTracer implements oteltrace.Tracer on top of the global Datadog tracer.
+type otelTracer struct {
+	embedded.Tracer
+
+	provider *TracerProvider
+	name     string // instrumentation library name
+	version  string // instrumentation library version
+}
+
+// Start implements oteltrace.Tracer.
+func (t *otelTracer) Start(ctx context.Context, name string, opts ...oteltrace.SpanStartOption) (context.Context, oteltrace.Span) {
+	cfg := oteltrace.NewSpanStartConfig(opts...)
+	var ddopts []ddtrace.StartSpanOption
+	if !cfg.NewRoot() {
+		if parent := parentContext(ctx); parent != nil {
+			ddopts = append(ddopts, tracer.ChildOf(parent))
+		}
+	}
+	if !cfg.Timestamp().IsZero() {
+		ddopts = append(ddopts, tracer.StartTime(cfg.Timestamp()))
+	}
+	if t.name != "" {
+		ddopts = append(ddopts, tracer.Tag(ext.OTELLibraryName, t.name))
+	}
+	if t.version != "" {
+		ddopts = append(ddopts, tracer.Tag(ext.OTELLibraryVersion, t.version))
+	}
+	if k := cfg.SpanKind(); k != oteltrace.SpanKindUnspecified && k != oteltrace.SpanKindInternal {
+		ddopts = append(ddopts, tracer.Tag(spanKindTag, k.String()))
+	}
+	s := &span{
+		dd:     tracer.StartSpan(name, ddopts...),
+		tracer: t,
+	}
+	s.SetAttributes(cfg.Attributes()...)
+	for _, l := range cfg.Links() {
+		s.AddLink(l)
+	}
+	ctx = tracer.ContextWithSpan(ctx, s.dd)
+	return oteltrace.ContextWithSpan(ctx, s), s
+}
+
+// parentContext returns the Datadog span context of the innermost span found
+// in ctx, if any. It considers spans started via this package, spans started by
+// Datadog integrations and remote OpenTelemetry span contexts, in this order.
+func parentContext(ctx context.Context) ddtrace.SpanContext {
+	ddspan, haveDD := tracer.SpanFromContext(ctx)
+	if s, ok := oteltrace.SpanFromContext(ctx).(*span); ok {
+		if !haveDD || ddspan == s.dd {
+			return s.dd.Context()
+		}
+		// A Datadog span was started as a child of s; since both are set
+		// into the context when starting s, the Datadog span is the innermost.
+	}
+	if haveDD {
+		return ddspan.Context()
+	}
+	if sc := oteltrace.SpanContextFromContext(ctx); sc.IsValid() {
+		return fromSpanContext(sc)
+	}
+	return nil
+}
+
+// w3c is used to translate span contexts between OpenTelemetry and Datadog,
+// since both retain the full 128-bit trace ID in W3C trace context headers.
+var w3c = new(tracer.W3CTraceContextPropagator)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+// fromSpanContext converts an OpenTelemetry span context into a Datadog one.
+// It returns nil if the span context can not be converted.
+func fromSpanContext(sc oteltrace.SpanContext) ddtrace.SpanContext {
+	carrier := tracer.TextMapCarrier{
+		traceparentHeader: fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), sc.TraceFlags()),
+	}
+	if ts := sc.TraceState().String(); ts != "" {
+		carrier[tracestateHeader] = ts
+	}
+	ctx, err := w3c.Extract(carrier)
+	if err != nil {
+		return nil
+	}
+	return ctx
+}
+
+// toSpanContext converts a Datadog span context into an OpenTelemetry one.
+// It returns an invalid span context if the conversion is not possible, such
+// as when the Datadog tracer is not started.
+func toSpanContext(ctx ddtrace.SpanContext) oteltrace.SpanContext {
+	carrier := tracer.TextMapCarrier{}
+	if err := w3c.Inject(ctx, carrier); err != nil {
+		return oteltrace.SpanContext{}
+	}
+	var (
+		tp  = carrier[traceparentHeader]
+		cfg oteltrace.SpanContextConfig
+		err error
+	)
+	// traceparent is formatted as "00-{trace-id}-{parent-id}-{flags}"
+	if len(tp) != 55 {
+		return oteltrace.SpanContext{}
+	}
+	if cfg.TraceID, err = oteltrace.TraceIDFromHex(tp[3:35]); err != nil {
+		return oteltrace.SpanContext{}
+	}
+	if cfg.SpanID, err = oteltrace.SpanIDFromHex(tp[36:52]); err != nil {
+		return oteltrace.SpanContext{}
+	}
+	if tp[53:] == "01" {
+		cfg.TraceFlags = oteltrace.FlagsSampled
+	}
+	if ts, err := oteltrace.ParseTraceState(carrier[tracestateHeader]); err == nil {
+		cfg.TraceState = ts
+	}
+	return oteltrace.NewSpanContext(cfg)
+}
@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestParenting(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	tr := (&TracerProvider{}).Tracer("test")
+	ctx, root := tr.Start(context.Background(), "otel.root")
+	ddspan, ctx := tracer.StartSpanFromContext(ctx, "dd.child")
+	_, child := tr.Start(ctx, "otel.grandchild", oteltrace.WithSpanKind(oteltrace.SpanKindClient))
+	child.End()
+	ddspan.Finish()
+	root.End()
+	root.End() // idempotent
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	grandchild, dd, r := spans[0], spans[1], spans[2]
+	assert.Equal("otel.root", r.OperationName())
+	assert.Equal("test", r.Tag("otel.library.name"))
+	assert.Equal(r.SpanID(), dd.ParentID())
+	assert.Equal(dd.SpanID(), grandchild.ParentID())
+	assert.Equal(r.TraceID(), grandchild.TraceID())
+	assert.Equal("client", grandchild.Tag(spanKindTag))
+	assert.False(child.IsRecording())
+
+	t.Run("new-root", func(t *testing.T) {
+		mt.Reset()
+		_, s := tr.Start(ctx, "otel.root", oteltrace.WithNewRoot())
+		s.End()
+		assert.Zero(mt.FinishedSpans()[0].ParentID())
+	})
+}
+
+func TestSetAttributes(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, s := (&TracerProvider{}).Tracer("").Start(context.Background(), "op",
+		oteltrace.WithAttributes(attribute.String("str", "a")))
+	s.SetAttributes(
+		attribute.Bool("bool", true),
+		attribute.Int64("int", 42),
+		attribute.Float64("float", 1.5),
+		attribute.StringSlice("strs", []string{"a", "b"}),
+		attribute.Int64Slice("ints", []int64{1, 2}),
+		attribute.BoolSlice("bools", []bool{false}),
+		attribute.Float64Slice("floats", []float64{0.5}),
+	)
+	s.SetName("new.op")
+	s.End()
+
+	span := mt.FinishedSpans()[0]
+	assert.Equal("new.op", span.OperationName())
+	assert.Equal("a", span.Tag("str"))
+	assert.Equal(true, span.Tag("bool"))
+	assert.Equal(int64(42), span.Tag("int"))
+	assert.Equal(1.5, span.Tag("float"))
+	assert.Equal("a", span.Tag("strs.0"))
+	assert.Equal("b", span.Tag("strs.1"))
+	assert.Equal(int64(2), span.Tag("ints.1"))
+	assert.Equal(false, span.Tag("bools.0"))
+	assert.Equal(0.5, span.Tag("floats.0"))
+}
+
+func TestStatus(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	tr := (&TracerProvider{}).Tracer("")
+	_, s := tr.Start(context.Background(), "op")
+	s.RecordError(errors.New("boom"))
+	s.End()
+	span := mt.FinishedSpans()[0]
+	assert.Equal("boom", span.Tag(ext.ErrorMsg))
+	assert.Equal("*errors.errorString", span.Tag(ext.ErrorType))
+	assert.Nil(span.Tag(ext.Error))
+
+	mt.Reset()
+	_, s = tr.Start(context.Background(), "op")
+	s.SetStatus(codes.Ok, "")
+	s.SetStatus(codes.Error, "failed")
+	s.End()
+	span = mt.FinishedSpans()[0]
+	assert.Equal(true, span.Tag(ext.Error))
+	assert.Equal("failed", span.Tag(ext.ErrorMsg))
+}
+
+func TestLinks(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	sc := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2},
+		SpanID:     oteltrace.SpanID{0, 0, 0, 0, 0, 0, 0, 3},
+		TraceFlags: oteltrace.FlagsSampled,
+	})
+	_, s := (&TracerProvider{}).Tracer("").Start(context.Background(), "op",
+		oteltrace.WithLinks(oteltrace.Link{SpanContext: sc, Attributes: []attribute.KeyValue{attribute.Int("n", 1)}}))
+	s.AddLink(oteltrace.Link{}) // invalid, ignored
+	s.End()
+
+	links := mt.FinishedSpans()[0].Links()
+	assert.Equal([]ddtrace.SpanLink{{
+		TraceIDHigh: 1,
+		TraceID:     2,
+		SpanID:      3,
+		Attributes:  map[string]string{"n": "1"},
+		Flags:       1<<31 | 1,
+	}}, links)
+}
+
+func TestAddEvent(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ts := time.Unix(1, 0)
+	_, s := (&TracerProvider{}).Tracer("").Start(context.Background(), "op")
+	s.AddEvent("cache.miss", oteltrace.WithTimestamp(ts), oteltrace.WithAttributes(attribute.String("key", "user:1")))
+	s.AddEvent("db.query", oteltrace.WithAttributes(attribute.Int64("rows", 3)))
+	s.End()
+
+	events := mt.FinishedSpans()[0].Events()
+	assert.Len(events, 2)
+	assert.Equal("cache.miss", events[0].Name())
+	assert.Equal(ts, events[0].Timestamp())
+	assert.Equal(map[string]interface{}{"key": "user:1"}, events[0].Attributes())
+	assert.Equal("db.query", events[1].Name())
+	assert.Equal(map[string]interface{}{"rows": int64(3)}, events[1].Attributes())
+}
+
+func TestSpanContext(t *testing.T) {
+	assert := assert.New(t)
+	tracer.Start(tracer.WithAgentAddr("localhost:0"))
+	defer tracer.Stop()
+
+	tid, err := oteltrace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.NoError(err)
+	remote := oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    tid,
+		SpanID:     oteltrace.SpanID{0, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: oteltrace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx := oteltrace.ContextWithRemoteSpanContext(context.Background(), remote)
+	ctx, s := (&TracerProvider{}).Tracer("").Start(ctx, "op")
+	defer s.End()
+
+	sc := s.SpanContext()
+	assert.True(sc.IsValid())
+	assert.False(sc.IsRemote())
+	assert.True(sc.IsSampled())
+	assert.Equal(remote.TraceID(), sc.TraceID(), "the 128-bit trace ID is preserved")
+	assert.NotEqual(remote.SpanID(), sc.SpanID())
+
+	ddspan, ok := tracer.SpanFromContext(ctx)
+	assert.True(ok)
+	assert.Equal(uint64(0xa3ce929d0e0e4736), ddspan.Context().TraceID(), "Datadog uses the lower 64 bits")
+
+	// children of Datadog spans keep the full trace ID as well
+	child, ctx := tracer.StartSpanFromContext(ctx, "dd.child")
+	defer child.Finish()
+	_, grandchild := (&TracerProvider{}).Tracer("").Start(ctx, "op")
+	defer grandchild.End()
+	assert.Equal(remote.TraceID(), grandchild.SpanContext().TraceID())
+}
@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package otel
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// spanKindTag holds the OpenTelemetry span kind (e.g. "server", "client").
+const spanKindTag = "span.kind"
+
+var _ oteltrace.Span = (*span)(nil)
+
+// span implements oteltrace.Span on top of ddtrace.Span.
+type span struct {
+	embedded.Span
+
+	dd     ddtrace.Span // underlying Datadog span
+	tracer *otelTracer
+
+	mu       sync.RWMutex // guards below fields
+	finished bool
+}
+
+// End implements oteltrace.Span.
+func (s *span) End(opts ...oteltrace.SpanEndOption) {
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	s.mu.Unlock()
+
+	cfg := oteltrace.NewSpanEndConfig(opts...)
+	var ddopts []ddtrace.FinishOption
+	if !cfg.Timestamp().IsZero() {
+		ddopts = append(ddopts, tracer.FinishTime(cfg.Timestamp()))
+	}
+	s.dd.Finish(ddopts...)
+}
+
+// AddEvent implements oteltrace.Span.
+func (s *span) AddEvent(name string, opts ...oteltrace.EventOption) {
+	cfg := oteltrace.NewEventConfig(opts...)
+	ddopts := []ddtrace.EventOption{tracer.WithTimestamp(cfg.Timestamp())}
+	if attrs := cfg.Attributes(); len(attrs) > 0 {
+		m := make(map[string]interface{}, len(attrs))
+		for _, kv := range attrs {
+			m[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		ddopts = append(ddopts, tracer.WithAttributes(m))
+	}
+	s.dd.AddEvent(name, ddopts...)
+}
+
+// AddLink implements oteltrace.Span.
+func (s *span) AddLink(link oteltrace.Link) {
+	if !link.SpanContext.IsValid() {
+		return
+	}
+	tid := link.SpanContext.TraceID()
+	sid := link.SpanContext.SpanID()
+	l := ddtrace.SpanLink{
+		TraceIDHigh: beUint64(tid[:8]),
+		TraceID:     beUint64(tid[8:]),
+		SpanID:      beUint64(sid[:]),
+		Tracestate:  link.SpanContext.TraceState().String(),
+		Flags:       uint32(link.SpanContext.TraceFlags()) | 1<<31,
+	}
+	if len(link.Attributes) > 0 {
+		l.Attributes = make(map[string]string, len(link.Attributes))
+		for _, kv := range link.Attributes {
+			l.Attributes[string(kv.Key)] = kv.Value.Emit()
+		}
+	}
+	s.dd.AddLink(l)
+}
+
+// IsRecording implements oteltrace.Span.
+func (s *span) IsRecording() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.finished
+}
+
+// RecordError implements oteltrace.Span. As required by OpenTelemetry, it does
+// not change the span's status; use SetStatus to mark the span as erroneous.
+func (s *span) RecordError(err error, opts ...oteltrace.EventOption) {
+	if err == nil {
+		return
+	}
+	s.dd.SetTag(ext.ErrorMsg, err.Error())
+	s.dd.SetTag(ext.ErrorType, reflect.TypeOf(err).String())
+}
+
+// SpanContext implements oteltrace.Span.
+func (s *span) SpanContext() oteltrace.SpanContext {
+	return toSpanContext(s.dd.Context())
+}
+
+// SetStatus implements oteltrace.Span. An Error status marks the Datadog span as
+// erroneous, using the description as error message.
+func (s *span) SetStatus(code codes.Code, description string) {
+	if code != codes.Error {
+		return
+	}
+	s.dd.SetTag(ext.Error, true)
+	if description != "" {
+		s.dd.SetTag(ext.ErrorMsg, description)
+	}
+}
+
+// SetName implements oteltrace.Span.
+func (s *span) SetName(name string) {
+	s.dd.SetOperationName(name)
+}
+
+// SetAttributes implements oteltrace.Span. Slice attributes are set as one tag
+// per element, suffixed by the element's index (e.g. "key.0", "key.1").
+func (s *span) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		k := string(attr.Key)
+		switch attr.Value.Type() {
+		case attribute.BOOL:
+			s.dd.SetTag(k, attr.Value.AsBool())
+		case attribute.INT64:
+			s.dd.SetTag(k, attr.Value.AsInt64())
+		case attribute.FLOAT64:
+			s.dd.SetTag(k, attr.Value.AsFloat64())
+		case attribute.STRING:
+			s.dd.SetTag(k, attr.Value.AsString())
+		case attribute.BOOLSLICE:
+			for i, v := range attr.Value.AsBoolSlice() {
+				s.dd.SetTag(k+"."+strconv.Itoa(i), v)
+			}
+		case attribute.INT64SLICE:
+			for i, v := range attr.Value.AsInt64Slice() {
+				s.dd.SetTag(k+"."+strconv.Itoa(i), v)
+			}
+		case attribute.FLOAT64SLICE:
+			for i, v := range attr.Value.AsFloat64Slice() {
+				s.dd.SetTag(k+"."+strconv.Itoa(i), v)
+			}
+		case attribute.STRINGSLICE:
+			for i, v := range attr.Value.AsStringSlice() {
+				s.dd.SetTag(k+"."+strconv.Itoa(i), v)
+			}
+		default:
+			// invalid or unknown type
+		}
+	}
+}
+
+// TracerProvider implements oteltrace.Span.
+func (s *span) TracerProvider() oteltrace.TracerProvider {
+	return s.tracer.provider
+}
+
+// beUint64 decodes a big-endian uint64 from the first 8 bytes of b.
+func beUint64(b []byte) uint64 {
+	var v uint64
+	for _, c := range b[:8] {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
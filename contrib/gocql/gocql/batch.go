@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package gocql
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	tagTable      = "cassandra.table"
+	tagBatchSize  = "cassandra.batch_size"
+	tagBatchIndex = "cassandra.batch_index"
+)
+
+// Batch inherits from gocql.Batch, it keeps the tracer and the context.
+type Batch struct {
+	*gocql.Batch
+	config *queryConfig
+	ctx    context.Context
+}
+
+// WrapBatch wraps a gocql.Batch into a traced Batch. The batch must be executed
+// using the ExecuteBatch method of the returned Batch for its span to be created.
+// As with WrapQuery, any method returning the batch for chaining should be called
+// before WrapBatch, except for WithContext.
+func WrapBatch(b *gocql.Batch, opts ...WrapOption) *Batch {
+	cfg := new(queryConfig)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if cfg.resourceName == "" {
+		cfg.resourceName = "BATCH"
+	}
+	return &Batch{Batch: b, config: cfg, ctx: b.Context()}
+}
+
+// WithContext adds the specified context to the traced Batch structure.
+func (tb *Batch) WithContext(ctx context.Context) *Batch {
+	tb.ctx = ctx
+	tb.Batch = tb.Batch.WithContext(ctx)
+	return tb
+}
+
+// ExecuteBatch executes the batch on the given session within a span. If enabled
+// using WithBatchStatementSpans, each statement of the batch is given a child span.
+func (tb *Batch) ExecuteBatch(session *gocql.Session) error {
+	span, children := tb.startSpans()
+	// execute the batch with the span in its context, so that any observer of the
+	// batch can use it as a parent
+	err := session.ExecuteBatch(tb.Batch.WithContext(tracer.ContextWithSpan(tb.ctx, span)))
+	for _, child := range children {
+		tb.finishSpan(child, err)
+	}
+	tb.finishSpan(span, err)
+	return err
+}
+
+// startSpans starts the span of the batch and, if enabled, the spans of each of
+// its statements.
+func (tb *Batch) startSpans() (span ddtrace.Span, children []ddtrace.Span) {
+	cfg := tb.config
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeCassandra),
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName(cfg.resourceName),
+		tracer.Tag(ext.CassandraKeyspace, tb.Batch.Keyspace()),
+		tracer.Tag(ext.CassandraConsistencyLevel, tb.Batch.GetConsistency().String()),
+		tracer.Tag(tagBatchSize, tb.Batch.Size()),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	span, ctx := tracer.StartSpanFromContext(tb.ctx, "cassandra.batch", opts...)
+	if !cfg.batchStatementSpans {
+		return span, nil
+	}
+	children = make([]ddtrace.Span, len(tb.Batch.Entries))
+	for i, entry := range tb.Batch.Entries {
+		keyspace, table := statementTable(entry.Stmt)
+		if keyspace == "" {
+			keyspace = tb.Batch.Keyspace()
+		}
+		children[i], _ = tracer.StartSpanFromContext(ctx, ext.CassandraQuery,
+			tracer.SpanType(ext.SpanTypeCassandra),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(obfuscateStatement(entry.Stmt)),
+			tracer.Tag(ext.CassandraKeyspace, keyspace),
+			tracer.Tag(tagTable, table),
+			tracer.Tag(tagBatchIndex, i),
+		)
+	}
+	return span, children
+}
+
+func (tb *Batch) finishSpan(span ddtrace.Span, err error) {
+	if tb.config.noDebugStack {
+		span.Finish(tracer.WithError(err), tracer.NoDebugStack())
+	} else {
+		span.Finish(tracer.WithError(err))
+	}
+}
+
+var (
+	tableRegexp   = regexp.MustCompile(`(?i)^\s*(?:INSERT\s+INTO|UPDATE|DELETE\s+.*?\s*FROM)\s+([\w."]+)`)
+	literalRegexp = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+)
+
+// statementTable returns the keyspace and table modified by the given CQL statement.
+// The keyspace is empty if the statement does not name it.
+func statementTable(stmt string) (keyspace, table string) {
+	m := tableRegexp.FindStringSubmatch(stmt)
+	if m == nil {
+		return "", ""
+	}
+	name := strings.Replace(m[1], `"`, "", -1)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// obfuscateStatement replaces the string and numeric literals of the given CQL
+// statement with "?".
+func obfuscateStatement(stmt string) string {
+	return literalRegexp.ReplaceAllString(stmt, "?")
+}
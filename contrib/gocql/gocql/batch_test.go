@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package gocql
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cluster := newCassandraCluster()
+	cluster.Keyspace = "trace"
+	session, err := cluster.CreateSession()
+	assert.Nil(err)
+	defer session.Close()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parentSpan")
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query("INSERT INTO person (name, age, description) VALUES (?, ?, ?)", "Lucy", 25, "A cat")
+	b.Query("UPDATE trace.person SET age = 26 WHERE name = 'Lucy'")
+	err = WrapBatch(b, WithServiceName("TestServiceName"), WithBatchStatementSpans(true)).
+		WithContext(ctx).
+		ExecuteBatch(session)
+	assert.Nil(err)
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 4)
+	first, second, batch := spans[0], spans[1], spans[2]
+
+	assert.Equal("cassandra.batch", batch.OperationName())
+	assert.Equal("BATCH", batch.Tag(ext.ResourceName))
+	assert.Equal("TestServiceName", batch.Tag(ext.ServiceName))
+	assert.Equal("trace", batch.Tag(ext.CassandraKeyspace))
+	assert.Equal(2, batch.Tag(tagBatchSize))
+	assert.Equal(spans[3].SpanID(), batch.ParentID())
+
+	assert.Equal(ext.CassandraQuery, first.OperationName())
+	assert.Equal("INSERT INTO person (name, age, description) VALUES (?, ?, ?)", first.Tag(ext.ResourceName))
+	assert.Equal("trace", first.Tag(ext.CassandraKeyspace))
+	assert.Equal("person", first.Tag(tagTable))
+	assert.Equal(0, first.Tag(tagBatchIndex))
+	assert.Equal(batch.SpanID(), first.ParentID())
+
+	assert.Equal("UPDATE trace.person SET age = ? WHERE name = ?", second.Tag(ext.ResourceName))
+	assert.Equal(1, second.Tag(tagBatchIndex))
+	assert.Equal(batch.SpanID(), second.ParentID())
+}
+
+func TestBatchSpans(t *testing.T) {
+	b := &gocql.Batch{Cons: gocql.One}
+	b.Query("INSERT INTO ks.events (id, payload) VALUES (1, 'secret')")
+	b.Query("DELETE FROM events WHERE id = 2")
+
+	t.Run("default", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		span, children := WrapBatch(b).startSpans()
+		assert.Len(children, 0)
+		span.Finish()
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal("ONE", spans[0].Tag(ext.CassandraConsistencyLevel))
+		assert.Equal(2, spans[0].Tag(tagBatchSize))
+		assert.Equal("gocql.query", spans[0].Tag(ext.ServiceName))
+	})
+
+	t.Run("statements", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		span, children := WrapBatch(b, WithBatchStatementSpans(true)).startSpans()
+		assert.Len(children, 2)
+		for _, child := range children {
+			child.Finish()
+		}
+		span.Finish()
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 3)
+		assert.Equal("INSERT INTO ks.events (id, payload) VALUES (?, ?)", spans[0].Tag(ext.ResourceName))
+		assert.Equal("ks", spans[0].Tag(ext.CassandraKeyspace))
+		assert.Equal("events", spans[0].Tag(tagTable))
+		assert.Equal("DELETE FROM events WHERE id = ?", spans[1].Tag(ext.ResourceName))
+		assert.Equal("", spans[1].Tag(ext.CassandraKeyspace))
+		assert.Equal("events", spans[1].Tag(tagTable))
+		assert.Equal(1, spans[1].Tag(tagBatchIndex))
+	})
+}
+
+func TestStatementTable(t *testing.T) {
+	for stmt, want := range map[string][2]string{
+		"INSERT INTO person (name) VALUES (?)":      {"", "person"},
+		`insert into "trace"."person" (name)`:       {"trace", "person"},
+		"UPDATE trace.person SET age = 1":           {"trace", "person"},
+		"DELETE age FROM trace.person WHERE id = 1": {"trace", "person"},
+		"SELECT * FROM person":                      {"", ""},
+	} {
+		keyspace, table := statementTable(stmt)
+		assert.Equal(t, want, [2]string{keyspace, table}, stmt)
+	}
+}
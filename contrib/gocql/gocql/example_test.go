@@ -35,3 +35,18 @@ func Example() {
 	// Execute your query as usual
 	tracedQuery.Exec()
 }
+
+// To trace batches, wrap them using WrapBatch and execute them using its ExecuteBatch method.
+func ExampleWrapBatch() {
+	cluster := gocql.NewCluster("127.0.0.1")
+	cluster.Keyspace = "trace"
+	session, _ := cluster.CreateSession()
+
+	batch := session.NewBatch(gocql.UnloggedBatch)
+	batch.Query("INSERT INTO person (name, age) VALUES (?, ?)", "Lucy", 25)
+	batch.Query("INSERT INTO person (name, age) VALUES (?, ?)", "Max", 31)
+
+	// Each statement of the batch gets its own child span
+	tracedBatch := gocqltrace.WrapBatch(batch, gocqltrace.WithBatchStatementSpans(true))
+	tracedBatch.WithContext(context.Background()).ExecuteBatch(session)
+}
@@ -13,6 +13,7 @@ type queryConfig struct {
 	serviceName, resourceName string
 	noDebugStack              bool
 	analyticsRate             float64
+	batchStatementSpans       bool
 }
 
 // WrapOption represents an option that can be passed to WrapQuery.
@@ -74,3 +75,12 @@ func NoDebugStack() WrapOption {
 		cfg.noDebugStack = true
 	}
 }
+
+// WithBatchStatementSpans enables the creation of a child span for each statement of
+// a batch wrapped using WrapBatch. The resource of these spans is the statement, with
+// its literals obfuscated.
+func WithBatchStatementSpans(on bool) WrapOption {
+	return func(cfg *queryConfig) {
+		cfg.batchStatementSpans = on
+	}
+}
@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package fiber_test
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	fibertrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/gofiber/fiber.v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	// Start the tracer
+	tracer.Start()
+	defer tracer.Stop()
+
+	// Create a fiber app and use the tracer middleware with your desired service name.
+	app := fiber.New()
+	app.Use(fibertrace.Middleware(fibertrace.WithServiceName("fiber-server")))
+
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		// Spans started from the user context are children of the request span
+		span, _ := tracer.StartSpanFromContext(c.UserContext(), "load.user")
+		defer span.Finish()
+		return c.SendString("Hello " + c.Params("id"))
+	})
+	app.Listen(":8080")
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package fiber provides tracing functions for tracing the gofiber/fiber/v2 package (https://github.com/gofiber/fiber).
+package fiber // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/gofiber/fiber.v2"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Middleware returns middleware that will trace incoming requests. The span is passed to
+// the next handlers through the user context of the request, see fiber.Ctx.UserContext,
+// and its context is injected into the response headers. The resource name of the span
+// is the path of the route which handled the request.
+func Middleware(opts ...Option) fiber.Handler {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return func(c *fiber.Ctx) error {
+		opts := []ddtrace.StartSpanOption{
+			tracer.SpanType(ext.SpanTypeWeb),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.Tag(ext.HTTPMethod, c.Method()),
+			// the path is only valid within the handler, but the tag outlives it;
+			// the query string is left out as it may hold sensitive data
+			tracer.Tag(ext.HTTPURL, utils.CopyString(c.Path())),
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		if spanctx, err := tracer.Extract(requestHeaderCarrier{&c.Request().Header}); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+		opts = append(opts, cfg.spanOpts...)
+		span, ctx := tracer.StartSpanFromContext(c.UserContext(), "http.request", opts...)
+		defer span.Finish()
+
+		tracer.Inject(span.Context(), responseHeaderCarrier{&c.Response().Header})
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		// the route is only known once the handlers have run
+		route := c.Route().Path
+		span.SetTag(ext.ResourceName, c.Method()+" "+route)
+		span.SetTag(ext.HTTPRoute, route)
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// the response is written by the error handler once the middleware
+			// returns, so the status is derived from the error the way the default
+			// error handler does it
+			status = fiber.StatusInternalServerError
+			var e *fiber.Error
+			if errors.As(err, &e) {
+				status = e.Code
+			}
+		}
+		span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+		if status >= 500 && status < 600 {
+			if err != nil {
+				span.SetTag(ext.Error, err)
+			} else {
+				span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+			}
+		}
+		return err
+	}
+}
+
+// requestHeaderCarrier implements tracer.TextMapReader for the headers of fasthttp requests.
+type requestHeaderCarrier struct{ h *fasthttp.RequestHeader }
+
+// ForeachKey implements tracer.TextMapReader.
+func (c requestHeaderCarrier) ForeachKey(handler func(key, val string) error) error {
+	var err error
+	c.h.VisitAll(func(k, v []byte) {
+		if err == nil {
+			err = handler(string(k), string(v))
+		}
+	})
+	return err
+}
+
+// responseHeaderCarrier implements tracer.TextMapWriter for the headers of fasthttp responses.
+type responseHeaderCarrier struct{ h *fasthttp.ResponseHeader }
+
+// Set implements tracer.TextMapWriter.
+func (c responseHeaderCarrier) Set(key, val string) {
+	c.h.Set(key, val)
+}
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package fiber
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrace200(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	app := fiber.New()
+	app.Use(Middleware(WithServiceName("foobar")))
+	app.Get("/user/:id", func(c *fiber.Ctx) error {
+		span, ok := tracer.SpanFromContext(c.UserContext())
+		assert.True(ok)
+		assert.Equal("foobar", span.(mocktracer.Span).Tag(ext.ServiceName))
+		return c.SendString(c.Params("id"))
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/user/123?page=2", nil))
+	assert.NoError(err)
+	assert.Equal(200, resp.StatusCode)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal(ext.SpanTypeWeb, span.Tag(ext.SpanType))
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("GET /user/:id", span.Tag(ext.ResourceName))
+	assert.Equal("/user/:id", span.Tag(ext.HTTPRoute))
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal("GET", span.Tag(ext.HTTPMethod))
+	assert.Equal("/user/123", span.Tag(ext.HTTPURL))
+	assert.Nil(span.Tag(ext.Error))
+
+	// the span context is injected into the response
+	assert.Equal(strconv.FormatUint(span.TraceID(), 10), resp.Header.Get("X-Datadog-Trace-Id"))
+	assert.Equal(strconv.FormatUint(span.SpanID(), 10), resp.Header.Get("X-Datadog-Parent-Id"))
+}
+
+func TestError(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/status", func(c *fiber.Ctx) error {
+		return c.SendStatus(503)
+	})
+	app.Get("/fiber-error", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadGateway, "upstream failed")
+	})
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return errors.New("oops")
+	})
+	app.Get("/not-found", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	for _, tt := range []struct {
+		url    string
+		status int
+		err    string
+	}{
+		{url: "/status", status: 503, err: "503: Service Unavailable"},
+		{url: "/fiber-error", status: 502, err: "upstream failed"},
+		{url: "/error", status: 500, err: "oops"},
+		{url: "/not-found", status: 404},
+	} {
+		t.Run(tt.url, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.url, nil))
+			assert.NoError(err)
+			assert.Equal(tt.status, resp.StatusCode)
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			span := spans[0]
+			assert.Equal(strconv.Itoa(tt.status), span.Tag(ext.HTTPCode))
+			if tt.err == "" {
+				assert.Nil(span.Tag(ext.Error))
+			} else {
+				assert.Equal(tt.err, span.Tag(ext.Error).(error).Error())
+			}
+		})
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	r := httptest.NewRequest("GET", "/user/123", nil)
+	pspan := tracer.StartSpan("test")
+	tracer.Inject(pspan.Context(), tracer.HTTPHeadersCarrier(r.Header))
+
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/user/:id", func(c *fiber.Ctx) error {
+		span, ok := tracer.SpanFromContext(c.UserContext())
+		assert.True(ok)
+		assert.Equal(pspan.(mocktracer.Span).SpanID(), span.(mocktracer.Span).ParentID())
+		return nil
+	})
+
+	resp, err := app.Test(r)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		app := fiber.New()
+		app.Use(Middleware(opts...))
+		app.Get("/user/:id", func(c *fiber.Ctx) error { return nil })
+
+		_, err := app.Test(httptest.NewRequest("GET", "/user/123", nil))
+		assert.NoError(t, err)
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("global", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rate := globalconfig.AnalyticsRate()
+		defer globalconfig.SetAnalyticsRate(rate)
+		globalconfig.SetAnalyticsRate(0.4)
+
+		assertRate(t, mt, 0.4)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
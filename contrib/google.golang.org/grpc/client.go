@@ -21,44 +21,15 @@ import (
 
 type clientStream struct {
 	grpc.ClientStream
-	cfg    *config
-	method string
+	messages *streamMessages
 }
 
-func (cs *clientStream) RecvMsg(m interface{}) (err error) {
-	if cs.cfg.traceStreamMessages {
-		span, _ := startSpanFromContext(
-			cs.Context(),
-			cs.method,
-			"grpc.message",
-			cs.cfg.clientServiceName(),
-			cs.cfg.analyticsRate,
-		)
-		if p, ok := peer.FromContext(cs.Context()); ok {
-			setSpanTargetFromPeer(span, *p)
-		}
-		defer func() { finishWithError(span, err, cs.cfg) }()
-	}
-	err = cs.ClientStream.RecvMsg(m)
-	return err
+func (cs *clientStream) RecvMsg(m interface{}) error {
+	return cs.messages.do(cs.Context(), messageTypeRecv, m, cs.ClientStream.RecvMsg)
 }
 
-func (cs *clientStream) SendMsg(m interface{}) (err error) {
-	if cs.cfg.traceStreamMessages {
-		span, _ := startSpanFromContext(
-			cs.Context(),
-			cs.method,
-			"grpc.message",
-			cs.cfg.clientServiceName(),
-			cs.cfg.analyticsRate,
-		)
-		if p, ok := peer.FromContext(cs.Context()); ok {
-			setSpanTargetFromPeer(span, *p)
-		}
-		defer func() { finishWithError(span, err, cs.cfg) }()
-	}
-	err = cs.ClientStream.SendMsg(m)
-	return err
+func (cs *clientStream) SendMsg(m interface{}) error {
+	return cs.messages.do(cs.Context(), messageTypeSend, m, cs.ClientStream.SendMsg)
 }
 
 // StreamClientInterceptor returns a grpc.StreamClientInterceptor which will trace client
@@ -110,7 +81,7 @@ func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
 
 			// it's possible there's already a span on the context even though
 			// we're not tracing calls, so inject it if it's there
-			ctx = injectSpanIntoContext(ctx)
+			ctx = injectSpanIntoContext(ctx, cfg)
 
 			var err error
 			stream, err = streamer(ctx, desc, cc, method, opts...)
@@ -118,16 +89,34 @@ func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
 				return nil, err
 			}
 		}
+		messages := &streamMessages{
+			cfg:     cfg,
+			method:  method,
+			service: cfg.clientServiceName(),
+			started: func(span ddtrace.Span) {
+				if p, ok := peer.FromContext(stream.Context()); ok {
+					setSpanTargetFromPeer(span, *p)
+				}
+			},
+		}
+		if cfg.traceStreamMessages && cfg.streamBatchSize > 1 {
+			go func() {
+				<-stream.Context().Done()
+				messages.flush()
+			}()
+		}
 		return &clientStream{
 			ClientStream: stream,
-			cfg:          cfg,
-			method:       method,
+			messages:     messages,
 		}, nil
 	}
 }
 
 // UnaryClientInterceptor returns a grpc.UnaryClientInterceptor which will trace requests using
-// the given set of options.
+// the given set of options. When the client stats handler returned by NewClientStatsHandler is
+// also installed, it reports the attempts made by the call instead of tracing them separately:
+// the span is then tagged with the number of attempts, and each retry is recorded as a
+// "grpc.retry" event on it.
 func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
 	cfg := new(config)
 	defaults(cfg)
@@ -135,10 +124,13 @@ func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
 		fn(cfg)
 	}
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, retries := withRetryTracker(ctx)
 		span, err := doClientRequest(ctx, cfg, method, methodKindUnary, opts,
 			func(ctx context.Context, opts []grpc.CallOption) error {
 				return invoker(ctx, method, req, reply, cc, opts...)
 			})
+		setRequestTag(span, req, cfg)
+		retries.finish(span)
 		finishWithError(span, err, cfg)
 		return err
 	}
@@ -161,7 +153,7 @@ func doClientRequest(
 	if methodKind != "" {
 		span.SetTag(tagMethodKind, methodKind)
 	}
-	ctx = injectSpanIntoContext(ctx)
+	ctx = injectSpanIntoContext(ctx, cfg)
 
 	// fill in the peer so we can add it to the tags
 	var p peer.Peer
@@ -190,7 +182,7 @@ func setSpanTargetFromPeer(span ddtrace.Span, p peer.Peer) {
 
 // injectSpanIntoContext injects the span associated with a context as gRPC metadata
 // if no span is associated with the context, just return the original context.
-func injectSpanIntoContext(ctx context.Context) context.Context {
+func injectSpanIntoContext(ctx context.Context, cfg *config) context.Context {
 	span, ok := tracer.SpanFromContext(ctx)
 	if !ok {
 		return ctx
@@ -206,5 +198,8 @@ func injectSpanIntoContext(ctx context.Context) context.Context {
 		// in practice this error should never really happen
 		grpclog.Warningf("ddtrace: failed to inject the span context into the gRPC metadata: %v", err)
 	}
+	if cfg.binaryPropagation {
+		injectBinary(span.Context(), md)
+	}
 	return metadata.NewOutgoingContext(ctx, md)
 }
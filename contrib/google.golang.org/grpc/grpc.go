@@ -9,6 +9,8 @@
 package grpc // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/google.golang.org/grpc"
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"math"
 
@@ -17,10 +19,12 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
+	"github.com/golang/protobuf/proto"
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func startSpanFromContext(
@@ -38,6 +42,8 @@ func startSpanFromContext(
 	md, _ := metadata.FromIncomingContext(ctx) // nil is ok
 	if sctx, err := tracer.Extract(grpcutil.MDCarrier(md)); err == nil {
 		opts = append(opts, tracer.ChildOf(sctx))
+	} else if sctx, err := extractBinary(md); err == nil {
+		opts = append(opts, tracer.ChildOf(sctx))
 	}
 	return tracer.StartSpanFromContext(ctx, operation, opts...)
 }
@@ -48,7 +54,14 @@ func finishWithError(span ddtrace.Span, err error, cfg *config) {
 		err = nil
 	}
 	errcode := status.Code(err)
-	if errcode == codes.OK || cfg.nonErrorCodes[errcode] {
+	switch {
+	case errcode == codes.OK:
+		err = nil
+	case cfg.statusMapper != nil:
+		if !cfg.statusMapper(errcode) {
+			err = nil
+		}
+	case cfg.nonErrorCodes[errcode]:
 		err = nil
 	}
 	span.SetTag(tagCode, errcode.String())
@@ -60,3 +73,62 @@ func finishWithError(span ddtrace.Span, err error, cfg *config) {
 	}
 	span.Finish(finishOptions...)
 }
+
+// redacted replaces the values of scrubbed request fields.
+const redacted = "[REDACTED]"
+
+// setRequestTag sets the JSON representation of req as a tag on span, when enabled
+// by cfg, redacting the fields to scrub.
+func setRequestTag(span ddtrace.Span, req interface{}, cfg *config) {
+	if !cfg.requestTags {
+		return
+	}
+	m, ok := req.(proto.Message)
+	if !ok {
+		return
+	}
+	b, err := protojson.Marshal(proto.MessageV2(m))
+	if err != nil {
+		return
+	}
+	if len(cfg.scrubFields) > 0 {
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return
+		}
+		for _, path := range cfg.scrubFields {
+			scrub(v, path)
+		}
+		if b, err = json.Marshal(v); err != nil {
+			return
+		}
+	} else {
+		// protojson output is not stable, so normalize it
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, b); err != nil {
+			return
+		}
+		b = buf.Bytes()
+	}
+	span.SetTag(tagRequest, string(b))
+}
+
+// scrub replaces the value found at path in v, as decoded by encoding/json.
+func scrub(v interface{}, path []string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		f, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			v[path[0]] = redacted
+			return
+		}
+		scrub(f, path[1:])
+	case []interface{}:
+		for _, e := range v {
+			scrub(e, path)
+		}
+	}
+}
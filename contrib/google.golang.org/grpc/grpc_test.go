@@ -25,6 +25,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 func TestUnary(t *testing.T) {
@@ -172,6 +173,11 @@ func TestStreaming(t *testing.T) {
 				assert.Equal(t, "/grpc.Fixture/StreamPing", span.Tag(tagMethodName),
 					"expected grpc method name to be set in span: %v", span)
 			}
+			if span.OperationName() == "grpc.message" {
+				assert.Contains(t, []interface{}{messageTypeSend, messageTypeRecv}, span.Tag(tagMessageType))
+				assert.NotNil(t, span.Tag(tagMessageIndex))
+				assert.NotNil(t, span.Tag(tagMessageSize))
+			}
 		}
 	}
 
@@ -257,6 +263,99 @@ func TestStreaming(t *testing.T) {
 	})
 }
 
+func TestStreamMessageSpans(t *testing.T) {
+	// runs a stream of two pings, waits for n spans to be finished and returns
+	// the number of message spans among them
+	messageSpans := func(t *testing.T, n int, opts ...Option) int {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rig, err := newRig(true, opts...)
+		if err != nil {
+			t.Fatalf("error setting up rig: %s", err)
+		}
+		defer rig.Close()
+
+		stream, err := rig.client.StreamPing(context.Background())
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, stream.Send(&FixtureRequest{Name: "pass"}))
+			_, err := stream.Recv()
+			assert.NoError(t, err)
+		}
+		stream.CloseSend()
+		stream.Recv()
+
+		waitForSpans(mt, n, 5*time.Second)
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, n)
+		var messages int
+		for _, span := range spans {
+			if span.OperationName() == "grpc.message" {
+				messages++
+			}
+		}
+		return messages
+	}
+
+	// 1 client call + 1 server call, and for each of them 4 messages and
+	// the message span of the final recv, which ends the stream
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, 10, messageSpans(t, 12))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		assert.Equal(t, 10, messageSpans(t, 12, WithStreamMessageSpans(true)))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Equal(t, 0, messageSpans(t, 2, WithStreamMessageSpans(false)))
+	})
+}
+
+func TestStreamBatchSize(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(true, WithStreamCalls(false), WithStreamBatchSize(3))
+	if err != nil {
+		t.Fatalf("error setting up rig: %s", err)
+	}
+	defer rig.Close()
+
+	stream, err := rig.client.StreamPing(context.Background())
+	assert.NoError(err)
+	for i := 0; i < 2; i++ {
+		assert.NoError(stream.Send(&FixtureRequest{Name: "pass"}))
+		_, err := stream.Recv()
+		assert.NoError(err)
+	}
+	stream.CloseSend()
+	stream.Recv()
+
+	// each side sends 2 messages, which are flushed once the stream ends, and
+	// receives 2 messages followed by an error (io.EOF on the server, and the
+	// resulting status on the client), which ends the batch.
+	waitForSpans(mt, 4, 5*time.Second)
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 4)
+	for _, span := range spans {
+		assert.Equal("grpc.message", span.OperationName())
+		assert.Equal(0, span.Tag(tagMessageIndex))
+		assert.NotZero(span.Tag(tagMessageSize))
+		switch span.Tag(tagMessageType) {
+		case messageTypeSend:
+			assert.Nil(span.Tag(ext.Error))
+			assert.Equal(2, span.Tag(tagMessageCount))
+		case messageTypeRecv:
+			assert.Equal(3, span.Tag(tagMessageCount))
+		default:
+			t.Fatalf("unexpected message type: %v", span.Tag(tagMessageType))
+		}
+	}
+}
+
 func TestChild(t *testing.T) {
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -395,9 +494,102 @@ func TestStreamSendsErrorCode(t *testing.T) {
 	assert.Equal(t, gotLastSpanCode, wantCode, "last span should contain error code")
 }
 
+func TestStatusCodeMapper(t *testing.T) {
+	for name, tt := range map[string]struct {
+		opts    []Option
+		code    codes.Code
+		wantErr bool
+	}{
+		"default/invalid":  {code: codes.InvalidArgument, wantErr: true},
+		"default/canceled": {code: codes.Canceled, wantErr: false},
+		"mapper/not-found": {opts: []Option{WithStatusCodeMapper(DefaultGRPCStatusMapper)}, code: codes.NotFound, wantErr: false},
+		"mapper/internal":  {opts: []Option{WithStatusCodeMapper(DefaultGRPCStatusMapper)}, code: codes.Internal, wantErr: true},
+		"mapper/overrides": {
+			opts:    []Option{NonErrorCodes(codes.Unavailable), WithStatusCodeMapper(DefaultGRPCStatusMapper)},
+			code:    codes.Unavailable,
+			wantErr: true,
+		},
+		"custom": {
+			opts:    []Option{WithStatusCodeMapper(func(c codes.Code) bool { return c == codes.Canceled })},
+			code:    codes.Canceled,
+			wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			cfg := new(config)
+			defaults(cfg)
+			for _, fn := range tt.opts {
+				fn(cfg)
+			}
+			span := tracer.StartSpan("grpc.client")
+			finishWithError(span, status.Error(tt.code, "x"), cfg)
+
+			s := mt.FinishedSpans()[0]
+			assert.Equal(t, tt.code.String(), s.Tag(tagCode))
+			assert.Equal(t, tt.wantErr, s.Tag(ext.Error) != nil)
+		})
+	}
+}
+
+func TestRequestTags(t *testing.T) {
+	t.Run("rig", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rig, err := newRig(true, WithRequestTags(true))
+		if err != nil {
+			t.Fatalf("error setting up rig: %s", err)
+		}
+		defer rig.Close()
+
+		_, err = rig.client.Ping(context.Background(), &FixtureRequest{Name: "pass"})
+		assert.NoError(t, err)
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 2)
+		for _, s := range spans {
+			assert.Equal(t, `{"name":"pass"}`, s.Tag(tagRequest), s.OperationName())
+		}
+	})
+
+	t.Run("scrub", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		req, err := structpb.NewStruct(map[string]interface{}{
+			"user": map[string]interface{}{
+				"name": "bob",
+				"credentials": map[string]interface{}{
+					"password": "hunter2",
+					"token":    "abc",
+				},
+			},
+			"items":    []interface{}{map[string]interface{}{"secret": 1}, map[string]interface{}{"id": 2}},
+			"password": "top-level",
+		})
+		assert.NoError(t, err)
+		cfg := new(config)
+		defaults(cfg)
+		WithRequestTags(true)(cfg)
+		WithScrubFields("user.credentials.password", "items.secret", "missing.field")(cfg)
+
+		span := tracer.StartSpan("grpc.server")
+		setRequestTag(span, req, cfg)
+		setRequestTag(span, "not a proto message", cfg)
+		span.Finish()
+		assert.JSONEq(t, `{
+			"user": {"name": "bob", "credentials": {"password": "[REDACTED]", "token": "abc"}},
+			"items": [{"secret": "[REDACTED]"}, {"id": 2}],
+			"password": "top-level"
+		}`, mt.FinishedSpans()[0].Tag(tagRequest).(string))
+	})
+}
+
 // fixtureServer a dummy implemenation of our grpc fixtureServer.
 type fixtureServer struct {
 	lastRequestMetadata atomic.Value
+	unavailableCalls    int32
 }
 
 func (s *fixtureServer) StreamPing(srv Fixture_StreamPingServer) error {
@@ -435,6 +627,12 @@ func (s *fixtureServer) Ping(ctx context.Context, in *FixtureRequest) (*FixtureR
 		return &FixtureReply{Message: "disabled"}, nil
 	case in.Name == "invalid":
 		return nil, status.Error(codes.InvalidArgument, "invalid")
+	case in.Name == "unavailable":
+		// fails the first two calls
+		if atomic.AddInt32(&s.unavailableCalls, 1) <= 2 {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+		return &FixtureReply{Message: "unavailable"}, nil
 	}
 	return &FixtureReply{Message: "passed"}, nil
 }
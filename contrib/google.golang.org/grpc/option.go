@@ -7,6 +7,7 @@ package grpc
 
 import (
 	"math"
+	"strings"
 
 	"google.golang.org/grpc/codes"
 )
@@ -18,9 +19,14 @@ type Option func(*config)
 type config struct {
 	serviceName         string
 	nonErrorCodes       map[codes.Code]bool
+	statusMapper        func(codes.Code) bool
+	binaryPropagation   bool
+	requestTags         bool
+	scrubFields         [][]string
 	analyticsRate       float64
 	traceStreamCalls    bool
 	traceStreamMessages bool
+	streamBatchSize     int
 	noDebugStack        bool
 }
 
@@ -47,6 +53,7 @@ func defaults(cfg *config) {
 	// cfg.serviceName defaults are set in interceptors
 	cfg.traceStreamCalls = true
 	cfg.traceStreamMessages = true
+	cfg.streamBatchSize = 1
 	cfg.nonErrorCodes = map[codes.Code]bool{codes.Canceled: true}
 	// cfg.analyticsRate = globalconfig.AnalyticsRate()
 	cfg.analyticsRate = math.NaN()
@@ -75,6 +82,28 @@ func WithStreamMessages(enabled bool) Option {
 	}
 }
 
+// WithStreamMessageSpans specifies whether a span is opened for each message sent
+// or received on a stream, as a child of the span of the streaming call. It is
+// enabled by default, and is equivalent to WithStreamMessages. This option does
+// not apply to the stats handler.
+func WithStreamMessageSpans(enabled bool) Option {
+	return WithStreamMessages(enabled)
+}
+
+// WithStreamBatchSize sets the number of streaming messages going in the same
+// direction which are grouped into a single span, bounding the overhead of tracing
+// long-lived streams. Each span then covers the time between the start of the
+// first message and the end of the last one. The default is 1, which traces
+// every message separately. This option does not apply to the stats handler.
+func WithStreamBatchSize(n int) Option {
+	return func(cfg *config) {
+		if n < 1 {
+			n = 1
+		}
+		cfg.streamBatchSize = n
+	}
+}
+
 // NoDebugStack disables debug stacks for traces with errors. This is useful in situations
 // where errors are frequent and the overhead of calling debug.Stack may affect performance.
 func NoDebugStack() Option {
@@ -85,6 +114,7 @@ func NoDebugStack() Option {
 
 // NonErrorCodes determines the list of codes which will not be considered errors in instrumentation.
 // This call overrides the default handling of codes.Canceled as a non-error.
+// It has no effect when WithStatusCodeMapper is used.
 func NonErrorCodes(cs ...codes.Code) InterceptorOption {
 	return func(cfg *config) {
 		cfg.nonErrorCodes = make(map[codes.Code]bool, len(cs))
@@ -94,6 +124,65 @@ func NonErrorCodes(cs ...codes.Code) InterceptorOption {
 	}
 }
 
+// WithStatusCodeMapper sets the function used to determine whether a non-OK status
+// code should mark the span as an error; fn reports true if it should. The status
+// code is tagged on the span regardless. It takes precedence over NonErrorCodes.
+// See DefaultGRPCStatusMapper for a mapper which only treats server-side failures
+// as errors.
+func WithStatusCodeMapper(fn func(codes.Code) bool) Option {
+	return func(cfg *config) {
+		cfg.statusMapper = fn
+	}
+}
+
+// DefaultGRPCStatusMapper is a status code mapper for use with WithStatusCodeMapper.
+// It only treats codes.Internal, codes.Unknown, codes.DataLoss and codes.Unavailable
+// as errors, since other codes such as codes.NotFound or codes.Canceled are
+// usually part of the normal operation of a service.
+func DefaultGRPCStatusMapper(c codes.Code) bool {
+	switch c {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// WithGRPCBinaryPropagation specifies whether outgoing calls should also carry the
+// span context in the binary "grpc-trace-bin" metadata key, as used by gRPC's
+// OpenCensus integration, in addition to the text keys. This allows propagation
+// through proxies which strip unknown metadata keys. Incoming calls are always
+// checked for this key when none of the text keys are found.
+func WithGRPCBinaryPropagation(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.binaryPropagation = enabled
+	}
+}
+
+// WithRequestTags specifies whether the requests of unary calls should be set as a
+// tag on client and server spans, serialized as JSON using protojson. Requests
+// which aren't proto messages are ignored. See WithScrubFields to redact sensitive
+// fields.
+func WithRequestTags(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.requestTags = enabled
+	}
+}
+
+// WithScrubFields sets the fields which are redacted from the requests tagged using
+// WithRequestTags. Fields are designated by their protojson (lowerCamelCase) names,
+// and nested fields by their dot-separated path from the request, such as
+// "user.credentials.password". Paths going through repeated fields apply to each
+// of their elements.
+func WithScrubFields(fields ...string) Option {
+	return func(cfg *config) {
+		cfg.scrubFields = make([][]string, 0, len(fields))
+		for _, f := range fields {
+			cfg.scrubFields = append(cfg.scrubFields, strings.Split(f, "."))
+		}
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) Option {
 	return func(cfg *config) {
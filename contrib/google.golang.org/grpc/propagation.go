@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package grpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// binaryMetadataKey is the metadata key holding the binary trace context, as
+// used by gRPC's OpenCensus integration.
+const binaryMetadataKey = "grpc-trace-bin"
+
+// The binary trace context is formatted as follows, see
+// https://github.com/census-instrumentation/opencensus-specs/blob/master/encodings/BinaryEncoding.md:
+//
+//	version (1 byte, 0)
+//	field ID (1 byte, 0) + trace ID (16 bytes)
+//	field ID (1 byte, 1) + span ID (8 bytes)
+//	field ID (1 byte, 2) + trace options (1 byte, where bit 0 reports sampling)
+const (
+	binaryTraceIDField = 0
+	binarySpanIDField  = 1
+	binaryOptionsField = 2
+	binaryLength       = 29
+)
+
+// w3c converts span contexts to and from their W3C representation, which holds
+// the same information as the binary format.
+var w3c = &tracer.W3CTraceContextPropagator{}
+
+// injectBinary sets the binary trace context of spanCtx into md. Span contexts
+// which weren't created by the tracer, such as the ones of the mocktracer
+// package, are propagated using their 64-bit IDs and are marked as sampled.
+func injectBinary(spanCtx ddtrace.SpanContext, md metadata.MD) {
+	var (
+		traceIDHigh uint64
+		traceID     = spanCtx.TraceID()
+		spanID      = spanCtx.SpanID()
+		sampled     = true
+	)
+	tm := tracer.TextMapCarrier{}
+	if err := w3c.Inject(spanCtx, tm); err == nil {
+		// traceparent is formatted as "00-{trace-id}-{parent-id}-{flags}"
+		if tp := tm["traceparent"]; len(tp) == 55 {
+			traceIDHigh, _ = strconv.ParseUint(tp[3:19], 16, 64)
+			sampled = tp[53:55] == "01"
+		}
+	}
+	b := make([]byte, binaryLength)
+	b[1] = binaryTraceIDField
+	binary.BigEndian.PutUint64(b[2:], traceIDHigh)
+	binary.BigEndian.PutUint64(b[10:], traceID)
+	b[18] = binarySpanIDField
+	binary.BigEndian.PutUint64(b[19:], spanID)
+	b[27] = binaryOptionsField
+	if sampled {
+		b[28] = 1
+	}
+	md.Set(binaryMetadataKey, string(b))
+}
+
+// extractBinary returns the span context found in the binary trace context of md.
+func extractBinary(md metadata.MD) (ddtrace.SpanContext, error) {
+	vs := md.Get(binaryMetadataKey)
+	if len(vs) == 0 || vs[0] == "" {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	b := []byte(vs[0])
+	if len(b) < binaryLength-2 || b[0] != 0 || b[1] != binaryTraceIDField || b[18] != binarySpanIDField {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	flags := "00"
+	if len(b) >= binaryLength && b[27] == binaryOptionsField && b[28]&1 == 1 {
+		flags = "01"
+	}
+	return w3c.Extract(tracer.TextMapCarrier{
+		"traceparent": fmt.Sprintf("00-%x-%x-%s", b[2:18], b[19:27], flags),
+	})
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package grpc
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBinaryPropagation(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	assert := assert.New(t)
+	sctx, err := w3c.Extract(tracer.TextMapCarrier{"traceparent": traceparent})
+	assert.NoError(err)
+
+	md := metadata.MD{}
+	injectBinary(sctx, md)
+	bin := []byte(md.Get(binaryMetadataKey)[0])
+	assert.Equal([]byte{
+		0, 0, 0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36,
+		1, 0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7,
+		2, 1,
+	}, bin)
+
+	got, err := extractBinary(md)
+	assert.NoError(err)
+	assert.Equal(sctx.TraceID(), got.TraceID())
+	assert.Equal(sctx.SpanID(), got.SpanID())
+	tm := tracer.TextMapCarrier{}
+	assert.NoError(w3c.Inject(got, tm))
+	assert.Equal(traceparent, tm["traceparent"])
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := extractBinary(metadata.MD{})
+		assert.Equal(tracer.ErrSpanContextNotFound, err)
+		_, err = extractBinary(metadata.Pairs(binaryMetadataKey, "\x00\x00abc"))
+		assert.Equal(tracer.ErrSpanContextCorrupted, err)
+		_, err = extractBinary(metadata.Pairs(binaryMetadataKey, string(append([]byte{1}, bin[1:]...))))
+		assert.Equal(tracer.ErrSpanContextCorrupted, err)
+	})
+}
+
+func TestWithGRPCBinaryPropagation(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(true, WithGRPCBinaryPropagation(true))
+	if err != nil {
+		t.Fatalf("error setting up rig: %s", err)
+	}
+	defer rig.Close()
+
+	_, err = rig.client.Ping(context.Background(), &FixtureRequest{Name: "pass"})
+	assert.NoError(err)
+
+	var client mocktracer.Span
+	for _, s := range mt.FinishedSpans() {
+		if s.OperationName() == "grpc.client" {
+			client = s
+		}
+	}
+	assert.NotNil(client)
+	md := rig.fixtureServer.lastRequestMetadata.Load().(metadata.MD)
+	bin := []byte(md.Get(binaryMetadataKey)[0])
+	assert.Len(bin, binaryLength)
+	assert.Equal(client.TraceID(), binary.BigEndian.Uint64(bin[10:18]))
+	assert.Equal(client.SpanID(), binary.BigEndian.Uint64(bin[19:27]))
+	// the text keys are still set
+	assert.NotEmpty(md.Get(tracer.DefaultTraceIDHeader))
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	retryTrackerKey struct{}
+	attemptKey      struct{}
+)
+
+// retryTracker records the attempts made by a single unary client call. gRPC
+// retries happen below the interceptors, so attempts are reported by the client
+// stats handler, which is invoked for each of them.
+type retryTracker struct {
+	mu       sync.Mutex // guards attempts
+	attempts []*attempt
+}
+
+// attempt holds the outcome of a single attempt of a call.
+type attempt struct {
+	tracker    *retryTracker
+	begin, end time.Time
+	err        error
+}
+
+// withRetryTracker returns a copy of ctx holding a new retry tracker.
+func withRetryTracker(ctx context.Context) (context.Context, *retryTracker) {
+	rt := new(retryTracker)
+	return context.WithValue(ctx, retryTrackerKey{}, rt), rt
+}
+
+// startAttempt records the start of a new attempt if ctx holds a retry tracker,
+// returning a context holding the attempt. ok reports whether the attempt is
+// being tracked.
+func startAttempt(ctx context.Context) (_ context.Context, ok bool) {
+	rt, ok := ctx.Value(retryTrackerKey{}).(*retryTracker)
+	if !ok {
+		return ctx, false
+	}
+	a := &attempt{tracker: rt, begin: time.Now()}
+	rt.mu.Lock()
+	rt.attempts = append(rt.attempts, a)
+	rt.mu.Unlock()
+	return context.WithValue(ctx, attemptKey{}, a), true
+}
+
+// finish records the end of the attempt, with the given error.
+func (a *attempt) finish(err error) {
+	a.tracker.mu.Lock()
+	a.end, a.err = time.Now(), err
+	a.tracker.mu.Unlock()
+}
+
+// finish tags span with the number of attempts which were made, and adds an event
+// to it for each retry. Nothing is done if no attempt was tracked, such as when
+// the client stats handler isn't installed.
+func (rt *retryTracker) finish(span ddtrace.Span) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.attempts) == 0 {
+		return
+	}
+	span.SetTag(tagTotalAttempts, len(rt.attempts))
+	for i := 1; i < len(rt.attempts); i++ {
+		prev, a := rt.attempts[i-1], rt.attempts[i]
+		attrs := map[string]interface{}{
+			tagRetryAttempt:    i + 1,
+			tagRetryStatusCode: status.Code(prev.err).String(),
+		}
+		if !prev.end.IsZero() {
+			attrs[tagRetryDelay] = a.begin.Sub(prev.end).Seconds() * 1000
+		}
+		span.AddEvent("grpc.retry", tracer.WithTimestamp(a.begin), tracer.WithAttributes(attrs))
+	}
+}
@@ -14,9 +14,8 @@ import (
 
 type serverStream struct {
 	grpc.ServerStream
-	cfg    *config
-	method string
-	ctx    context.Context
+	messages *streamMessages
+	ctx      context.Context
 }
 
 // Context returns the ServerStream Context.
@@ -31,34 +30,12 @@ func (ss *serverStream) Context() context.Context {
 	return ss.ctx
 }
 
-func (ss *serverStream) RecvMsg(m interface{}) (err error) {
-	if ss.cfg.traceStreamMessages {
-		span, _ := startSpanFromContext(
-			ss.ctx,
-			ss.method,
-			"grpc.message",
-			ss.cfg.serverServiceName(),
-			ss.cfg.analyticsRate,
-		)
-		defer func() { finishWithError(span, err, ss.cfg) }()
-	}
-	err = ss.ServerStream.RecvMsg(m)
-	return err
+func (ss *serverStream) RecvMsg(m interface{}) error {
+	return ss.messages.do(ss.ctx, messageTypeRecv, m, ss.ServerStream.RecvMsg)
 }
 
-func (ss *serverStream) SendMsg(m interface{}) (err error) {
-	if ss.cfg.traceStreamMessages {
-		span, _ := startSpanFromContext(
-			ss.ctx,
-			ss.method,
-			"grpc.message",
-			ss.cfg.serverServiceName(),
-			ss.cfg.analyticsRate,
-		)
-		defer func() { finishWithError(span, err, ss.cfg) }()
-	}
-	err = ss.ServerStream.SendMsg(m)
-	return err
+func (ss *serverStream) SendMsg(m interface{}) error {
+	return ss.messages.do(ss.ctx, messageTypeSend, m, ss.ServerStream.SendMsg)
 }
 
 // StreamServerInterceptor will trace streaming requests to the given gRPC server.
@@ -97,12 +74,17 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 
 		// call the original handler with a new stream, which traces each send
 		// and recv if message tracing is enabled
+		messages := &streamMessages{
+			cfg:     cfg,
+			method:  info.FullMethod,
+			service: cfg.serviceName,
+		}
 		err = handler(srv, &serverStream{
 			ServerStream: ss,
-			cfg:          cfg,
-			method:       info.FullMethod,
+			messages:     messages,
 			ctx:          ctx,
 		})
+		messages.flush()
 
 		return err
 	}
@@ -124,6 +106,7 @@ func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
 			cfg.analyticsRate,
 		)
 		span.SetTag(tagMethodKind, methodKindUnary)
+		setRequestTag(span, req, cfg)
 		resp, err := handler(ctx, req)
 		finishWithError(span, err, cfg)
 		return resp, err
@@ -31,6 +31,10 @@ type clientStatsHandler struct{ cfg *config }
 
 // TagRPC starts a new span for the initiated RPC request.
 func (h *clientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
+	if ctx, ok := startAttempt(ctx); ok {
+		// the call is traced by the unary client interceptor
+		return ctx
+	}
 	_, ctx = startSpanFromContext(
 		ctx,
 		rti.FullMethodName,
@@ -38,12 +42,19 @@ func (h *clientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo)
 		h.cfg.clientServiceName(),
 		h.cfg.analyticsRate,
 	)
-	ctx = injectSpanIntoContext(ctx)
+	ctx = injectSpanIntoContext(ctx, h.cfg)
 	return ctx
 }
 
 // HandleRPC processes the RPC ending event by finishing the span from the context.
 func (h *clientStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	if a, ok := ctx.Value(attemptKey{}).(*attempt); ok {
+		// the call is traced by the unary client interceptor
+		if end, ok := rs.(*stats.End); ok {
+			a.finish(end.Error)
+		}
+		return
+	}
 	span, ok := tracer.SpanFromContext(ctx)
 	if !ok {
 		return
@@ -84,3 +84,57 @@ func newClientStatsHandlerTestServer(statsHandler stats.Handler) (*rig, error) {
 		client:        NewFixtureClient(conn),
 	}, nil
 }
+
+func TestClientRetryAttempts(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server := grpc.NewServer()
+	RegisterFixtureServer(server, new(fixtureServer))
+	li, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	go server.Serve(li)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(li.Addr().String(),
+		grpc.WithInsecure(),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(WithServiceName("grpc"))),
+		grpc.WithStatsHandler(NewClientStatsHandler(WithServiceName("grpc"))),
+		grpc.WithDefaultServiceConfig(`{"methodConfig": [{
+			"name": [{"service": "grpc.Fixture"}],
+			"retryPolicy": {
+				"maxAttempts": 3,
+				"initialBackoff": "0.01s",
+				"maxBackoff": "0.01s",
+				"backoffMultiplier": 1,
+				"retryableStatusCodes": ["UNAVAILABLE"]
+			}
+		}]}`),
+	)
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	defer conn.Close()
+
+	_, err = NewFixtureClient(conn).Ping(context.Background(), &FixtureRequest{Name: "unavailable"})
+	assert.NoError(err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	call := spans[0]
+	assert.Equal("grpc.client", call.OperationName())
+	assert.Equal(3, call.Tag(tagTotalAttempts))
+	assert.Equal(codes.OK.String(), call.Tag(tagCode))
+	retries := call.Events()
+	assert.Len(retries, 2)
+	for i, r := range retries {
+		assert.Equal("grpc.retry", r.Name())
+		assert.False(r.Timestamp().Before(call.StartTime()))
+		assert.Equal(i+2, r.Attributes()[tagRetryAttempt])
+		assert.Equal(codes.Unavailable.String(), r.Attributes()[tagRetryStatusCode])
+		assert.True(r.Attributes()[tagRetryDelay].(float64) >= 0)
+	}
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package grpc
+
+import (
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+
+	"github.com/golang/protobuf/proto"
+	context "golang.org/x/net/context"
+)
+
+const (
+	messageTypeSend = "send"
+	messageTypeRecv = "recv"
+)
+
+// streamMessages traces the messages sent and received on a stream. Messages
+// going in the same direction are grouped into one span per cfg.streamBatchSize
+// messages.
+type streamMessages struct {
+	cfg     *config
+	method  string
+	service string
+	// started, if set, is called with every new message span.
+	started func(ddtrace.Span)
+
+	mu   sync.Mutex // guards below fields
+	send messageBatch
+	recv messageBatch
+}
+
+// messageBatch holds the state of the messages traced in a single direction.
+type messageBatch struct {
+	span  ddtrace.Span // current span; nil when no batch is in progress
+	index int          // index of the next message
+	count int          // number of messages in the current batch
+	size  int          // total size of the messages in the current batch
+}
+
+// do calls fn with the message m, tracing it as a message of the given type.
+func (sm *streamMessages) do(ctx context.Context, typ string, m interface{}, fn func(interface{}) error) error {
+	if !sm.cfg.traceStreamMessages {
+		return fn(m)
+	}
+	b := &sm.send
+	if typ == messageTypeRecv {
+		b = &sm.recv
+	}
+	sm.mu.Lock()
+	if b.span == nil {
+		b.span, _ = startSpanFromContext(ctx, sm.method, "grpc.message", sm.service, sm.cfg.analyticsRate)
+		b.span.SetTag(tagMessageType, typ)
+		b.span.SetTag(tagMessageIndex, b.index)
+		if sm.started != nil {
+			sm.started(b.span)
+		}
+	}
+	span := b.span
+	b.index++
+	b.count++
+	sm.mu.Unlock()
+
+	err := fn(m)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if b.span != span {
+		// the batch was already finished by the end of the stream
+		return err
+	}
+	if err == nil {
+		if pm, ok := m.(proto.Message); ok {
+			b.size += proto.Size(pm)
+		}
+	}
+	if err != nil || b.count >= sm.cfg.streamBatchSize {
+		sm.finish(b, err)
+	}
+	return err
+}
+
+// finish finishes the current batch of b with the given error. sm.mu must be held.
+func (sm *streamMessages) finish(b *messageBatch, err error) {
+	b.span.SetTag(tagMessageSize, b.size)
+	if sm.cfg.streamBatchSize > 1 {
+		b.span.SetTag(tagMessageCount, b.count)
+	}
+	finishWithError(b.span, err, sm.cfg)
+	b.span, b.count, b.size = nil, 0, 0
+}
+
+// flush finishes any batch still in progress. It is called once the stream ends.
+func (sm *streamMessages) flush() {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, b := range []*messageBatch{&sm.send, &sm.recv} {
+		if b.span != nil {
+			sm.finish(b, nil)
+		}
+	}
+}
@@ -10,6 +10,17 @@ const (
 	tagMethodName = "grpc.method.name"
 	tagMethodKind = "grpc.method.kind"
 	tagCode       = "grpc.code"
+	tagRequest    = "grpc.request"
+
+	tagMessageType  = "grpc.message_type"
+	tagMessageIndex = "grpc.message_index"
+	tagMessageSize  = "grpc.message_size"
+	tagMessageCount = "grpc.message_count"
+
+	tagTotalAttempts   = "grpc.total_attempts"
+	tagRetryAttempt    = "grpc.retry_attempt"
+	tagRetryStatusCode = "grpc.retry_status_code"
+	tagRetryDelay      = "grpc.retry_delay_ms"
 )
 
 const (
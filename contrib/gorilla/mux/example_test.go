@@ -9,6 +9,8 @@ import (
 	"net/http"
 
 	muxtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/gorilla/mux"
+
+	"github.com/gorilla/mux"
 )
 
 func handler(w http.ResponseWriter, r *http.Request) {
@@ -26,3 +28,10 @@ func Example_withServiceName() {
 	mux.HandleFunc("/", handler)
 	http.ListenAndServe(":8080", mux)
 }
+
+func ExampleMiddleware() {
+	r := mux.NewRouter()
+	r.Use(muxtrace.Middleware(muxtrace.WithServiceName("mux.route")))
+	r.HandleFunc("/users/{id}", handler)
+	http.ListenAndServe(":8080", r)
+}
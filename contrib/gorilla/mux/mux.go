@@ -9,6 +9,7 @@ package mux // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/gorilla/mux"
 import (
 	"math"
 	"net/http"
+	"strings"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httputil"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
@@ -72,8 +73,7 @@ func (r *Router) UseEncodedPath() *Router {
 	return r
 }
 
-// NewRouter returns a new router instance traced with the global tracer.
-func NewRouter(opts ...RouterOption) *Router {
+func newConfig(opts []RouterOption) *routerConfig {
 	cfg := new(routerConfig)
 	defaults(cfg)
 	for _, fn := range opts {
@@ -82,9 +82,28 @@ func NewRouter(opts ...RouterOption) *Router {
 	if !math.IsNaN(cfg.analyticsRate) {
 		cfg.spanOpts = append(cfg.spanOpts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
 	}
+	return cfg
+}
+
+// NewRouter returns a new router instance traced with the global tracer.
+func NewRouter(opts ...RouterOption) *Router {
 	return &Router{
 		Router: mux.NewRouter(),
-		config: cfg,
+		config: newConfig(opts),
+	}
+}
+
+// Middleware returns middleware that traces the requests served by a mux.Router
+// that was not created using NewRouter. It is only invoked by the router for matched
+// routes, so requests that do not match any route are not traced.
+func Middleware(opts ...RouterOption) mux.MiddlewareFunc {
+	cfg := newConfig(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			route, spanopts := routeSpanOptions(cfg, mux.CurrentRoute(req), mux.Vars(req))
+			spanopts = append(spanopts, cfg.spanOpts...)
+			httputil.TraceAndServe(next, w, req, cfg.serviceName, req.Method+" "+route, spanopts...)
+		})
 	}
 }
 
@@ -94,20 +113,42 @@ func NewRouter(opts ...RouterOption) *Router {
 // all the incoming requests to the underlying multiplexer
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	var (
-		match    mux.RouteMatch
-		spanopts []ddtrace.StartSpanOption
-		route    = "unknown"
+		match   mux.RouteMatch
+		matched *mux.Route
 	)
 	// get the resource associated to this request
-	if r.Match(req, &match) && match.Route != nil {
-		if r, err := match.Route.GetPathTemplate(); err == nil {
-			route = r
-		}
-		if h, err := match.Route.GetHostTemplate(); err == nil {
-			spanopts = append(spanopts, tracer.Tag("mux.host", h))
-		}
+	if r.Match(req, &match) {
+		matched = match.Route
 	}
+	route, spanopts := routeSpanOptions(r.config, matched, match.Vars)
 	spanopts = append(spanopts, r.config.spanOpts...)
 	resource := req.Method + " " + route
 	httputil.TraceAndServe(r.Router, w, req, r.config.serviceName, resource, spanopts...)
 }
+
+// routeSpanOptions returns the path template of the given matched route along with
+// the span options tagging its host template, query templates and route variables.
+// The path template is "unknown" if the route is nil or has none.
+func routeSpanOptions(cfg *routerConfig, route *mux.Route, vars map[string]string) (string, []ddtrace.StartSpanOption) {
+	if route == nil {
+		return "unknown", nil
+	}
+	var spanopts []ddtrace.StartSpanOption
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		path = "unknown"
+	}
+	if h, err := route.GetHostTemplate(); err == nil {
+		spanopts = append(spanopts, tracer.Tag("mux.host", h))
+	}
+	if q, err := route.GetQueriesTemplates(); err == nil && len(q) > 0 {
+		spanopts = append(spanopts, tracer.Tag("mux.queries", strings.Join(q, "&")))
+	}
+	for k, v := range vars {
+		if cfg.scrubRouteVars {
+			v = "?"
+		}
+		spanopts = append(spanopts, tracer.Tag("mux.var."+k, v))
+	}
+	return path, spanopts
+}
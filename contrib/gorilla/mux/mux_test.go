@@ -17,6 +17,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -114,6 +115,95 @@ func TestSpanOptions(t *testing.T) {
 	assert.Equal(2, spans[0].Tag(ext.SamplingPriority))
 }
 
+func TestRouteVars(t *testing.T) {
+	for name, tt := range map[string]struct {
+		opts []RouterOption
+		id   string
+		name string
+	}{
+		"default": {id: "123", name: "lucy"},
+		"scrub":   {opts: []RouterOption{WithScrubRouteVars(true)}, id: "?", name: "?"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			router := NewRouter(tt.opts...)
+			router.Handle("/users/{id:[0-9]+}/{name}", okHandler()).Queries("page", "{page}")
+			r := httptest.NewRequest("GET", "/users/123/lucy?page=2", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			spans := mt.FinishedSpans()
+			assert.Equal(1, len(spans))
+			s := spans[0]
+			assert.Equal("GET /users/{id:[0-9]+}/{name}", s.Tag(ext.ResourceName))
+			assert.Equal("page={page}", s.Tag("mux.queries"))
+			assert.Equal(tt.id, s.Tag("mux.var.id"))
+			assert.Equal(tt.name, s.Tag("mux.var.name"))
+			assert.Nil(s.Tag("mux.host"))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Middleware(WithServiceName("my-service"), WithScrubRouteVars(true)))
+	router.Handle("/users/{id}", okHandler()).Host("{subdomain}.example.com")
+	router.Handle("/500", errorHandler(http.StatusInternalServerError))
+
+	t.Run("vars", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		r := httptest.NewRequest("GET", "http://api.example.com/users/123", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(200, w.Code)
+
+		spans := mt.FinishedSpans()
+		assert.Equal(1, len(spans))
+		s := spans[0]
+		assert.Equal("http.request", s.OperationName())
+		assert.Equal("my-service", s.Tag(ext.ServiceName))
+		assert.Equal("GET /users/{id}", s.Tag(ext.ResourceName))
+		assert.Equal("/users/123", s.Tag(ext.HTTPURL))
+		assert.Equal("200", s.Tag(ext.HTTPCode))
+		assert.Equal("{subdomain}.example.com", s.Tag("mux.host"))
+		assert.Equal("?", s.Tag("mux.var.id"))
+		assert.Equal("?", s.Tag("mux.var.subdomain"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		r := httptest.NewRequest("GET", "/500", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		spans := mt.FinishedSpans()
+		assert.Equal(1, len(spans))
+		s := spans[0]
+		assert.Equal("GET /500", s.Tag(ext.ResourceName))
+		assert.Equal("500", s.Tag(ext.HTTPCode))
+		assert.Equal("500: Internal Server Error", s.Tag(ext.Error).(error).Error())
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		r := httptest.NewRequest("GET", "/not_a_real_route", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(404, w.Code)
+
+		// the middleware is only invoked for matched routes
+		assert.Len(mt.FinishedSpans(), 0)
+	})
+}
+
 // TestImplementingMethods is a regression tests asserting that all the mux.Router methods
 // returning the router will return the modified traced version of it and not the original
 // router.
@@ -13,12 +13,13 @@ import (
 )
 
 type routerConfig struct {
-	serviceName   string
-	spanOpts      []ddtrace.StartSpanOption // additional span options to be applied
-	analyticsRate float64
+	serviceName    string
+	spanOpts       []ddtrace.StartSpanOption // additional span options to be applied
+	analyticsRate  float64
+	scrubRouteVars bool
 }
 
-// RouterOption represents an option that can be passed to NewRouter.
+// RouterOption represents an option that can be passed to NewRouter or Middleware.
 type RouterOption func(*routerConfig)
 
 func defaults(cfg *routerConfig) {
@@ -41,6 +42,15 @@ func WithSpanOptions(opts ...ddtrace.StartSpanOption) RouterOption {
 	}
 }
 
+// WithScrubRouteVars specifies whether the values of the route variables, which are
+// tagged as "mux.var.<name>", are replaced with "?". It is useful when the variables
+// contain sensitive information, such as e-mail addresses or tokens.
+func WithScrubRouteVars(scrub bool) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.scrubRouteVars = scrub
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) RouterOption {
 	return func(cfg *routerConfig) {
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package gorm provides helper functions for tracing the gorm.io/gorm package (https://github.com/go-gorm/gorm).
+package gorm
+
+import (
+	"math"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"gorm.io/gorm"
+)
+
+const (
+	pluginName           = "dd-trace-gorm"
+	gormSpanStartTimeKey = "dd-trace-go:span"
+)
+
+// Open opens a new (traced) database connection using the given dialector. In order for
+// the underlying SQL queries to be traced too, the dialector should be given a connection
+// opened using (gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql).Open.
+func Open(dialector gorm.Dialector, cfg *gorm.Config, opts ...Option) (*gorm.DB, error) {
+	if cfg == nil {
+		cfg = &gorm.Config{}
+	}
+	db, err := gorm.Open(dialector, cfg)
+	if err != nil {
+		return db, err
+	}
+	return db, db.Use(NewTracePlugin(opts...))
+}
+
+type tracePlugin struct {
+	cfg *config
+}
+
+// NewTracePlugin returns a gorm.Plugin which traces the Create, Query, Update, Delete,
+// Row and Raw operations of the *gorm.DB it is used with. It should be passed to the
+// (*gorm.DB).Use method once, after opening the db. The context set using
+// (*gorm.DB).WithContext is used as a basis for creating new spans.
+func NewTracePlugin(opts ...Option) gorm.Plugin {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return &tracePlugin{cfg: cfg}
+}
+
+// Name implements gorm.Plugin.
+func (p *tracePlugin) Name() string {
+	return pluginName
+}
+
+// Initialize implements gorm.Plugin. It registers the tracing callbacks on db.
+func (p *tracePlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("dd-trace-go:before_create", before); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("dd-trace-go:after_create", p.after("gorm.create")); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("dd-trace-go:before_query", before); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("dd-trace-go:after_query", p.after("gorm.query")); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("dd-trace-go:before_update", before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("dd-trace-go:after_update", p.after("gorm.update")); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("dd-trace-go:before_delete", before); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("dd-trace-go:after_delete", p.after("gorm.delete")); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("dd-trace-go:before_row", before); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("dd-trace-go:after_row", p.after("gorm.row")); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("dd-trace-go:before_raw", before); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("dd-trace-go:after_raw", p.after("gorm.raw"))
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(gormSpanStartTimeKey, time.Now())
+}
+
+func (p *tracePlugin) after(operationName string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormSpanStartTimeKey)
+		if !ok {
+			return
+		}
+		t, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		query := db.Statement.SQL.String()
+		resource := db.Statement.Table
+		if resource == "" {
+			// raw SQL, such as passed to Raw or Exec
+			resource = query
+		}
+		opts := []ddtrace.StartSpanOption{
+			tracer.StartTime(t),
+			tracer.ServiceName(p.cfg.serviceName),
+			tracer.SpanType(ext.SpanTypeSQL),
+			tracer.ResourceName(resource),
+			tracer.Tag(ext.DBStatement, query),
+		}
+		if !math.IsNaN(p.cfg.analyticsRate) {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, p.cfg.analyticsRate))
+		}
+
+		span, _ := tracer.StartSpanFromContext(db.Statement.Context, operationName, opts...)
+		var err error
+		if db.Error != nil && p.cfg.errCheck(db.Error) {
+			err = db.Error
+		}
+		span.Finish(tracer.WithError(err))
+	}
+}
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	sqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type Product struct {
+	gorm.Model
+	Code  string
+	Price uint
+}
+
+func openTestDB(t *testing.T, opts ...Option) *gorm.DB {
+	sqltrace.Register("sqlite3", &sqlite3.SQLiteDriver{})
+	sqldb, err := sqltrace.Open("sqlite3", "file::memory:?cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqldb.Close() })
+	db, err := Open(sqlite.Dialector{Conn: sqldb}, &gorm.Config{}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// gormSpans returns the finished spans started by the plugin.
+func gormSpans(mt mocktracer.Tracer) []mocktracer.Span {
+	var spans []mocktracer.Span
+	for _, s := range mt.FinishedSpans() {
+		if s.Tag(ext.ServiceName) != "sqlite3.db" {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func TestCallbacks(t *testing.T) {
+	assert := assert.New(t)
+	db := openTestDB(t)
+
+	for _, tt := range []struct {
+		name string
+		op   func(db *gorm.DB)
+	}{
+		{"gorm.create", func(db *gorm.DB) { db.Create(&Product{Code: "L1212", Price: 1000}) }},
+		{"gorm.query", func(db *gorm.DB) { db.First(&Product{}, "code = ?", "L1212") }},
+		{"gorm.update", func(db *gorm.DB) { db.Model(&Product{}).Where("code = ?", "L1212").Update("price", 2000) }},
+		{"gorm.delete", func(db *gorm.DB) { db.Where("code = ?", "L1212").Delete(&Product{}) }},
+		{"gorm.row", func(db *gorm.DB) { db.Model(&Product{}).Select("code").Row() }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			parent, ctx := tracer.StartSpanFromContext(context.Background(), "http.request")
+			tt.op(db.WithContext(ctx))
+			parent.Finish()
+
+			spans := gormSpans(mt)
+			assert.Len(spans, 2)
+			span := spans[0]
+			assert.Equal(tt.name, span.OperationName())
+			assert.Equal("gorm.db", span.Tag(ext.ServiceName))
+			assert.Equal(ext.SpanTypeSQL, span.Tag(ext.SpanType))
+			assert.Equal("products", span.Tag(ext.ResourceName))
+			assert.NotEmpty(span.Tag(ext.DBStatement))
+			assert.Equal(parent.Context().SpanID(), span.ParentID())
+		})
+	}
+}
+
+func TestRawQueries(t *testing.T) {
+	db := openTestDB(t)
+
+	for _, tt := range []struct {
+		name, query string
+		op          func(db *gorm.DB, query string)
+	}{
+		{"gorm.raw", "UPDATE products SET price = 3000", func(db *gorm.DB, query string) { db.Exec(query) }},
+		{"gorm.row", "SELECT code FROM products", func(db *gorm.DB, query string) {
+			var codes []string
+			db.Raw(query).Scan(&codes)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			tt.op(db, tt.query)
+
+			spans := gormSpans(mt)
+			assert.Len(spans, 1)
+			assert.Equal(tt.name, spans[0].OperationName())
+			assert.Equal(tt.query, spans[0].Tag(ext.ResourceName))
+			assert.Equal(tt.query, spans[0].Tag(ext.DBStatement))
+		})
+	}
+}
+
+func TestErrorCheck(t *testing.T) {
+	find := func(t *testing.T, opts ...Option) mocktracer.Span {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		db := openTestDB(t, opts...)
+		mt.Reset()
+		err := db.First(&Product{}, "code = ?", "missing").Error
+		assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+		spans := gormSpans(mt)
+		assert.Len(t, spans, 1)
+		return spans[0]
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		assert.Nil(t, find(t).Tag(ext.Error))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		span := find(t, WithErrorCheck(func(err error) bool { return true }))
+		assert.Equal(t, gorm.ErrRecordNotFound, span.Tag(ext.Error))
+	})
+}
+
+func TestPluginName(t *testing.T) {
+	assert.Equal(t, "dd-trace-gorm", NewTracePlugin().Name())
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, rate interface{}, opts ...Option) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		db := openTestDB(t, opts...)
+		mt.Reset()
+		db.Create(&Product{Code: "L1212", Price: 1000})
+
+		spans := gormSpans(mt)
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		assertRate(t, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		assertRate(t, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assertRate(t, nil, WithAnalytics(false))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		assertRate(t, 0.23, WithAnalyticsRate(0.23))
+	})
+}
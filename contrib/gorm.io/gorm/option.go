@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package gorm
+
+import (
+	"errors"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+type config struct {
+	serviceName   string
+	analyticsRate float64
+	errCheck      func(err error) bool
+}
+
+// Option represents an option that can be passed to Open or NewTracePlugin.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = "gorm.db"
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.analyticsRate = math.NaN()
+	cfg.errCheck = func(err error) bool {
+		return !errors.Is(err, gorm.ErrRecordNotFound)
+	}
+}
+
+// WithServiceName sets the given service name for the started spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithErrorCheck specifies a function fn which determines whether the passed
+// error should be marked as an error on the span. By default, all errors are
+// marked except gorm.ErrRecordNotFound, which reports that a query found no rows.
+func WithErrorCheck(fn func(err error) bool) Option {
+	return func(cfg *config) {
+		cfg.errCheck = fn
+	}
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package elasticutil holds the code shared by the transports tracing Elasticsearch clients.
+package elasticutil // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/elasticutil"
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+// Peek attempts to return the first n bytes, as a string, from the provided io.ReadCloser.
+// It returns a new io.ReadCloser which points to the same underlying stream and can be read
+// from to access the entire data including the snippet. max is used to specify the length
+// of the stream contained in the reader. If unknown, it should be -1. If 0 < max < n it
+// will override n. If encoding is "gzip", the returned snippet is unpacked.
+func Peek(rc io.ReadCloser, encoding string, max, n int) (string, io.ReadCloser, error) {
+	if rc == nil {
+		return "", rc, errors.New("empty stream")
+	}
+	if max > 0 && max < n {
+		n = max
+	}
+	r := bufio.NewReaderSize(rc, n)
+	rc2 := struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: r,
+		Closer: rc,
+	}
+	snip, err := r.Peek(n)
+	if err == io.EOF {
+		err = nil
+	}
+	if err != nil {
+		return string(snip), rc2, err
+	}
+	if encoding == "gzip" {
+		// unpack the snippet
+		gzr, err := gzip.NewReader(bytes.NewReader(snip))
+		if err != nil {
+			// snip wasn't gzip; return it as is
+			return string(snip), rc2, nil
+		}
+		defer gzr.Close()
+		snip, err = ioutil.ReadAll(gzr)
+	}
+	return string(snip), rc2, err
+}
+
+// TagResponse tags span with the outcome of a round trip which returned res and err.
+// Responses with a non-2xx status mark the span as errored, using at most the first
+// cutoff bytes of their body as the error message. The body of res is replaced with
+// one which still yields the entire response.
+func TagResponse(span ddtrace.Span, res *http.Response, err error, cutoff int) {
+	if err != nil {
+		// roundtrip error
+		span.SetTag(ext.Error, err)
+	} else if res.StatusCode < 200 || res.StatusCode > 299 {
+		// HTTP error
+		snip, rc, err := Peek(res.Body, res.Header.Get("Content-Encoding"), int(res.ContentLength), cutoff)
+		if err != nil || snip == "" {
+			snip = http.StatusText(res.StatusCode)
+		}
+		span.SetTag(ext.Error, errors.New(snip))
+		res.Body = rc
+	}
+	if res != nil {
+		span.SetTag(ext.HTTPCode, strconv.Itoa(res.StatusCode))
+	}
+}
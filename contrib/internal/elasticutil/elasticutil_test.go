@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package elasticutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestPeek(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, tt := range [...]struct {
+		max  int    // content length
+		txt  string // stream
+		n    int    // bytes to peek at
+		snip string // expected snippet
+		err  error  // expected error
+	}{
+		0: {
+			// extract 3 bytes from a content of length 7
+			txt:  "ABCDEFG",
+			max:  7,
+			n:    3,
+			snip: "ABC",
+		},
+		1: {
+			// extract 7 bytes from a content of length 7
+			txt:  "ABCDEFG",
+			max:  7,
+			n:    7,
+			snip: "ABCDEFG",
+		},
+		2: {
+			// extract 100 bytes from a content of length 9 (impossible scenario)
+			txt:  "ABCDEFG",
+			max:  9,
+			n:    100,
+			snip: "ABCDEFG",
+		},
+		3: {
+			// extract 5 bytes from a content of length 2 (impossible scenario)
+			txt:  "ABCDEFG",
+			max:  2,
+			n:    5,
+			snip: "AB",
+		},
+		4: {
+			txt:  "ABCDEFG",
+			max:  0,
+			n:    1,
+			snip: "A",
+		},
+		5: {
+			n:   4,
+			max: 4,
+			err: errors.New("empty stream"),
+		},
+		6: {
+			txt:  "ABCDEFG",
+			n:    4,
+			max:  -1,
+			snip: "ABCD",
+		},
+	} {
+		var readcloser io.ReadCloser
+		if tt.txt != "" {
+			readcloser = ioutil.NopCloser(bytes.NewBufferString(tt.txt))
+		}
+		snip, rc, err := Peek(readcloser, "", tt.max, tt.n)
+		assert.Equal(tt.err, err)
+		assert.Equal(tt.snip, snip)
+
+		if readcloser != nil {
+			// if a non-nil io.ReadCloser was sent, the returned io.ReadCloser
+			// must always return the entire original content.
+			all, err := ioutil.ReadAll(rc)
+			assert.Nil(err)
+			assert.Equal(tt.txt, string(all))
+		}
+	}
+}
+
+func TestPeekGzip(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(`{"error":"not found"}`))
+	w.Close()
+	data := buf.Bytes()
+
+	snip, rc, err := Peek(ioutil.NopCloser(bytes.NewReader(data)), "gzip", len(data), 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"error":"not found"}`, snip)
+	all, err := ioutil.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, data, all)
+}
+
+func TestTagResponse(t *testing.T) {
+	newResponse := func(code int, body string) *http.Response {
+		return &http.Response{
+			StatusCode:    code,
+			Header:        http.Header{},
+			Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+			ContentLength: int64(len(body)),
+		}
+	}
+	tagResponse := func(res *http.Response, err error, cutoff int) mocktracer.Span {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		span := tracer.StartSpan("elasticsearch.query")
+		TagResponse(span, res, err, cutoff)
+		span.Finish()
+		return mt.FinishedSpans()[0]
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		span := tagResponse(newResponse(200, `{"found":true}`), nil, 10)
+		assert.Equal(t, "200", span.Tag(ext.HTTPCode))
+		assert.Nil(t, span.Tag(ext.Error))
+	})
+
+	t.Run("status", func(t *testing.T) {
+		res := newResponse(404, `{"error":"not found"}`)
+		span := tagResponse(res, nil, 10)
+		assert.Equal(t, "404", span.Tag(ext.HTTPCode))
+		assert.Equal(t, `{"error":"`, span.Tag(ext.Error).(error).Error())
+		all, err := ioutil.ReadAll(res.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"error":"not found"}`, string(all))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		span := tagResponse(newResponse(503, ""), nil, 10)
+		assert.Equal(t, "503", span.Tag(ext.HTTPCode))
+		assert.Equal(t, http.StatusText(503), span.Tag(ext.Error).(error).Error())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := errors.New("connection refused")
+		span := tagResponse(nil, err, 10)
+		assert.Nil(t, span.Tag(ext.HTTPCode))
+		assert.Equal(t, err, span.Tag(ext.Error))
+	})
+}
@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package pgx provides functions to trace the jackc/pgx package v5 (https://github.com/jackc/pgx).
+package pgx // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/jackc/pgx.v5"
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Tracer traces the queries, batches and copies of pgx connections. It implements
+// pgx.QueryTracer, pgx.BatchTracer and pgx.CopyFromTracer.
+type Tracer struct {
+	cfg *config
+}
+
+var (
+	_ pgx.QueryTracer    = (*Tracer)(nil)
+	_ pgx.BatchTracer    = (*Tracer)(nil)
+	_ pgx.CopyFromTracer = (*Tracer)(nil)
+)
+
+// NewTracer returns a new Tracer, which should be set as the Tracer of a pgx.ConnConfig.
+func NewTracer(opts ...Option) *Tracer {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return &Tracer{cfg: cfg}
+}
+
+// RegisterConnConfig sets a new Tracer as the tracer of the given connection config,
+// so that all connections created from it are traced.
+func RegisterConnConfig(cfg *pgx.ConnConfig, opts ...Option) {
+	cfg.Tracer = NewTracer(opts...)
+}
+
+type spanKey struct{}
+
+// batchState holds the span of a batch, along with the time at which the last
+// query of the batch completed.
+type batchState struct {
+	span ddtrace.Span
+	last time.Time
+}
+
+type batchKey struct{}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	span, ctx := t.startSpan(ctx, conn, "pgx.query", data.SQL, time.Now())
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	finishSpan(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+// TraceBatchStart implements pgx.BatchTracer. It starts the pgx.batch span, which is the
+// parent of the spans of the queries in the batch.
+func (t *Tracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	start := time.Now()
+	span, ctx := t.startSpan(ctx, conn, "pgx.batch", "pgx.batch", start)
+	if data.Batch != nil {
+		span.SetTag("db.batch_size", data.Batch.Len())
+	}
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	return context.WithValue(ctx, batchKey{}, &batchState{span: span, last: start})
+}
+
+// TraceBatchQuery implements pgx.BatchTracer. It is called once each query of the batch
+// completes; the query span is considered to start when the previous one completed.
+func (t *Tracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	state, ok := ctx.Value(batchKey{}).(*batchState)
+	if !ok {
+		return
+	}
+	span, _ := t.startSpan(ctx, conn, "pgx.batch.query", data.SQL, state.last)
+	state.last = time.Now()
+	if data.Err == nil {
+		span.SetTag("db.rows_affected", data.CommandTag.RowsAffected())
+	}
+	span.Finish(tracer.FinishTime(state.last), tracer.WithError(data.Err))
+}
+
+// TraceBatchEnd implements pgx.BatchTracer.
+func (t *Tracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	if span, ok := ctx.Value(spanKey{}).(ddtrace.Span); ok {
+		span.Finish(tracer.WithError(data.Err))
+	}
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (t *Tracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	span, ctx := t.startSpan(ctx, conn, "pgx.copy_from", data.TableName.Sanitize(), time.Now())
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (t *Tracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	finishSpan(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+// startSpan starts a span with the given operation and resource names, tagged with the
+// connection's configuration when conn is not nil.
+func (t *Tracer) startSpan(ctx context.Context, conn *pgx.Conn, operationName, resource string, start time.Time) (ddtrace.Span, context.Context) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.StartTime(start),
+		tracer.ServiceName(t.cfg.serviceName),
+		tracer.SpanType(ext.SpanTypeSQL),
+		tracer.ResourceName(resource),
+	}
+	if !math.IsNaN(t.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, t.cfg.analyticsRate))
+	}
+	if conn != nil {
+		if cfg := conn.Config(); cfg != nil {
+			opts = append(opts,
+				tracer.Tag(ext.TargetHost, cfg.Host),
+				tracer.Tag(ext.TargetPort, strconv.FormatUint(uint64(cfg.Port), 10)),
+				tracer.Tag(ext.DBUser, cfg.User),
+				tracer.Tag(ext.DBName, cfg.Database),
+			)
+		}
+	}
+	return tracer.StartSpanFromContext(ctx, operationName, opts...)
+}
+
+// finishSpan finishes the span started by the matching Trace*Start hook.
+func finishSpan(ctx context.Context, rowsAffected int64, err error) {
+	span, ok := ctx.Value(spanKey{}).(ddtrace.Span)
+	if !ok {
+		return
+	}
+	if err == nil {
+		span.SetTag("db.rows_affected", rowsAffected)
+	}
+	span.Finish(tracer.WithError(err))
+}
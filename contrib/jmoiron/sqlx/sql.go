@@ -20,8 +20,12 @@ import (
 
 // Open opens a new (traced) connection to the database using the given driver and source.
 // Note that the driver must formerly be registered using database/sql integration's Register.
-func Open(driverName, dataSourceName string) (*sqlx.DB, error) {
-	db, err := sqltraced.Open(driverName, dataSourceName)
+// The given options override the ones the driver was registered with.
+//
+// Named queries are bound by sqlx before reaching the driver, so their spans use the
+// resulting SQL, in which the named parameters are replaced by the driver's bind variables.
+func Open(driverName, dataSourceName string, opts ...sqltraced.Option) (*sqlx.DB, error) {
+	db, err := sqltraced.Open(driverName, dataSourceName, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -31,8 +35,8 @@ func Open(driverName, dataSourceName string) (*sqlx.DB, error) {
 // MustOpen is the same as Open, but panics on error.
 // To get tracing, the driver must be formerly registered using the database/sql integration's
 // Register.
-func MustOpen(driverName, dataSourceName string) (*sqlx.DB, error) {
-	db, err := sqltraced.Open(driverName, dataSourceName)
+func MustOpen(driverName, dataSourceName string, opts ...sqltraced.Option) (*sqlx.DB, error) {
+	db, err := sqltraced.Open(driverName, dataSourceName, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -42,8 +46,8 @@ func MustOpen(driverName, dataSourceName string) (*sqlx.DB, error) {
 // Connect connects to the data source using the given driver.
 // To get tracing, the driver must be formerly registered using the database/sql integration's
 // Register.
-func Connect(driverName, dataSourceName string) (*sqlx.DB, error) {
-	db, err := Open(driverName, dataSourceName)
+func Connect(driverName, dataSourceName string, opts ...sqltraced.Option) (*sqlx.DB, error) {
+	db, err := Open(driverName, dataSourceName, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +62,8 @@ func Connect(driverName, dataSourceName string) (*sqlx.DB, error) {
 // MustConnect connects to a database and panics on error.
 // To get tracing, the driver must be formerly registered using the database/sql integration's
 // Register.
-func MustConnect(driverName, dataSourceName string) *sqlx.DB {
-	db, err := Connect(driverName, dataSourceName)
+func MustConnect(driverName, dataSourceName string, opts ...sqltraced.Option) *sqlx.DB {
+	db, err := Connect(driverName, dataSourceName, opts...)
 	if err != nil {
 		panic(err)
 	}
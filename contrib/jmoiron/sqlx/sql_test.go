@@ -6,6 +6,7 @@
 package sqlx
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -14,9 +15,13 @@ import (
 	sqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql"
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/sqltest"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
 )
 
 // tableName holds the SQL table that these tests will be run against. It must be unique cross-repo.
@@ -81,3 +86,50 @@ func TestPostgres(t *testing.T) {
 	}
 	sqltest.RunAll(t, testConfig)
 }
+
+func TestSQLiteNamed(t *testing.T) {
+	assert := assert.New(t)
+	sqltrace.Register("sqlite3", &sqlite3.SQLiteDriver{})
+	dbx, err := Open("sqlite3", ":memory:", sqltrace.WithSQLObfuscation(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbx.Close()
+	// a single connection keeps the in-memory database alive between queries
+	dbx.SetMaxOpenConns(1)
+	_, err = dbx.Exec("CREATE TABLE city (name TEXT, population INTEGER)")
+	assert.NoError(err)
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	type city struct {
+		Name       string `db:"name"`
+		Population int    `db:"population"`
+	}
+	_, err = dbx.NamedExecContext(ctx, "INSERT INTO city (name, population) VALUES (:name, :population)",
+		city{Name: "Paris", Population: 2161000})
+	assert.NoError(err)
+	var cities []city
+	assert.NoError(dbx.SelectContext(ctx, &cities, "SELECT * FROM city WHERE population > 1000000"))
+	assert.Len(cities, 1)
+	var c city
+	assert.NoError(dbx.GetContext(ctx, &c, "SELECT * FROM city WHERE name = ?", "Paris"))
+	assert.Equal("Paris", c.Name)
+	parent.Finish()
+
+	var resources []string
+	for _, s := range mt.FinishedSpans() {
+		if s.OperationName() != "sqlite3.query" || s.Tag(ext.ResourceName) == "Connect" {
+			continue
+		}
+		assert.Equal(parent.Context().SpanID(), s.ParentID())
+		resources = append(resources, s.Tag(ext.ResourceName).(string))
+	}
+	assert.Equal([]string{
+		"INSERT INTO city (name, population) VALUES (?, ?)",
+		"SELECT * FROM city WHERE population > ?",
+		"SELECT * FROM city WHERE name = ?",
+	}, resources)
+}
@@ -35,3 +35,22 @@ func Example() {
 
 	fmt.Println(pods.Items)
 }
+
+func ExampleNewInstrumentedConfig() {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		panic(err.Error())
+	}
+	// Use the returned config to trace all calls made to the Kubernetes API
+	client, err := kubernetes.NewForConfig(kubernetestrace.NewInstrumentedConfig(cfg))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	deployment, err := client.AppsV1().Deployments("default").Get("web", meta_v1.GetOptions{})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(deployment.Status)
+}
@@ -15,10 +15,13 @@ import (
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+
+	"k8s.io/client-go/rest"
 )
 
 const (
 	prefixAPI   = "/api/v1/"
+	prefixAPIs  = "/apis/"
 	prefixWatch = "watch/"
 )
 
@@ -37,10 +40,29 @@ func WrapRoundTripper(rt http.RoundTripper) http.RoundTripper {
 	return wrapRoundTripperWithOptions(rt)
 }
 
+// NewInstrumentedConfig returns a copy of cfg whose transport traces all requests,
+// using the given set of RoundTripperOption. Any WrapTransport function already set
+// on cfg is kept and applied before the tracing one.
+func NewInstrumentedConfig(cfg *rest.Config, opts ...httptrace.RoundTripperOption) *rest.Config {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Wrap(WrapRoundTripperFunc(opts...))
+	return cfg
+}
+
 func wrapRoundTripperWithOptions(rt http.RoundTripper, opts ...httptrace.RoundTripperOption) http.RoundTripper {
 	opts = append(opts, httptrace.WithBefore(func(req *http.Request, span ddtrace.Span) {
 		span.SetTag(ext.ServiceName, "kubernetes")
 		span.SetTag(ext.ResourceName, RequestToResource(req.Method, req.URL.Path))
+		namespace, resource, name := requestToObject(req.URL.Path)
+		if namespace != "" {
+			span.SetTag("kubernetes.namespace", namespace)
+		}
+		if resource != "" {
+			span.SetTag("kubernetes.resource", resource)
+		}
+		if name != "" {
+			span.SetTag("kubernetes.name", name)
+		}
 		traceID := span.Context().TraceID()
 		if traceID == 0 {
 			// tracer is not running
@@ -53,9 +75,40 @@ func wrapRoundTripperWithOptions(rt http.RoundTripper, opts ...httptrace.RoundTr
 	return httptrace.WrapRoundTripper(rt, opts...)
 }
 
+// splitPath splits the path of a request to the Kubernetes API into the group and
+// version of the API, formatted as "{group}/{version}" and empty for the core API,
+// and the path relative to the API, stripped of any "watch/" prefix. It reports
+// whether path is a path of the Kubernetes API.
+func splitPath(path string) (groupVersion, rel string, watch, ok bool) {
+	switch {
+	case strings.HasPrefix(path, prefixAPI):
+		rel = strings.TrimPrefix(path, prefixAPI)
+	case strings.HasPrefix(path, prefixAPIs):
+		parts := strings.SplitN(strings.TrimPrefix(path, prefixAPIs), "/", 3)
+		if len(parts) < 2 {
+			return "", "", false, false
+		}
+		groupVersion = parts[0] + "/" + parts[1]
+		if len(parts) == 3 {
+			rel = parts[2]
+		}
+	default:
+		return "", "", false, false
+	}
+	if strings.HasPrefix(rel, prefixWatch) {
+		// strip out /watch
+		rel = strings.TrimPrefix(rel, prefixWatch)
+		watch = true
+	}
+	return groupVersion, rel, watch, true
+}
+
 // RequestToResource parses a Kubernetes request and extracts a resource name from it.
+// Requests to API groups other than the core one are prefixed with the group and version,
+// such as "GET apps/v1/namespaces/{namespace}/deployments/{name}".
 func RequestToResource(method, path string) string {
-	if !strings.HasPrefix(path, prefixAPI) {
+	groupVersion, path, watch, ok := splitPath(path)
+	if !ok {
 		return method
 	}
 
@@ -63,11 +116,14 @@ func RequestToResource(method, path string) string {
 	out.WriteString(method)
 	out.WriteByte(' ')
 
-	path = strings.TrimPrefix(path, prefixAPI)
-
-	if strings.HasPrefix(path, prefixWatch) {
-		// strip out /watch
-		path = strings.TrimPrefix(path, prefixWatch)
+	if groupVersion != "" {
+		out.WriteString(groupVersion)
+		if path == "" {
+			return out.String()
+		}
+		out.WriteByte('/')
+	}
+	if watch {
 		out.WriteString(prefixWatch)
 	}
 
@@ -88,6 +144,27 @@ func RequestToResource(method, path string) string {
 	return out.String()
 }
 
+// requestToObject parses a Kubernetes request and extracts from it the namespace,
+// type and name of the object it targets. Any of them is empty when not part of
+// the request, such as the name of list requests.
+func requestToObject(path string) (namespace, resource, name string) {
+	_, path, _, ok := splitPath(path)
+	if !ok || path == "" {
+		return "", "", ""
+	}
+	parts := strings.Split(path, "/")
+	if parts[0] == "namespaces" && len(parts) > 2 {
+		// namespaces/{namespace}/{type}...
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+	resource = parts[0]
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+	return namespace, resource, name
+}
+
 func typeToPlaceholder(typ string) string {
 	switch typ {
 	case "namespaces":
@@ -44,6 +44,87 @@ func TestPathToResource(t *testing.T) {
 	}
 }
 
+func TestGroupPathToResource(t *testing.T) {
+	expected := map[string]string{
+		"/apis/apps/v1":                                        "apps/v1",
+		"/apis/apps/v1/deployments":                            "apps/v1/deployments",
+		"/apis/apps/v1/namespaces/default/deployments":         "apps/v1/namespaces/{namespace}/deployments",
+		"/apis/apps/v1/namespaces/default/deployments/web":     "apps/v1/namespaces/{namespace}/deployments/{name}",
+		"/apis/batch/v1/namespaces/default/jobs/job-1/status":  "batch/v1/namespaces/{namespace}/jobs/{name}/status",
+		"/apis/apps/v1/watch/namespaces/default/deployments":   "apps/v1/watch/namespaces/{namespace}/deployments",
+		"/apis/rbac.authorization.k8s.io/v1/clusterroles/view": "rbac.authorization.k8s.io/v1/clusterroles/{name}",
+	}
+
+	for path, expectedResource := range expected {
+		assert.Equal(t, "GET "+expectedResource, RequestToResource("GET", path), "mapping %v", path)
+	}
+	assert.Equal(t, "GET", RequestToResource("GET", "/apis"))
+	assert.Equal(t, "GET", RequestToResource("GET", "/version"))
+}
+
+func TestRequestToObject(t *testing.T) {
+	for path, expected := range map[string][3]string{
+		"/api/v1/namespaces":                                  {"", "namespaces", ""},
+		"/api/v1/namespaces/default":                          {"", "namespaces", "default"},
+		"/api/v1/namespaces/default/configmaps":               {"default", "configmaps", ""},
+		"/api/v1/namespaces/default/configmaps/some-config":   {"default", "configmaps", "some-config"},
+		"/api/v1/watch/namespaces/kube-system/pods/pod-1":     {"kube-system", "pods", "pod-1"},
+		"/api/v1/nodes/node-1":                                {"", "nodes", "node-1"},
+		"/apis/apps/v1/namespaces/default/deployments/web":    {"default", "deployments", "web"},
+		"/apis/batch/v1/namespaces/default/jobs/job-1/status": {"default", "jobs", "job-1"},
+		"/apis/apps/v1":                                       {"", "", ""},
+		"/version":                                            {"", "", ""},
+	} {
+		namespace, resource, name := requestToObject(path)
+		assert.Equal(t, expected, [3]string{namespace, resource, name}, "mapping %v", path)
+	}
+}
+
+func TestNewInstrumentedConfig(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello World"))
+	}))
+	defer s.Close()
+
+	cfg, err := clientcmd.BuildConfigFromKubeconfigGetter(s.URL, func() (*clientcmdapi.Config, error) {
+		return clientcmdapi.NewConfig(), nil
+	})
+	assert.NoError(t, err)
+	var wrapped bool
+	cfg.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		wrapped = true
+		return rt
+	}
+
+	client, err := kubernetes.NewForConfig(NewInstrumentedConfig(cfg, httptrace.RTWithAnalytics(true)))
+	assert.NoError(t, err)
+	assert.True(t, wrapped)
+
+	client.AppsV1().Deployments("default").Get("web", meta_v1.GetOptions{})
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	{
+		s := spans[0]
+		assert.Equal(t, "http.request", s.OperationName())
+		assert.Equal(t, "kubernetes", s.Tag(ext.ServiceName))
+		assert.Equal(t, "GET apps/v1/namespaces/{namespace}/deployments/{name}", s.Tag(ext.ResourceName))
+		assert.Equal(t, "200", s.Tag(ext.HTTPCode))
+		assert.Equal(t, "GET", s.Tag(ext.HTTPMethod))
+		assert.Equal(t, "default", s.Tag("kubernetes.namespace"))
+		assert.Equal(t, "deployments", s.Tag("kubernetes.resource"))
+		assert.Equal(t, "web", s.Tag("kubernetes.name"))
+		assert.Equal(t, 1.0, s.Tag(ext.EventSampleRate))
+	}
+
+	// the given config is left untouched
+	_, ok := cfg.WrapTransport(http.DefaultTransport).(*http.Transport)
+	assert.True(t, ok)
+}
+
 func TestKubernetes(t *testing.T) {
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package iris_test
+
+import (
+	"github.com/kataras/iris/v12"
+
+	iristrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/kataras/iris.v12"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	// Start the tracer
+	tracer.Start()
+	defer tracer.Stop()
+
+	// Create an iris application and use the tracer middleware with your desired service name.
+	app := iris.New()
+	app.Use(iristrace.Middleware(iristrace.WithServiceName("iris-server")))
+
+	app.Get("/users/{id}", func(ctx iris.Context) {
+		// Spans started from the request context are children of the request span
+		span, _ := tracer.StartSpanFromContext(ctx.Request().Context(), "load.user")
+		defer span.Finish()
+		ctx.WriteString("Hello " + ctx.Params().Get("id"))
+	})
+	app.Listen(":8080")
+}
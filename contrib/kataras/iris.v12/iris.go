@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package iris provides functions to trace the kataras/iris/v12 package (https://github.com/kataras/iris).
+package iris // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/kataras/iris.v12"
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/kataras/iris/v12"
+)
+
+// Middleware returns iris middleware which will trace incoming requests. It should be
+// registered using Use or UseRouter on the application or a party. The resource name
+// of the spans, as well as their http.route tag, is the path the matched route was
+// registered with, such as "/users/{id:uint64}".
+//
+// The span is passed to the handlers through the context of the request, which is
+// available using ctx.Request().Context().
+func Middleware(opts ...Option) iris.Handler {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return func(ctx iris.Context) {
+		r := ctx.Request()
+		opts := []ddtrace.StartSpanOption{
+			tracer.SpanType(ext.SpanTypeWeb),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.Tag(ext.HTTPMethod, r.Method),
+			tracer.Tag(ext.HTTPURL, r.URL.Path),
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header)); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+		opts = append(opts, cfg.spanOpts...)
+		span, sctx := tracer.StartSpanFromContext(r.Context(), "http.request", opts...)
+		defer span.Finish()
+
+		// pass the span through the request context and serve the request to the next handler
+		ctx.ResetRequest(r.WithContext(sctx))
+		ctx.Next()
+
+		route := "unknown"
+		if rt := ctx.GetCurrentRoute(); rt != nil && rt.StatusErrorCode() == 0 {
+			route = rt.Path()
+			span.SetTag(ext.HTTPRoute, route)
+		}
+		span.SetTag(ext.ResourceName, r.Method+" "+route)
+
+		status := ctx.GetStatusCode()
+		span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+		if status >= 500 && status < 600 {
+			// mark 5xx server error, preferring the error set by the handlers
+			if err := ctx.GetErr(); err != nil {
+				span.SetTag(ext.Error, err)
+			} else {
+				span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package iris
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(opts ...Option) *iris.Application {
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.Use(Middleware(opts...))
+	return app
+}
+
+func serve(t *testing.T, app *iris.Application, r *http.Request) *httptest.ResponseRecorder {
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	return w
+}
+
+func TestTrace200(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	var called, traced bool
+
+	app := newApp(WithServiceName("foobar"))
+	app.Get("/user/{id:uint64}", func(ctx iris.Context) {
+		called = true
+		var span tracer.Span
+		span, traced = tracer.SpanFromContext(ctx.Request().Context())
+		assert.Equal("foobar", span.(mocktracer.Span).Tag(ext.ServiceName))
+		ctx.WriteString(ctx.Params().Get("id"))
+	})
+
+	root := tracer.StartSpan("root")
+	r := httptest.NewRequest("GET", "/user/123", nil)
+	err := tracer.Inject(root.Context(), tracer.HTTPHeadersCarrier(r.Header))
+	assert.Nil(err)
+	w := serve(t, app, r)
+	assert.Equal(200, w.Code)
+	assert.Equal("123", w.Body.String())
+
+	// verify traces look good
+	assert.True(called)
+	assert.True(traced)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal(ext.SpanTypeWeb, span.Tag(ext.SpanType))
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("GET /user/{id:uint64}", span.Tag(ext.ResourceName))
+	assert.Equal("/user/{id:uint64}", span.Tag(ext.HTTPRoute))
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal("GET", span.Tag(ext.HTTPMethod))
+	assert.Equal("/user/123", span.Tag(ext.HTTPURL))
+	assert.Equal(root.Context().SpanID(), span.ParentID())
+	assert.Nil(span.Tag(ext.Error))
+}
+
+func TestParty(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	api := app.Party("/api", Middleware(WithServiceName("api")))
+	api.Get("/users/{id}", func(ctx iris.Context) {})
+
+	w := serve(t, app, httptest.NewRequest("GET", "/api/users/123", nil))
+	assert.Equal(200, w.Code)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("api", span.Tag(ext.ServiceName))
+	assert.Equal("GET /api/users/{id}", span.Tag(ext.ResourceName))
+	assert.Equal("/api/users/{id}", span.Tag(ext.HTTPRoute))
+}
+
+func TestError(t *testing.T) {
+	wantErr := errors.New("oh no")
+	app := newApp()
+	app.Get("/err", func(ctx iris.Context) {
+		ctx.StopWithError(http.StatusInternalServerError, wantErr)
+	})
+	app.Get("/unavailable", func(ctx iris.Context) {
+		ctx.StatusCode(http.StatusServiceUnavailable)
+	})
+	app.Get("/bad", func(ctx iris.Context) {
+		ctx.StopWithStatus(http.StatusBadRequest)
+	})
+
+	for _, tt := range []struct {
+		url    string
+		status int
+		err    string
+	}{
+		{url: "/err", status: 500, err: wantErr.Error()},
+		{url: "/unavailable", status: 503, err: "503: Service Unavailable"},
+		{url: "/bad", status: 400},
+	} {
+		t.Run(tt.url, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			w := serve(t, app, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(tt.status, w.Code)
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			span := spans[0]
+			assert.Equal("GET "+tt.url, span.Tag(ext.ResourceName))
+			assert.Equal(strconv.Itoa(tt.status), span.Tag(ext.HTTPCode))
+			if tt.err == "" {
+				assert.Nil(span.Tag(ext.Error))
+			} else {
+				assert.Equal(tt.err, span.Tag(ext.Error).(error).Error())
+			}
+		})
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	app := iris.New()
+	app.Logger().SetLevel("disable")
+	app.UseRouter(Middleware())
+	app.Get("/ping", func(ctx iris.Context) {})
+
+	w := serve(t, app, httptest.NewRequest("GET", "/missing", nil))
+	assert.Equal(404, w.Code)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("GET unknown", span.Tag(ext.ResourceName))
+	assert.Equal("404", span.Tag(ext.HTTPCode))
+	assert.Nil(span.Tag(ext.HTTPRoute))
+	assert.Nil(span.Tag(ext.Error))
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		app := newApp(opts...)
+		app.Get("/user/{id}", func(ctx iris.Context) {})
+		serve(t, app, httptest.NewRequest("GET", "/user/123", nil))
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("global", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rate := globalconfig.AnalyticsRate()
+		defer globalconfig.SetAnalyticsRate(rate)
+		globalconfig.SetAnalyticsRate(0.4)
+
+		assertRate(t, mt, 0.4)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
@@ -0,0 +1,321 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChildSpan(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	var called, traced bool
+
+	router := echo.New()
+	router.Use(Middleware(WithServiceName("foobar")))
+	router.GET("/user/:id", func(c echo.Context) error {
+		called = true
+		_, traced = tracer.SpanFromContext(c.Request().Context())
+		return c.NoContent(200)
+	})
+
+	r := httptest.NewRequest("GET", "/user/123", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	// verify traces look good
+	assert.True(called)
+	assert.True(traced)
+}
+
+func TestTrace200(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	var called, traced bool
+
+	router := echo.New()
+	router.Use(Middleware(WithServiceName("foobar"), WithAnalytics(false)))
+	router.GET("/user/:id", func(c echo.Context) error {
+		called = true
+		var span tracer.Span
+		span, traced = tracer.SpanFromContext(c.Request().Context())
+
+		// we patch the span on the request context.
+		span.SetTag("test.echo", "echony")
+		assert.Equal(span.(mocktracer.Span).Tag(ext.ServiceName), "foobar")
+		return c.NoContent(200)
+	})
+
+	root := tracer.StartSpan("root")
+	r := httptest.NewRequest("GET", "/user/123", nil)
+	err := tracer.Inject(root.Context(), tracer.HTTPHeadersCarrier(r.Header))
+	assert.Nil(err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	// verify traces look good
+	assert.True(called)
+	assert.True(traced)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal(ext.SpanTypeWeb, span.Tag(ext.SpanType))
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("echony", span.Tag("test.echo"))
+	assert.Equal("GET /user/:id", span.Tag(ext.ResourceName))
+	assert.Equal("/user/:id", span.Tag(ext.HTTPRoute))
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal("GET", span.Tag(ext.HTTPMethod))
+	assert.Equal(root.Context().SpanID(), span.ParentID())
+
+	assert.Equal("/user/123", span.Tag(ext.HTTPURL))
+}
+
+func TestTraceAnalytics(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	var called, traced bool
+
+	router := echo.New()
+	router.Use(Middleware(WithServiceName("foobar"), WithAnalytics(true)))
+	router.GET("/user/:id", func(c echo.Context) error {
+		called = true
+		var span tracer.Span
+		span, traced = tracer.SpanFromContext(c.Request().Context())
+
+		// we patch the span on the request context.
+		span.SetTag("test.echo", "echony")
+		assert.Equal(span.(mocktracer.Span).Tag(ext.ServiceName), "foobar")
+		return c.NoContent(200)
+	})
+
+	root := tracer.StartSpan("root")
+	r := httptest.NewRequest("GET", "/user/123", nil)
+	err := tracer.Inject(root.Context(), tracer.HTTPHeadersCarrier(r.Header))
+	assert.Nil(err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	// verify traces look good
+	assert.True(called)
+	assert.True(traced)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal(ext.SpanTypeWeb, span.Tag(ext.SpanType))
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("echony", span.Tag("test.echo"))
+	assert.Contains(span.Tag(ext.ResourceName), "/user/:id")
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal("GET", span.Tag(ext.HTTPMethod))
+	assert.Equal(1.0, span.Tag(ext.EventSampleRate))
+	assert.Equal(root.Context().SpanID(), span.ParentID())
+
+	assert.Equal("/user/123", span.Tag(ext.HTTPURL))
+}
+
+func TestError(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	var called, traced bool
+
+	// setup
+	router := echo.New()
+	router.Use(Middleware(WithServiceName("foobar")))
+	wantErr := errors.New("oh no")
+
+	// a handler with an error and make the requests
+	router.GET("/err", func(c echo.Context) error {
+		_, traced = tracer.SpanFromContext(c.Request().Context())
+		called = true
+
+		err := wantErr
+		c.Error(err)
+		return err
+	})
+	r := httptest.NewRequest("GET", "/err", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	// verify the errors and status are correct
+	assert.True(called)
+	assert.True(traced)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("500", span.Tag(ext.HTTPCode))
+	assert.Equal(wantErr.Error(), span.Tag(ext.Error).(error).Error())
+}
+
+func TestErrorHandling(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	var called, traced bool
+	var handled int
+
+	// setup
+	router := echo.New()
+	router.HTTPErrorHandler = func(err error, ctx echo.Context) {
+		handled++
+		ctx.Response().WriteHeader(http.StatusInternalServerError)
+	}
+	router.Use(Middleware(WithServiceName("foobar")))
+	wantErr := errors.New("oh no")
+
+	// a handler with an error and make the requests
+	router.GET("/err", func(c echo.Context) error {
+		_, traced = tracer.SpanFromContext(c.Request().Context())
+		called = true
+		return wantErr
+	})
+	r := httptest.NewRequest("GET", "/err", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	// verify the errors and status are correct
+	assert.True(called)
+	assert.True(traced)
+	assert.Equal(1, handled, "the error is handled once")
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("500", span.Tag(ext.HTTPCode))
+	assert.Equal(wantErr.Error(), span.Tag(ext.Error).(error).Error())
+}
+
+func TestHTTPError(t *testing.T) {
+	router := echo.New()
+	router.Use(Middleware(WithServiceName("foobar")))
+	router.GET("/internal", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed").SetInternal(errors.New("connection refused"))
+	})
+	router.GET("/missing", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "no such user")
+	})
+
+	t.Run("server", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/internal", nil))
+		assert.Equal(500, w.Code)
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		span := spans[0]
+		assert.Equal("500", span.Tag(ext.HTTPCode))
+		assert.Contains(span.Tag(ext.Error).(error).Error(), "connection refused")
+	})
+
+	t.Run("client", func(t *testing.T) {
+		assert := assert.New(t)
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+		assert.Equal(404, w.Code)
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		span := spans[0]
+		assert.Equal("404", span.Tag(ext.HTTPCode))
+		assert.Contains(span.Tag(ext.Error).(error).Error(), "no such user")
+	})
+}
+
+func TestGroup(t *testing.T) {
+	router := echo.New()
+	router.Use(Middleware(WithServiceName("root")))
+	api := router.Group("/api")
+	api.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(200)
+	})
+	admin := echo.New()
+	admin.Group("/admin", Middleware(WithServiceName("admin"))).GET("/stats/*", func(c echo.Context) error {
+		return c.NoContent(200)
+	})
+
+	for _, tt := range []struct {
+		router   *echo.Echo
+		url      string
+		service  string
+		resource string
+		route    string
+	}{
+		{router: router, url: "/api/users/123", service: "root", resource: "GET /api/users/:id", route: "/api/users/:id"},
+		{router: admin, url: "/admin/stats/cpu", service: "admin", resource: "GET /admin/stats/*", route: "/admin/stats/*"},
+	} {
+		t.Run(tt.url, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			w := httptest.NewRecorder()
+			tt.router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(200, w.Code)
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			span := spans[0]
+			assert.Equal(tt.service, span.Tag(ext.ServiceName))
+			assert.Equal(tt.resource, span.Tag(ext.ResourceName))
+			assert.Equal(tt.route, span.Tag(ext.HTTPRoute))
+			assert.Equal(tt.url, span.Tag(ext.HTTPURL))
+		})
+	}
+}
+
+func TestGetSpanNotInstrumented(t *testing.T) {
+	assert := assert.New(t)
+	router := echo.New()
+	var called, traced bool
+
+	router.GET("/ping", func(c echo.Context) error {
+		// Assert we don't have a span on the context.
+		called = true
+		_, traced = tracer.SpanFromContext(c.Request().Context())
+		return c.NoContent(200)
+	})
+
+	r := httptest.NewRequest("GET", "/ping", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+	assert.True(called)
+	assert.False(traced)
+}
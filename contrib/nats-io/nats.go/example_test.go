@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nats_test
+
+import (
+	"log"
+
+	"github.com/nats-io/nats.go"
+
+	natstrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/nats-io/nats.go"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	nc, err := natstrace.NewConn(nats.DefaultURL, natstrace.WithServiceName("my-nats"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer nc.Close()
+
+	// every received message is traced as a child of the span that published it
+	nc.Subscribe("updates", func(m *nats.Msg) {
+		// the headers of m now carry the context of the consumer span
+		spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(m.Header))
+		if err == nil {
+			span := tracer.StartSpan("process.update", tracer.ChildOf(spanctx))
+			defer span.Finish()
+		}
+	})
+	nc.Publish("updates", []byte("hello"))
+}
+
+func ExampleWrapJetStream() {
+	nc, err := nats.Connect(nats.DefaultURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer nc.Close()
+	jsc, err := nc.JetStream()
+	if err != nil {
+		log.Fatal(err)
+	}
+	js := natstrace.WrapJetStream(jsc)
+
+	js.Subscribe("orders.*", func(m *nats.Msg) {
+		m.Ack()
+	}, nats.Durable("processor"))
+	js.Publish("orders.new", []byte("order"))
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nats
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/nats-io/nats.go"
+)
+
+// A TracedJetStream wraps a nats.JetStreamContext, tracing published and received
+// messages. All other methods are proxied to the wrapped context.
+type TracedJetStream struct {
+	nats.JetStreamContext
+	cfg *config
+}
+
+// WrapJetStream wraps a nats.JetStreamContext so that published and received messages
+// are traced. Publish spans are tagged with the stream and sequence number acknowledged
+// by the server, and consumer spans with the stream, consumer and sequence numbers of
+// the delivery.
+func WrapJetStream(js nats.JetStreamContext, opts ...Option) *TracedJetStream {
+	return &TracedJetStream{JetStreamContext: js, cfg: newConfig(opts...)}
+}
+
+// Publish publishes data to the given subject and waits for its acknowledgement.
+func (js *TracedJetStream) Publish(subj string, data []byte, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	return js.PublishMsgWithContext(context.Background(), &nats.Msg{Subject: subj, Data: data}, opts...)
+}
+
+// PublishMsg publishes the given message and waits for its acknowledgement.
+func (js *TracedJetStream) PublishMsg(m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	return js.PublishMsgWithContext(context.Background(), m, opts...)
+}
+
+// PublishMsgWithContext is like PublishMsg, using ctx as a basis for the started span.
+func (js *TracedJetStream) PublishMsgWithContext(ctx context.Context, m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	span := startPublishSpan(ctx, js.cfg, m)
+	ack, err := js.JetStreamContext.PublishMsg(m, opts...)
+	if ack != nil {
+		span.SetTag(tagStream, ack.Stream)
+		span.SetTag(tagStreamSeq, ack.Sequence)
+	}
+	span.Finish(tracer.WithError(err))
+	return ack, err
+}
+
+// Subscribe calls the underlying JetStreamContext.Subscribe with a handler which starts
+// a consumer span for each message, as a child of the span context found in the message
+// headers. The span covers the handler only: with the default automatic acknowledgement,
+// the message is acknowledged once the handler returns, after the span is finished. To
+// include the acknowledgement in the span, pass nats.ManualAck and acknowledge the
+// message from cb.
+func (js *TracedJetStream) Subscribe(subj string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	return js.JetStreamContext.Subscribe(subj, wrapHandler(js.cfg, "", cb), opts...)
+}
+
+// QueueSubscribe is like Subscribe, for queue groups.
+func (js *TracedJetStream) QueueSubscribe(subj, queue string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	return js.JetStreamContext.QueueSubscribe(subj, queue, wrapHandler(js.cfg, queue, cb), opts...)
+}
@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package nats provides functions to trace the nats-io/nats.go package (https://github.com/nats-io/nats.go).
+// Trace contexts are propagated through message headers, which require NATS 2.2 or later.
+package nats // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/nats-io/nats.go"
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	tagSubject    = "nats.subject"
+	tagQueue      = "nats.queue"
+	tagStream     = "nats.stream"
+	tagConsumer   = "nats.consumer"
+	tagStreamSeq  = "nats.sequence.stream"
+	tagConsumeSeq = "nats.sequence.consumer"
+	tagDelivered  = "nats.num_delivered"
+)
+
+// A TracedConn wraps a nats.Conn, tracing published and received messages. All other
+// methods are proxied to the wrapped connection.
+type TracedConn struct {
+	*nats.Conn
+	cfg *config
+}
+
+// NewConn calls nats.Connect with the options given using WithConnectOptions and wraps
+// the resulting connection.
+func NewConn(url string, opts ...Option) (*TracedConn, error) {
+	cfg := newConfig(opts...)
+	nc, err := nats.Connect(url, cfg.connectOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TracedConn{Conn: nc, cfg: cfg}, nil
+}
+
+// WrapConn wraps an existing nats.Conn so that published and received messages are traced.
+func WrapConn(nc *nats.Conn, opts ...Option) *TracedConn {
+	return &TracedConn{Conn: nc, cfg: newConfig(opts...)}
+}
+
+func newConfig(opts ...Option) *config {
+	cfg := new(config)
+	defaults(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Publish publishes data to the given subject, injecting the span context of the
+// started producer span into the message headers.
+func (c *TracedConn) Publish(subj string, data []byte) error {
+	return c.PublishMsgWithContext(context.Background(), &nats.Msg{Subject: subj, Data: data})
+}
+
+// PublishMsg publishes the given message, injecting the span context of the started
+// producer span into its headers.
+func (c *TracedConn) PublishMsg(m *nats.Msg) error {
+	return c.PublishMsgWithContext(context.Background(), m)
+}
+
+// PublishMsgWithContext is like PublishMsg, using ctx as a basis for the started span.
+func (c *TracedConn) PublishMsgWithContext(ctx context.Context, m *nats.Msg) error {
+	span := startPublishSpan(ctx, c.cfg, m)
+	err := c.Conn.PublishMsg(m)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// Subscribe calls the underlying Conn.Subscribe with a handler which starts a consumer
+// span for each message, as a child of the span context found in the message headers.
+func (c *TracedConn) Subscribe(subj string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	return c.Conn.Subscribe(subj, wrapHandler(c.cfg, "", cb))
+}
+
+// QueueSubscribe is like Subscribe, for queue groups.
+func (c *TracedConn) QueueSubscribe(subj, queue string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	return c.Conn.QueueSubscribe(subj, queue, wrapHandler(c.cfg, queue, cb))
+}
+
+// startPublishSpan starts a producer span for the given message and injects its context
+// into the message headers.
+func startPublishSpan(ctx context.Context, cfg *config, m *nats.Msg) ddtrace.Span {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName("Publish Subject " + m.Subject),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(tagSubject, m.Subject),
+	}
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, "nats.publish", opts...)
+	if m.Header == nil {
+		m.Header = nats.Header{}
+	}
+	tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(http.Header(m.Header)))
+	return span
+}
+
+// wrapHandler returns a handler which traces the processing of each message by cb. The
+// context of the consumer span is injected back into the message headers, so that cb
+// can use it as a parent.
+func wrapHandler(cfg *config, queue string, cb nats.MsgHandler) nats.MsgHandler {
+	return func(m *nats.Msg) {
+		opts := []ddtrace.StartSpanOption{
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName("Consume Subject " + m.Subject),
+			tracer.SpanType(ext.SpanTypeMessageConsumer),
+			tracer.Tag(tagSubject, m.Subject),
+		}
+		if queue != "" {
+			opts = append(opts, tracer.Tag(tagQueue, queue))
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		if m.Header == nil {
+			m.Header = nats.Header{}
+		}
+		carrier := tracer.HTTPHeadersCarrier(http.Header(m.Header))
+		if spanctx, err := tracer.Extract(carrier); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+		// JetStream messages carry their delivery metadata in the reply subject
+		if md, err := m.Metadata(); err == nil {
+			opts = append(opts,
+				tracer.Tag(tagStream, md.Stream),
+				tracer.Tag(tagConsumer, md.Consumer),
+				tracer.Tag(tagStreamSeq, md.Sequence.Stream),
+				tracer.Tag(tagConsumeSeq, md.Sequence.Consumer),
+				tracer.Tag(tagDelivered, md.NumDelivered),
+			)
+		}
+		span := tracer.StartSpan("nats.consume", opts...)
+		tracer.Inject(span.Context(), carrier)
+		defer span.Finish()
+		cb(m)
+	}
+}
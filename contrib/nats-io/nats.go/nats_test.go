@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nats
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishConsume(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := newConfig(WithServiceName("my-nats"))
+	m := &nats.Msg{Subject: "updates", Data: []byte("hello")}
+	startPublishSpan(context.Background(), cfg, m).Finish()
+
+	var handled bool
+	wrapHandler(cfg, "workers", func(m *nats.Msg) {
+		// the handler sees the consumer span context in the headers
+		spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(http.Header(m.Header)))
+		assert.NoError(err)
+		assert.NotNil(spanctx)
+		handled = true
+	})(m)
+	assert.True(handled)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	pub, cons := spans[0], spans[1]
+
+	assert.Equal("nats.publish", pub.OperationName())
+	assert.Equal("Publish Subject updates", pub.Tag(ext.ResourceName))
+	assert.Equal("my-nats", pub.Tag(ext.ServiceName))
+	assert.Equal(ext.SpanTypeMessageProducer, pub.Tag(ext.SpanType))
+	assert.Equal("updates", pub.Tag(tagSubject))
+
+	assert.Equal("nats.consume", cons.OperationName())
+	assert.Equal("Consume Subject updates", cons.Tag(ext.ResourceName))
+	assert.Equal(ext.SpanTypeMessageConsumer, cons.Tag(ext.SpanType))
+	assert.Equal("workers", cons.Tag(tagQueue))
+	assert.Equal(pub.SpanID(), cons.ParentID())
+	assert.Equal(pub.TraceID(), cons.TraceID())
+}
+
+func TestPublishParent(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	startPublishSpan(ctx, newConfig(), &nats.Msg{Subject: "updates"}).Finish()
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	assert.Equal(spans[1].SpanID(), spans[0].ParentID())
+	assert.Equal("nats", spans[0].Tag(ext.ServiceName))
+}
+
+func TestConsumeJetStreamMetadata(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// JetStream deliveries encode their metadata in the reply subject
+	m := &nats.Msg{
+		Subject: "orders.new",
+		Sub:     &nats.Subscription{},
+		Reply:   "$JS.ACK.ORDERS.processor.2.15.7.1600000000000000000.0",
+	}
+	wrapHandler(newConfig(), "", func(*nats.Msg) {})(m)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	s := spans[0]
+	assert.Equal("ORDERS", s.Tag(tagStream))
+	assert.Equal("processor", s.Tag(tagConsumer))
+	assert.Equal(uint64(15), s.Tag(tagStreamSeq))
+	assert.Equal(uint64(7), s.Tag(tagConsumeSeq))
+	assert.Equal(uint64(2), s.Tag(tagDelivered))
+	assert.Equal(uint64(0), s.ParentID())
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		cfg := newConfig(opts...)
+		m := &nats.Msg{Subject: "updates"}
+		startPublishSpan(context.Background(), cfg, m).Finish()
+		wrapHandler(cfg, "", func(*nats.Msg) {})(m)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 2)
+		for _, s := range spans {
+			assert.Equal(t, rate, s.Tag(ext.EventSampleRate))
+		}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
+
+func TestIntegration(t *testing.T) {
+	if _, ok := os.LookupEnv("INTEGRATION"); !ok {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	nc, err := NewConn(nats.DefaultURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+
+	done := make(chan struct{})
+	_, err = nc.Subscribe("dd-trace-go.test", func(*nats.Msg) { close(done) })
+	assert.NoError(err)
+	assert.NoError(nc.Publish("dd-trace-go.test", []byte("hello")))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	nc.Flush()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+}
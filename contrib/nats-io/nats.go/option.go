@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package nats
+
+import (
+	"math"
+
+	"github.com/nats-io/nats.go"
+)
+
+type config struct {
+	serviceName   string
+	analyticsRate float64
+	connectOpts   []nats.Option
+}
+
+func defaults(cfg *config) {
+	cfg.serviceName = "nats"
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.analyticsRate = math.NaN()
+}
+
+// An Option is used to customize the config for the nats tracer.
+type Option func(cfg *config)
+
+// WithServiceName sets the given service name for the started spans.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithConnectOptions sets the options passed to nats.Connect by NewConn.
+func WithConnectOptions(opts ...nats.Option) Option {
+	return func(cfg *config) {
+		cfg.connectOpts = append(cfg.connectOpts, opts...)
+	}
+}
@@ -9,8 +9,11 @@ package http // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
 import (
 	"math"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httputil"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
@@ -40,13 +43,25 @@ func NewServeMux(opts ...Option) *ServeMux {
 // We only need to rewrite this function to be able to trace
 // all the incoming requests to the underlying multiplexer
 func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if mux.cfg.ignored(r) {
+		mux.ServeMux.ServeHTTP(w, r)
+		return
+	}
 	// get the resource associated to this request
 	_, route := mux.Handler(r)
-	resource := r.Method + " " + route
-	opts := mux.cfg.spanOpts
+	resource := mux.cfg.resourceName(r, r.Method+" "+route)
+	// copy the configured options so that concurrent requests never append
+	// to the same backing array
+	opts := append(make([]ddtrace.StartSpanOption, 0, len(mux.cfg.spanOpts)+3), mux.cfg.spanOpts...)
 	if !math.IsNaN(mux.cfg.analyticsRate) {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, mux.cfg.analyticsRate))
 	}
+	if mux.cfg.queryString {
+		opts = append(opts, tracer.Tag(ext.HTTPURL, mux.cfg.url(r)))
+	}
+	if spanctx, ok := mux.cfg.otelParent(r); ok {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
 	httputil.TraceAndServe(mux.ServeMux, w, r, mux.cfg.serviceName, resource, opts...)
 }
 
@@ -58,6 +73,69 @@ func WrapHandler(h http.Handler, service, resource string, opts ...Option) http.
 		fn(cfg)
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		httputil.TraceAndServe(h, w, req, service, resource, cfg.spanOpts...)
+		if cfg.ignored(req) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		opts := append(make([]ddtrace.StartSpanOption, 0, len(cfg.spanOpts)+2), cfg.spanOpts...)
+		if cfg.queryString {
+			opts = append(opts, tracer.Tag(ext.HTTPURL, cfg.url(req)))
+		}
+		if spanctx, ok := cfg.otelParent(req); ok {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+		httputil.TraceAndServe(h, w, req, service, cfg.resourceName(req, resource), opts...)
 	})
 }
+
+// redacted is the value which replaces sensitive query parameters in URL tags.
+const redacted = "<redacted>"
+
+// url returns the URL path of r, followed by its query string in which the values
+// of the configured sensitive parameters have been redacted.
+func (cfg *config) url(r *http.Request) string {
+	if r.URL.RawQuery == "" {
+		return r.URL.Path
+	}
+	pairs := strings.Split(r.URL.RawQuery, "&")
+	for i, pair := range pairs {
+		rawKey := pair
+		if j := strings.IndexByte(pair, '='); j >= 0 {
+			rawKey = pair[:j]
+		}
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		for _, p := range cfg.redactedParams {
+			if strings.EqualFold(key, p) {
+				pairs[i] = rawKey + "=" + redacted
+				break
+			}
+		}
+	}
+	return r.URL.Path + "?" + strings.Join(pairs, "&")
+}
+
+// w3c extracts span contexts from the W3C trace context headers ("traceparent"
+// and "tracestate") sent by OpenTelemetry instrumented services.
+var w3c = &tracer.W3CTraceContextPropagator{}
+
+// otelParent returns the span context found in the W3C trace context headers of r
+// when the OTEL fallback is enabled and the global tracer could not extract a span
+// context from the request. The sampling decision is taken from the trace flags.
+func (cfg *config) otelParent(r *http.Request) (ddtrace.SpanContext, bool) {
+	if !cfg.otelFallback {
+		return nil, false
+	}
+	carrier := tracer.HTTPHeadersCarrier(r.Header)
+	if _, err := tracer.Extract(carrier); err == nil {
+		// the span context will be extracted by TraceAndServe
+		return nil, false
+	}
+	spanctx, err := w3c.Extract(carrier)
+	if err != nil {
+		return nil, false
+	}
+	return spanctx, true
+}
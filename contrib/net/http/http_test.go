@@ -6,8 +6,11 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -101,6 +104,265 @@ func TestWrapHandler200(t *testing.T) {
 	assert.Equal("bar", s.Tag("foo"))
 }
 
+func TestIgnoreURLPrefixes(t *testing.T) {
+	t.Run("mux", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		assert := assert.New(t)
+
+		mux := NewServeMux(WithIgnoreURLPrefixes("/healthz", "/metrics"))
+		mux.HandleFunc("/healthz", handler200)
+		mux.HandleFunc("/metrics/", handler200)
+		mux.HandleFunc("/200", handler200)
+
+		for _, url := range []string{"/healthz", "/metrics/go", "/200"} {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			assert.Equal(200, w.Code)
+			assert.Equal("OK\n", w.Body.String())
+		}
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal("GET /200", spans[0].Tag(ext.ResourceName))
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		assert := assert.New(t)
+
+		handler := WrapHandler(http.HandlerFunc(handler200), "my-service", "my-resource",
+			WithIgnoreURLPrefixes("/healthz"))
+		for _, url := range []string{"/healthz/ready", "/"} {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(200, w.Code)
+		}
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal("/", spans[0].Tag(ext.HTTPURL))
+	})
+}
+
+func TestResourceNameFunc(t *testing.T) {
+	namer := func(r *http.Request) string {
+		if strings.HasPrefix(r.URL.Path, "/users/") {
+			return r.Method + " /users/{id}"
+		}
+		return ""
+	}
+
+	t.Run("mux", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		assert := assert.New(t)
+
+		mux := NewServeMux(WithResourceNameFunc(namer))
+		mux.HandleFunc("/users/", handler200)
+		mux.HandleFunc("/200", handler200)
+		for _, url := range []string{"/users/123", "/users/456", "/200"} {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+		}
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 3)
+		assert.Equal("GET /users/{id}", spans[0].Tag(ext.ResourceName))
+		assert.Equal("GET /users/{id}", spans[1].Tag(ext.ResourceName))
+		assert.Equal("GET /200", spans[2].Tag(ext.ResourceName))
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		assert := assert.New(t)
+
+		handler := WrapHandler(http.HandlerFunc(handler200), "my-service", "my-resource",
+			WithResourceNameFunc(namer))
+		for _, url := range []string{"/users/123", "/"} {
+			r := httptest.NewRequest("GET", url, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+		}
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 2)
+		assert.Equal("GET /users/{id}", spans[0].Tag(ext.ResourceName))
+		assert.Equal("my-resource", spans[1].Tag(ext.ResourceName))
+	})
+}
+
+func TestOTELFallback(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	newRequest := func(headers map[string]string) *http.Request {
+		r := httptest.NewRequest("GET", "/200", nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+	newConfig := func(opts ...Option) *config {
+		cfg := new(config)
+		defaults(cfg)
+		for _, fn := range opts {
+			fn(cfg)
+		}
+		return cfg
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		r := newRequest(map[string]string{"traceparent": traceparent})
+		_, ok := newConfig().otelParent(r)
+		assert.False(t, ok)
+	})
+
+	t.Run("traceparent", func(t *testing.T) {
+		assert := assert.New(t)
+		r := newRequest(map[string]string{
+			"traceparent": traceparent,
+			"tracestate":  "congo=t61rcWkgMzE",
+		})
+		spanctx, ok := newConfig(WithOTELFallback(true)).otelParent(r)
+		assert.True(ok)
+		assert.Equal(uint64(0xa3ce929d0e0e4736), spanctx.TraceID())
+		assert.Equal(uint64(0x00f067aa0ba902b7), spanctx.SpanID())
+	})
+
+	t.Run("datadog", func(t *testing.T) {
+		r := newRequest(map[string]string{
+			"traceparent":                traceparent,
+			tracer.DefaultTraceIDHeader:  "1",
+			tracer.DefaultParentIDHeader: "2",
+		})
+		_, ok := newConfig(WithOTELFallback(true)).otelParent(r)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		r := newRequest(map[string]string{"traceparent": "00-0000-invalid-01"})
+		_, ok := newConfig(WithOTELFallback(true)).otelParent(r)
+		assert.False(t, ok)
+	})
+
+	t.Run("mux", func(t *testing.T) {
+		defer mt.Reset()
+		assert := assert.New(t)
+		mux := NewServeMux(WithOTELFallback(true))
+		mux.HandleFunc("/200", handler200)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, newRequest(map[string]string{"traceparent": traceparent}))
+		assert.Equal(200, w.Code)
+		assert.Len(mt.FinishedSpans(), 1)
+	})
+}
+
+func TestQueryStringScrubbing(t *testing.T) {
+	assertURL := func(t *testing.T, url, want string, opts ...Option) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		mux := NewServeMux(opts...)
+		mux.HandleFunc("/200", handler200)
+		r := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, want, spans[0].Tag(ext.HTTPURL))
+	}
+
+	t.Run("default", func(t *testing.T) {
+		assertURL(t, "/200?token=abc&page=2", "/200")
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		assertURL(t, "/200?Token=abc&page=2&api_key=x&api_key=y", "/200?Token=<redacted>&page=2&api_key=<redacted>&api_key=<redacted>",
+			WithQueryStringScrubbing("token", "api_key"))
+	})
+
+	t.Run("escaped", func(t *testing.T) {
+		assertURL(t, "/200?api%5Fkey=x&q=a%20b", "/200?api%5Fkey=<redacted>&q=a%20b",
+			WithQueryStringScrubbing("api_key"))
+	})
+
+	t.Run("no-query", func(t *testing.T) {
+		assertURL(t, "/200", "/200", WithQueryStringScrubbing("token"))
+	})
+
+	t.Run("full", func(t *testing.T) {
+		assertURL(t, "/200?token=abc&page=2", "/200",
+			WithQueryStringScrubbing("token"), WithFullQueryStringScrubbing())
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		handler := WrapHandler(http.HandlerFunc(handler200), "my-service", "my-resource",
+			WithQueryStringScrubbing("token"))
+		r := httptest.NewRequest("GET", "/?token=abc", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "/?token=<redacted>", spans[0].Tag(ext.HTTPURL))
+	})
+}
+
+// TestConcurrentSpanOptions is meant to be run with -race. Three calls to
+// WithSpanOptions leave spare capacity in the configured slice of options.
+func TestConcurrentSpanOptions(t *testing.T) {
+	opts := []Option{
+		WithSpanOptions(tracer.Tag("a", 1)),
+		WithSpanOptions(tracer.Tag("b", 2)),
+		WithSpanOptions(tracer.Tag("c", 3)),
+		WithQueryStringScrubbing("token"),
+	}
+	for name, h := range map[string]http.Handler{
+		"mux": func() http.Handler {
+			mux := NewServeMux(opts...)
+			mux.HandleFunc("/200", handler200)
+			return mux
+		}(),
+		"handler": WrapHandler(http.HandlerFunc(handler200), "my-service", "my-resource", opts...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					r := httptest.NewRequest("GET", fmt.Sprintf("/200?page=%d", i), nil)
+					h.ServeHTTP(httptest.NewRecorder(), r)
+				}(i)
+			}
+			wg.Wait()
+
+			spans := mt.FinishedSpans()
+			assert.Len(t, spans, 50)
+			seen := make(map[interface{}]bool, len(spans))
+			for _, s := range spans {
+				seen[s.Tag(ext.HTTPURL)] = true
+				assert.Equal(t, 3, s.Tag("c"))
+			}
+			assert.Len(t, seen, 50)
+		})
+	}
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		mux := NewServeMux(opts...)
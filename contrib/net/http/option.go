@@ -8,15 +8,21 @@ package http
 import (
 	"math"
 	"net/http"
+	"strings"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
 )
 
 type config struct {
-	serviceName   string
-	analyticsRate float64
-	spanOpts      []ddtrace.StartSpanOption
+	serviceName    string
+	analyticsRate  float64
+	spanOpts       []ddtrace.StartSpanOption
+	ignorePrefixes []string
+	resourceNamer  func(r *http.Request) string
+	queryString    bool     // when true, the query string is included in the URL tag
+	redactedParams []string // query parameters whose values are redacted
+	otelFallback   bool     // when true, W3C trace context headers are extracted as a fallback
 }
 
 // MuxOption has been deprecated in favor of Option.
@@ -68,6 +74,79 @@ func WithSpanOptions(opts ...ddtrace.StartSpanOption) Option {
 	}
 }
 
+// WithIgnoreURLPrefixes disables tracing for incoming requests whose URL path
+// starts with any of the given prefixes (e.g. "/healthz" or "/metrics"). Such
+// requests are passed on to the handler without creating a span.
+func WithIgnoreURLPrefixes(prefixes ...string) Option {
+	return func(cfg *config) {
+		cfg.ignorePrefixes = append(cfg.ignorePrefixes, prefixes...)
+	}
+}
+
+// WithResourceNameFunc specifies a function which will be used to obtain the
+// resource name for a given request, for example to normalize high-cardinality
+// paths such as "/users/123" into "/users/{id}". When fn returns an empty string,
+// the default resource name is used.
+func WithResourceNameFunc(fn func(r *http.Request) string) Option {
+	return func(cfg *config) {
+		cfg.resourceNamer = fn
+	}
+}
+
+// resourceName returns the resource name obtained from the configured resource
+// name function, or def if that is not set or returns an empty string.
+func (cfg *config) resourceName(r *http.Request, def string) string {
+	if cfg.resourceNamer == nil {
+		return def
+	}
+	if name := cfg.resourceNamer(r); name != "" {
+		return name
+	}
+	return def
+}
+
+// WithQueryStringScrubbing includes the request's query string in the URL tag of
+// the span, replacing the values of any of the given sensitive parameters (such as
+// API keys or session tokens) with "<redacted>". Parameter names are matched
+// case-insensitively.
+func WithQueryStringScrubbing(sensitiveParams ...string) Option {
+	return func(cfg *config) {
+		cfg.queryString = true
+		cfg.redactedParams = append(cfg.redactedParams, sensitiveParams...)
+	}
+}
+
+// WithFullQueryStringScrubbing removes the query string entirely from the URL
+// tag of the span, overriding any previous WithQueryStringScrubbing option. This
+// is the default behavior.
+func WithFullQueryStringScrubbing() Option {
+	return func(cfg *config) {
+		cfg.queryString = false
+		cfg.redactedParams = nil
+	}
+}
+
+// WithOTELFallback enables extracting the parent span context from the W3C trace
+// context headers ("traceparent" and "tracestate") used by OpenTelemetry, when no
+// span context can be extracted using the configured propagators. This allows
+// stitching traces started by OTEL instrumented proxies or gateways, regardless
+// of the DD_PROPAGATION_STYLE_EXTRACT setting.
+func WithOTELFallback(on bool) Option {
+	return func(cfg *config) {
+		cfg.otelFallback = on
+	}
+}
+
+// ignored reports whether the request r should not be traced.
+func (cfg *config) ignored(r *http.Request) bool {
+	for _, prefix := range cfg.ignorePrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // A RoundTripperBeforeFunc can be used to modify a span before an http
 // RoundTrip is made.
 type RoundTripperBeforeFunc func(*http.Request, ddtrace.Span)
@@ -77,10 +156,12 @@ type RoundTripperBeforeFunc func(*http.Request, ddtrace.Span)
 type RoundTripperAfterFunc func(*http.Response, ddtrace.Span)
 
 type roundTripperConfig struct {
-	before        RoundTripperBeforeFunc
-	after         RoundTripperAfterFunc
-	analyticsRate float64
-	serviceName   string
+	before          RoundTripperBeforeFunc
+	after           RoundTripperAfterFunc
+	analyticsRate   float64
+	serviceName     string
+	redirectSpans   bool
+	retryAttemptTag bool
 }
 
 func newRoundTripperConfig() *roundTripperConfig {
@@ -109,6 +190,25 @@ func WithAfter(f RoundTripperAfterFunc) RoundTripperOption {
 	}
 }
 
+// WithRedirectSpans makes the spans of the requests which follow redirects children
+// of the span of the original request, instead of siblings of it. These spans are
+// tagged with the redirect target ("http.redirect_target") and the number of
+// redirects which led to it ("http.redirect_count"). It relies on the
+// http.Client setting the Response field of redirected requests.
+func WithRedirectSpans(on bool) RoundTripperOption {
+	return func(cfg *roundTripperConfig) {
+		cfg.redirectSpans = on
+	}
+}
+
+// WithRetryAttemptTag enables tagging the spans of requests with the attempt number
+// set on their context using ContextWithRetryAttempt, as "http.retry_attempt".
+func WithRetryAttemptTag(on bool) RoundTripperOption {
+	return func(cfg *roundTripperConfig) {
+		cfg.retryAttemptTag = on
+	}
+}
+
 // RTWithServiceName sets the given service name for the RoundTripper.
 func RTWithServiceName(name string) RoundTripperOption {
 	return func(cfg *roundTripperConfig) {
@@ -6,6 +6,7 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"net/http"
@@ -37,7 +38,23 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err er
 	if rt.cfg.serviceName != "" {
 		opts = append(opts, tracer.ServiceName(rt.cfg.serviceName))
 	}
-	span, ctx := tracer.StartSpanFromContext(req.Context(), defaultResourceName, opts...)
+	if rt.cfg.retryAttemptTag {
+		if attempt, ok := req.Context().Value(retryAttemptKey{}).(int); ok {
+			opts = append(opts, tracer.Tag(ext.HTTPRetryAttempt, attempt))
+		}
+	}
+	ctx := req.Context()
+	if rt.cfg.redirectSpans {
+		if parent, n := redirectParent(req); parent != nil {
+			// redirected requests are children of the request which started the redirect chain
+			ctx = tracer.ContextWithSpan(ctx, parent)
+			opts = append(opts,
+				tracer.Tag("http.redirect_target", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+				tracer.Tag("http.redirect_count", n),
+			)
+		}
+	}
+	span, ctx := tracer.StartSpanFromContext(ctx, defaultResourceName, opts...)
 	defer func() {
 		if rt.cfg.after != nil {
 			rt.cfg.after(res, span)
@@ -53,7 +70,13 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err er
 		// this should never happen
 		fmt.Fprintf(os.Stderr, "contrib/net/http.Roundtrip: failed to inject http headers: %v\n", err)
 	}
-	res, err = rt.base.RoundTrip(req.WithContext(ctx))
+	r := req.WithContext(ctx)
+	res, err = rt.base.RoundTrip(r)
+	if err == nil && rt.cfg.redirectSpans {
+		// the client sets the response on the request following a redirect, which
+		// is how redirectParent finds the span of the previous request
+		res.Request = r
+	}
 	if err != nil {
 		span.SetTag("http.errors", err.Error())
 	} else {
@@ -66,6 +89,32 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (res *http.Response, err er
 	return res, err
 }
 
+// retryAttemptKey is the context key under which the attempt number of a request is stored.
+type retryAttemptKey struct{}
+
+// ContextWithRetryAttempt returns a copy of ctx which carries the given attempt number.
+// Retry wrappers can use it on the context of each attempt they make so that, when
+// WithRetryAttemptTag is enabled, the spans of the requests are tagged with it.
+func ContextWithRetryAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, retryAttemptKey{}, attempt)
+}
+
+// redirectParent returns the span of the request which started the redirect chain
+// of req along with the number of redirects which led to req. It returns a nil
+// span if req is not the result of a redirect.
+func redirectParent(req *http.Request) (ddtrace.Span, int) {
+	n := 0
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+		n++
+	}
+	if n == 0 {
+		return nil, 0
+	}
+	span, _ := tracer.SpanFromContext(req.Context())
+	return span, n
+}
+
 // WrapRoundTripper returns a new RoundTripper which traces all requests sent
 // over the transport.
 func WrapRoundTripper(rt http.RoundTripper, opts ...RoundTripperOption) http.RoundTripper {
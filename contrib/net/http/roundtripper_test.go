@@ -174,3 +174,123 @@ func TestServiceName(t *testing.T) {
 		assert.Equal(t, serviceName, spans[0].Tag(ext.ServiceName))
 	})
 }
+
+func TestRedirectSpans(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/first", http.RedirectHandler("/second", http.StatusFound))
+	mux.Handle("/second", http.RedirectHandler("/third?page=1", http.StatusMovedPermanently))
+	mux.HandleFunc("/third", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Hello World"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		client := WrapClient(&http.Client{}, WithRedirectSpans(true))
+		res, err := client.Get(s.URL + "/first")
+		assert.NoError(t, err)
+		assert.Equal(t, 200, res.StatusCode)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 3)
+		first, second, third := spans[0], spans[1], spans[2]
+		assert.Equal(t, "302", first.Tag(ext.HTTPCode))
+		assert.Equal(t, "/first", first.Tag(ext.HTTPURL))
+		assert.Equal(t, uint64(0), first.ParentID())
+		assert.Nil(t, first.Tag("http.redirect_count"))
+		assert.Nil(t, first.Tag("http.redirect_target"))
+
+		assert.Equal(t, "301", second.Tag(ext.HTTPCode))
+		assert.Equal(t, first.SpanID(), second.ParentID())
+		assert.Equal(t, 1, second.Tag("http.redirect_count"))
+		assert.Equal(t, s.URL+"/second", second.Tag("http.redirect_target"))
+
+		assert.Equal(t, "200", third.Tag(ext.HTTPCode))
+		assert.Equal(t, first.SpanID(), third.ParentID())
+		assert.Equal(t, 2, third.Tag("http.redirect_count"))
+		assert.Equal(t, s.URL+"/third", third.Tag("http.redirect_target"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		root := tracer.StartSpan("root")
+		req, err := http.NewRequest("GET", s.URL+"/first", nil)
+		assert.NoError(t, err)
+		client := WrapClient(&http.Client{})
+		_, err = client.Do(req.WithContext(tracer.ContextWithSpan(req.Context(), root)))
+		assert.NoError(t, err)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 3)
+		for _, span := range spans {
+			assert.Equal(t, root.Context().SpanID(), span.ParentID())
+			assert.Nil(t, span.Tag("http.redirect_count"))
+		}
+	})
+}
+
+func TestRetryAttemptTag(t *testing.T) {
+	var calls int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+
+	// retry mimics a retry wrapper which sets the attempt number on the context of each attempt
+	retry := func(client *http.Client) {
+		calls = 0
+		for attempt := 1; attempt <= 3; attempt++ {
+			req, err := http.NewRequest("GET", s.URL, nil)
+			assert.NoError(t, err)
+			res, err := client.Do(req.WithContext(ContextWithRetryAttempt(req.Context(), attempt)))
+			assert.NoError(t, err)
+			res.Body.Close()
+			if res.StatusCode == http.StatusOK {
+				return
+			}
+		}
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		retry(WrapClient(&http.Client{}, WithRetryAttemptTag(true)))
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 3)
+		for i, span := range spans {
+			assert.Equal(t, i+1, span.Tag(ext.HTTPRetryAttempt))
+		}
+		assert.Equal(t, "200", spans[2].Tag(ext.HTTPCode))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		retry(WrapClient(&http.Client{}))
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 3)
+		for _, span := range spans {
+			assert.Nil(t, span.Tag(ext.HTTPRetryAttempt))
+		}
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		client := WrapClient(&http.Client{}, WithRetryAttemptTag(true))
+		_, err := client.Get(s.URL)
+		assert.NoError(t, err)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Nil(t, spans[0].Tag(ext.HTTPRetryAttempt))
+	})
+}
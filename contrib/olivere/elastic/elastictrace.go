@@ -3,22 +3,18 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2016-2020 Datadog, Inc.
 
-// Package elastic provides functions to trace the gopkg.in/olivere/elastic.v{3,5} packages.
+// Package elastic provides functions to trace the gopkg.in/olivere/elastic.v{3,5} and
+// github.com/olivere/elastic/v7 packages.
 package elastic // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/olivere/elastic"
 
 import (
-	"bufio"
-	"bytes"
-	"compress/gzip"
-	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"math"
 	"net/http"
 	"regexp"
-	"strconv"
+	"strings"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/elasticutil"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -62,28 +58,20 @@ func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	defer span.Finish()
 
 	contentEncoding := req.Header.Get("Content-Encoding")
-	snip, rc, err := peek(req.Body, contentEncoding, int(req.ContentLength), bodyCutoff)
+	snip, rc, err := elasticutil.Peek(req.Body, contentEncoding, int(req.ContentLength), bodyCutoff)
 	if err == nil {
+		if t.config.obfuscateBody && strings.HasSuffix(url, "_search") {
+			if snip, err = obfuscateBody(snip); err != nil {
+				// bodies which are truncated or not JSON can not be obfuscated
+				snip = "?"
+			}
+		}
 		span.SetTag("elasticsearch.body", snip)
 	}
 	req.Body = rc
 	// process using the standard transport
 	res, err := t.config.transport.RoundTrip(req)
-	if err != nil {
-		// roundtrip error
-		span.SetTag(ext.Error, err)
-	} else if res.StatusCode < 200 || res.StatusCode > 299 {
-		// HTTP error
-		snip, rc, err := peek(res.Body, contentEncoding, int(res.ContentLength), bodyCutoff)
-		if err != nil {
-			snip = http.StatusText(res.StatusCode)
-		}
-		span.SetTag(ext.Error, errors.New(snip))
-		res.Body = rc
-	}
-	if res != nil {
-		span.SetTag(ext.HTTPCode, strconv.Itoa(res.StatusCode))
-	}
+	elasticutil.TagResponse(span, res, err, bodyCutoff)
 	return res, err
 }
 
@@ -102,43 +90,3 @@ func quantize(url, method string) string {
 	quantizedURL = indexRegexp.ReplaceAll(quantizedURL, indexPlaceholder)
 	return fmt.Sprintf("%s %s", method, quantizedURL)
 }
-
-// peek attempts to return the first n bytes, as a string, from the provided io.ReadCloser.
-// It returns a new io.ReadCloser which points to the same underlying stream and can be read
-// from to access the entire data including the snippet. max is used to specify the length
-// of the stream contained in the reader. If unknown, it should be -1. If 0 < max < n it
-// will override n.
-func peek(rc io.ReadCloser, encoding string, max, n int) (string, io.ReadCloser, error) {
-	if rc == nil {
-		return "", rc, errors.New("empty stream")
-	}
-	if max > 0 && max < n {
-		n = max
-	}
-	r := bufio.NewReaderSize(rc, n)
-	rc2 := struct {
-		io.Reader
-		io.Closer
-	}{
-		Reader: r,
-		Closer: rc,
-	}
-	snip, err := r.Peek(n)
-	if err == io.EOF {
-		err = nil
-	}
-	if err != nil {
-		return string(snip), rc2, err
-	}
-	if encoding == "gzip" {
-		// unpack the snippet
-		gzr, err := gzip.NewReader(bytes.NewReader(snip))
-		if err != nil {
-			// snip wasn't gzip; return it as is
-			return string(snip), rc2, nil
-		}
-		defer gzr.Close()
-		snip, err = ioutil.ReadAll(gzr)
-	}
-	return string(snip), rc2, err
-}
@@ -6,13 +6,12 @@
 package elastic
 
 import (
-	"bytes"
 	"context"
-	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
@@ -363,78 +362,90 @@ func TestResourceNamerSettings(t *testing.T) {
 	})
 }
 
-func TestPeek(t *testing.T) {
-	assert := assert.New(t)
+func TestClientSearch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"took":1,"hits":{"total":{"value":0},"hits":[]}}`))
+	}))
+	defer srv.Close()
+	body := `{"query":{"bool":{"must":{"match":{"user":{"query":"secret-user"}}}}},"size":10}`
+	search := func(opts ...ClientOption) {
+		res, err := NewHTTPClient(opts...).Post(srv.URL+"/twitter/_search", "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		res.Body.Close()
+	}
+
+	t.Run("default", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		search()
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, body, spans[0].Tag("elasticsearch.body"))
+	})
+
+	t.Run("obfuscated", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		search(WithBodyObfuscation(true))
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, `{"query":{"bool":{"must":{"match":{"user":{"query":"?"}}}}},"size":10}`,
+			spans[0].Tag("elasticsearch.body"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
 
-	for _, tt := range [...]struct {
-		max  int    // content length
-		txt  string // stream
-		n    int    // bytes to peek at
-		snip string // expected snippet
-		err  error  // expected error
+		search(WithBodyObfuscation(false))
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Contains(t, spans[0].Tag("elasticsearch.body"), "secret-user")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		defer func(old int) { bodyCutoff = old }(bodyCutoff)
+		bodyCutoff = 10
+
+		search(WithBodyObfuscation(true))
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "?", spans[0].Tag("elasticsearch.body"))
+	})
+}
+
+func TestObfuscateBody(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
 	}{
-		0: {
-			// extract 3 bytes from a content of length 7
-			txt:  "ABCDEFG",
-			max:  7,
-			n:    3,
-			snip: "ABC",
-		},
-		1: {
-			// extract 7 bytes from a content of length 7
-			txt:  "ABCDEFG",
-			max:  7,
-			n:    7,
-			snip: "ABCDEFG",
-		},
-		2: {
-			// extract 100 bytes from a content of length 9 (impossible scenario)
-			txt:  "ABCDEFG",
-			max:  9,
-			n:    100,
-			snip: "ABCDEFG",
-		},
-		3: {
-			// extract 5 bytes from a content of length 2 (impossible scenario)
-			txt:  "ABCDEFG",
-			max:  2,
-			n:    5,
-			snip: "AB",
+		{
+			in:  `{"query":{"match":{"user":"test"}}}`,
+			out: `{"query":{"match":{"user":"?"}}}`,
 		},
-		4: {
-			txt:  "ABCDEFG",
-			max:  0,
-			n:    1,
-			snip: "A",
+		{
+			in:  `{"query":{"terms":{"tags":["a","b"]}},"sort":["date"]}`,
+			out: `{"query":{"terms":{"tags":["?","?"]}},"sort":["date"]}`,
 		},
-		5: {
-			n:   4,
-			max: 4,
-			err: errors.New("empty stream"),
+		{
+			in:  `{"query":{"range":{"age":{"gte":21.5}}},"_source":"user"}`,
+			out: `{"_source":"user","query":{"range":{"age":{"gte":21.5}}}}`,
 		},
-		6: {
-			txt:  "ABCDEFG",
-			n:    4,
-			max:  -1,
-			snip: "ABCD",
+		{
+			in:  `{"aggs":{"users":{"filter":{"query":{"term":{"user":"test"}}}}}}`,
+			out: `{"aggs":{"users":{"filter":{"query":{"term":{"user":"?"}}}}}}`,
 		},
 	} {
-		var readcloser io.ReadCloser
-		if tt.txt != "" {
-			readcloser = ioutil.NopCloser(bytes.NewBufferString(tt.txt))
-		}
-		snip, rc, err := peek(readcloser, "", tt.max, tt.n)
-		assert.Equal(tt.err, err)
-		assert.Equal(tt.snip, snip)
-
-		if readcloser != nil {
-			// if a non-nil io.ReadCloser was sent, the returned io.ReadCloser
-			// must always return the entire original content.
-			all, err := ioutil.ReadAll(rc)
-			assert.Nil(err)
-			assert.Equal(tt.txt, string(all))
-		}
+		out, err := obfuscateBody(tc.in)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.out, out)
 	}
+
+	_, err := obfuscateBody(`{"query":{"match":`)
+	assert.Error(t, err)
 }
 
 func TestAnalyticsSettings(t *testing.T) {
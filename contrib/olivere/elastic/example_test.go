@@ -12,6 +12,8 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	elasticv3 "gopkg.in/olivere/elastic.v3"
 	elasticv5 "gopkg.in/olivere/elastic.v5"
+
+	elasticv7 "github.com/olivere/elastic/v7"
 )
 
 // To start tracing elastic.v5 requests, create a new TracedHTTPClient that you will
@@ -65,3 +67,25 @@ func Example_v3() {
 		DoC(ctx)
 	root.Finish()
 }
+
+// The same client can be used with elastic/v7. Here, the query strings of search
+// requests are also obfuscated in the elasticsearch.body tag of the span.
+func Example_v7() {
+	tc := elastictrace.NewHTTPClient(
+		elastictrace.WithServiceName("my-es-service"),
+		elastictrace.WithBodyObfuscation(true),
+	)
+	client, _ := elasticv7.NewClient(
+		elasticv7.SetURL("http://127.0.0.1:9200"),
+		elasticv7.SetHttpClient(tc),
+	)
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "parent.request",
+		tracer.ServiceName("web"),
+		tracer.ResourceName("/tweets/search"),
+	)
+	client.Search("twitter").
+		Query(elasticv7.NewMatchQuery("user", "test")).
+		Do(ctx)
+	root.Finish()
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package elastic
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// obfuscateBody returns the JSON search body with all string values found under a
+// "query" key replaced by "?". It returns an error if body is not valid JSON.
+func obfuscateBody(body string) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(body)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(obfuscateValue(v, false))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// obfuscateValue redacts the string values within v, if inQuery is set, or within
+// the values of any "query" keys nested inside v.
+func obfuscateValue(v interface{}, inQuery bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, vv := range v {
+			v[k] = obfuscateValue(vv, inQuery || k == "query")
+		}
+		return v
+	case []interface{}:
+		for i, vv := range v {
+			v[i] = obfuscateValue(vv, inQuery)
+		}
+		return v
+	case string:
+		if inQuery {
+			return "?"
+		}
+	}
+	return v
+}
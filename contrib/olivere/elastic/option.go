@@ -15,6 +15,7 @@ type clientConfig struct {
 	transport     *http.Transport
 	analyticsRate float64
 	resourceNamer func(url, method string) string
+	obfuscateBody bool
 }
 
 // ClientOption represents an option that can be used when creating a client.
@@ -74,3 +75,14 @@ func WithResourceNamer(namer func(url, method string) string) ClientOption {
 		cfg.resourceNamer = namer
 	}
 }
+
+// WithBodyObfuscation controls whether the bodies of requests sent to _search endpoints
+// are obfuscated before being tagged. When enabled, all string values found under "query"
+// keys are replaced with "?" while the structure of the query is preserved. Bodies which
+// can not be parsed, such as ones truncated because of their size, are tagged as "?".
+// It is disabled by default.
+func WithBodyObfuscation(on bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.obfuscateBody = on
+	}
+}
@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics_test
+
+import (
+	"net/http"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/prometheus/metrics"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func Example() {
+	// Start the tracer collecting the stats of finished spans.
+	tracer.Start(tracer.WithSpanStats(true))
+	defer tracer.Stop()
+
+	// Register the collector with the default Prometheus registry and
+	// expose its metrics.
+	metrics.NewREDCollector(metrics.TraceStatsFunc(tracer.Stats))
+	http.Handle("/metrics", promhttp.Handler())
+	http.ListenAndServe(":2112", nil)
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package metrics provides a Prometheus collector exposing Rate, Error and Duration
+// (RED) metrics derived from the spans finished by the tracer
+// (https://github.com/prometheus/client_golang).
+package metrics // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/prometheus/metrics"
+
+import (
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TraceStatsProvider provides the stats of the spans finished since it was last called.
+type TraceStatsProvider interface {
+	Stats() []tracer.SpanStats
+}
+
+// TraceStatsFunc is an adapter allowing the use of functions, such as tracer.Stats,
+// as a TraceStatsProvider.
+type TraceStatsFunc func() []tracer.SpanStats
+
+// Stats implements TraceStatsProvider.
+func (fn TraceStatsFunc) Stats() []tracer.SpanStats { return fn() }
+
+// labels holds the names of the labels of all the exported metrics.
+var labels = []string{"service", "resource", "span_type"}
+
+// redCollector is a prometheus.Collector which updates its metrics from the span
+// stats it drains every time it is collected.
+type redCollector struct {
+	provider TraceStatsProvider
+
+	mu       sync.Mutex // guards the metrics below while they are updated
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewREDCollector returns a prometheus.Collector exposing the dd_span_requests_total,
+// dd_span_errors_total and dd_span_duration_seconds metrics, labeled by service,
+// resource and span_type, computed from the span stats obtained from provider.
+// To use the stats of the global tracer, pass TraceStatsFunc(tracer.Stats) and
+// start the tracer using tracer.WithSpanStats. The collector is registered with
+// prometheus.DefaultRegisterer, unless specified otherwise using WithRegisterer.
+func NewREDCollector(provider TraceStatsProvider, opts ...Option) prometheus.Collector {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	c := &redCollector{
+		provider: provider,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "dd_span_requests_total",
+			Help:      "Number of finished spans.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Name:      "dd_span_errors_total",
+			Help:      "Number of finished spans marked as errors.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace,
+			Name:      "dd_span_duration_seconds",
+			Help:      "Duration of finished spans, in seconds.",
+			Buckets:   cfg.buckets,
+		}, labels),
+	}
+	if cfg.registerer != nil {
+		cfg.registerer.MustRegister(c)
+	}
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (c *redCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *redCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.provider.Stats() {
+		lvs := []string{s.Service, s.Resource, s.Type}
+		c.requests.WithLabelValues(lvs...).Inc()
+		if s.Error {
+			c.errors.WithLabelValues(lvs...).Inc()
+		}
+		c.duration.WithLabelValues(lvs...).Observe(s.Duration.Seconds())
+	}
+	c.requests.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+}
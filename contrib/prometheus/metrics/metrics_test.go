@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// testProvider is a TraceStatsProvider returning the stats queued in it.
+type testProvider struct {
+	stats []tracer.SpanStats
+}
+
+func (p *testProvider) Stats() []tracer.SpanStats {
+	stats := p.stats
+	p.stats = nil
+	return stats
+}
+
+func gather(t *testing.T, reg *prometheus.Registry) map[string]*dto.MetricFamily {
+	mfs, err := reg.Gather()
+	assert.NoError(t, err)
+	families := make(map[string]*dto.MetricFamily)
+	for _, mf := range mfs {
+		families[mf.GetName()] = mf
+	}
+	return families
+}
+
+func labelValues(m *dto.Metric) map[string]string {
+	lvs := make(map[string]string)
+	for _, lp := range m.GetLabel() {
+		lvs[lp.GetName()] = lp.GetValue()
+	}
+	return lvs
+}
+
+func TestREDCollector(t *testing.T) {
+	assert := assert.New(t)
+	reg := prometheus.NewRegistry()
+	p := &testProvider{}
+	NewREDCollector(p, WithRegisterer(reg))
+
+	p.stats = []tracer.SpanStats{
+		{Service: "web", Resource: "GET /", Type: ext.SpanTypeWeb, Duration: 100 * time.Millisecond},
+		{Service: "web", Resource: "GET /", Type: ext.SpanTypeWeb, Duration: 300 * time.Millisecond, Error: true},
+		{Service: "db", Resource: "SELECT", Type: ext.SpanTypeSQL, Duration: time.Millisecond},
+	}
+	families := gather(t, reg)
+	assert.Len(families, 3)
+
+	requests := families["dd_span_requests_total"].GetMetric()
+	assert.Len(requests, 2)
+	for _, m := range requests {
+		switch labelValues(m)["service"] {
+		case "web":
+			assert.Equal(map[string]string{"service": "web", "resource": "GET /", "span_type": "web"}, labelValues(m))
+			assert.Equal(2.0, m.GetCounter().GetValue())
+		case "db":
+			assert.Equal(1.0, m.GetCounter().GetValue())
+		default:
+			t.Fatalf("unexpected metric: %v", m)
+		}
+	}
+
+	errors := families["dd_span_errors_total"].GetMetric()
+	assert.Len(errors, 1)
+	assert.Equal("web", labelValues(errors[0])["service"])
+	assert.Equal(1.0, errors[0].GetCounter().GetValue())
+
+	for _, m := range families["dd_span_duration_seconds"].GetMetric() {
+		if labelValues(m)["service"] == "web" {
+			assert.Equal(uint64(2), m.GetHistogram().GetSampleCount())
+			assert.InDelta(0.4, m.GetHistogram().GetSampleSum(), 1e-9)
+		}
+	}
+
+	// counters accumulate across collections
+	p.stats = []tracer.SpanStats{{Service: "db", Resource: "SELECT", Type: ext.SpanTypeSQL}}
+	for _, m := range gather(t, reg)["dd_span_requests_total"].GetMetric() {
+		if labelValues(m)["service"] == "db" {
+			assert.Equal(2.0, m.GetCounter().GetValue())
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	assert := assert.New(t)
+	reg := prometheus.NewRegistry()
+	p := &testProvider{stats: []tracer.SpanStats{{Service: "web", Duration: time.Second}}}
+	c := NewREDCollector(p, WithRegisterer(nil), WithNamespace("app"), WithDurationBuckets(0.5, 2))
+	assert.NoError(reg.Register(c))
+
+	families := gather(t, reg)
+	assert.Contains(families, "app_dd_span_requests_total")
+	h := families["app_dd_span_duration_seconds"].GetMetric()[0].GetHistogram()
+	assert.Len(h.GetBucket(), 2)
+	assert.Equal(uint64(0), h.GetBucket()[0].GetCumulativeCount())
+	assert.Equal(uint64(1), h.GetBucket()[1].GetCumulativeCount())
+}
+
+func TestTraceStatsFunc(t *testing.T) {
+	var called bool
+	var p TraceStatsProvider = TraceStatsFunc(func() []tracer.SpanStats {
+		called = true
+		return nil
+	})
+	p.Stats()
+	assert.True(t, called)
+}
+
+// the stats of the global tracer can be used as a TraceStatsProvider.
+var _ TraceStatsProvider = TraceStatsFunc(tracer.Stats)
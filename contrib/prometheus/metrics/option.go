@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type config struct {
+	registerer prometheus.Registerer
+	namespace  string
+	buckets    []float64
+}
+
+// Option represents an option that can be passed to NewREDCollector.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.registerer = prometheus.DefaultRegisterer
+	cfg.buckets = prometheus.DefBuckets
+}
+
+// WithRegisterer sets the registerer with which the collector is registered. When
+// nil, the collector is not registered and should be registered by the caller.
+func WithRegisterer(r prometheus.Registerer) Option {
+	return func(cfg *config) {
+		cfg.registerer = r
+	}
+}
+
+// WithNamespace sets the namespace prefixing the names of the exported metrics.
+func WithNamespace(namespace string) Option {
+	return func(cfg *config) {
+		cfg.namespace = namespace
+	}
+}
+
+// WithDurationBuckets sets the upper bounds, in seconds, of the buckets of the
+// span duration histogram. It defaults to prometheus.DefBuckets.
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(cfg *config) {
+		cfg.buckets = buckets
+	}
+}
@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package redis_test
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+
+	redistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/redis/go-redis.v9"
+)
+
+// To start tracing Redis, wrap a client and continue using it as you normally would.
+func Example() {
+	c := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	redistrace.WrapClient(c)
+
+	// any action emits a span
+	c.Set(context.Background(), "test_key", "test_value", 0)
+}
+
+// Cluster clients can record a child span for each node a command is dispatched to.
+func Example_cluster() {
+	c := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{":7000", ":7001", ":7002"}})
+	redistrace.WrapClient(c, redistrace.WithClusterShardTags(true))
+
+	// keys spanning several slots produce one redis.shard span per node
+	c.MGet(context.Background(), "key1", "key2", "key3")
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package redis
+
+import (
+	"math"
+)
+
+type clientConfig struct {
+	serviceName      string
+	analyticsRate    float64
+	clusterShardTags bool
+	// pipelineCommandSpans specifies whether each command of a pipeline gets a span.
+	pipelineCommandSpans bool
+}
+
+// ClientOption represents an option that can be used to wrap a client.
+type ClientOption func(*clientConfig)
+
+func defaults(cfg *clientConfig) {
+	cfg.serviceName = "redis.client"
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.analyticsRate = math.NaN()
+}
+
+// WithServiceName sets the given service name for the client.
+func WithServiceName(name string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.serviceName = name
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) ClientOption {
+	return func(cfg *clientConfig) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) ClientOption {
+	return func(cfg *clientConfig) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithClusterShardTags specifies whether the commands and pipelines which a cluster
+// client dispatches to its nodes should be traced. When enabled, each logical command
+// produces a redis.command span with a redis.shard child span for every node it was
+// sent to, tagged with redis.shard_slot, redis.shard_host and redis.key_count. It has
+// no effect on clients which aren't a *redis.ClusterClient.
+func WithClusterShardTags(enabled bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.clusterShardTags = enabled
+	}
+}
+
+// WithPipelineCommandSpans specifies whether a child span should be started for each
+// command of an executed pipeline, in addition to the span of the pipeline. The child
+// spans are tagged with redis.command, redis.args_length and their position in the
+// pipeline, redis.pipeline_index. They finish with the error of their own command.
+func WithPipelineCommandSpans(enabled bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.pipelineCommandSpans = enabled
+	}
+}
@@ -0,0 +1,253 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package redis provides tracing functions for tracing the redis/go-redis package v9 (https://github.com/redis/go-redis).
+package redis // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/redis/go-redis.v9"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WrapClient adds a tracing hook to the given client. Cluster clients must be wrapped
+// before they are used for cluster shard tags to be recorded, see WithClusterShardTags.
+func WrapClient(client redis.UniversalClient, opts ...ClientOption) {
+	cfg := new(clientConfig)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	h := &hook{config: cfg}
+	switch c := client.(type) {
+	case *redis.Client:
+		opt := c.Options()
+		host, port, err := net.SplitHostPort(opt.Addr)
+		if err != nil {
+			host = opt.Addr
+			port = "6379"
+		}
+		h.tags = []ddtrace.StartSpanOption{
+			tracer.Tag(ext.TargetHost, host),
+			tracer.Tag(ext.TargetPort, port),
+			tracer.Tag("out.db", strconv.Itoa(opt.DB)),
+		}
+	case *redis.ClusterClient:
+		if cfg.clusterShardTags {
+			c.OnNewNode(func(node *redis.Client) {
+				node.AddHook(&shardHook{config: cfg, addr: node.Options().Addr})
+			})
+		}
+	}
+	client.AddHook(h)
+}
+
+// hook traces the commands and pipelines processed by a client.
+type hook struct {
+	config *clientConfig
+	// tags holds the connection tags of non-cluster clients.
+	tags []ddtrace.StartSpanOption
+}
+
+var _ redis.Hook = (*hook)(nil)
+
+func (h *hook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		raw := cmdString(cmd)
+		opts := append(h.startOptions(cmd.Name()),
+			tracer.Tag("redis.raw_command", raw),
+			tracer.Tag("redis.args_length", strconv.Itoa(len(cmd.Args())-1)),
+		)
+		span, ctx := tracer.StartSpanFromContext(ctx, "redis.command", opts...)
+		err := next(ctx, cmd)
+		finish(span, err)
+		return err
+	}
+}
+
+func (h *hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		opts := append(h.startOptions("redis"),
+			tracer.Tag("redis.raw_command", cmdsString(cmds)),
+			tracer.Tag("redis.pipeline_length", strconv.Itoa(len(cmds))),
+		)
+		span, ctx := tracer.StartSpanFromContext(ctx, "redis.command", opts...)
+		var children []ddtrace.Span
+		if h.config.pipelineCommandSpans {
+			// commands are only known once the pipeline is executed, so their
+			// spans are started here rather than when they are queued
+			children = make([]ddtrace.Span, len(cmds))
+			for i, cmd := range cmds {
+				opts := append(h.startOptions(cmd.Name()),
+					tracer.Tag("redis.command", cmd.Name()),
+					tracer.Tag("redis.raw_command", cmdString(cmd)),
+					tracer.Tag("redis.args_length", strconv.Itoa(len(cmd.Args())-1)),
+					tracer.Tag("redis.pipeline_index", i),
+				)
+				children[i], _ = tracer.StartSpanFromContext(ctx, "redis.command", opts...)
+			}
+		}
+		err := next(ctx, cmds)
+		for i, child := range children {
+			finish(child, cmds[i].Err())
+		}
+		finish(span, err)
+		return err
+	}
+}
+
+func (h *hook) startOptions(resource string) []ddtrace.StartSpanOption {
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeRedis),
+		tracer.ServiceName(h.config.serviceName),
+		tracer.ResourceName(resource),
+	}
+	opts = append(opts, h.tags...)
+	if !math.IsNaN(h.config.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, h.config.analyticsRate))
+	}
+	return opts
+}
+
+// shardHook traces the commands and pipelines which a cluster client sends to one
+// of its nodes. The resulting spans are children of the ones started by hook.
+type shardHook struct {
+	config *clientConfig
+	// addr holds the address of the node.
+	addr string
+}
+
+var _ redis.Hook = (*shardHook)(nil)
+
+func (h *shardHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *shardHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		span, ctx := h.startSpan(ctx, cmd.Name(), []redis.Cmder{cmd})
+		err := next(ctx, cmd)
+		finish(span, err)
+		return err
+	}
+}
+
+func (h *shardHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		span, ctx := h.startSpan(ctx, "redis", cmds)
+		err := next(ctx, cmds)
+		finish(span, err)
+		return err
+	}
+}
+
+func (h *shardHook) startSpan(ctx context.Context, resource string, cmds []redis.Cmder) (ddtrace.Span, context.Context) {
+	host, port, err := net.SplitHostPort(h.addr)
+	if err != nil {
+		host = h.addr
+		port = "6379"
+	}
+	var keys int
+	for _, cmd := range cmds {
+		keys += keyCount(cmd)
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeRedis),
+		tracer.ServiceName(h.config.serviceName),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.TargetHost, host),
+		tracer.Tag(ext.TargetPort, port),
+		tracer.Tag("redis.shard_host", h.addr),
+		tracer.Tag("redis.key_count", keys),
+	}
+	if len(cmds) > 0 && len(cmds[0].Args()) > 1 {
+		opts = append(opts, tracer.Tag("redis.shard_slot", keySlot(fmt.Sprint(cmds[0].Args()[1]))))
+	}
+	return tracer.StartSpanFromContext(ctx, "redis.shard", opts...)
+}
+
+func finish(span ddtrace.Span, err error) {
+	var finishOpts []ddtrace.FinishOption
+	if err != redis.Nil {
+		finishOpts = append(finishOpts, tracer.WithError(err))
+	}
+	span.Finish(finishOpts...)
+}
+
+// keyCount returns the number of keys in cmd. Commands taking key-value pairs and
+// commands taking a list of keys are recognized; other commands are assumed to take
+// at most one key, as their first argument.
+func keyCount(cmd redis.Cmder) int {
+	n := len(cmd.Args()) - 1
+	if n <= 0 {
+		return 0
+	}
+	switch strings.ToLower(cmd.Name()) {
+	case "mset", "msetnx":
+		return n / 2
+	case "mget", "del", "unlink", "exists", "touch", "watch":
+		return n
+	default:
+		return 1
+	}
+}
+
+// keySlot returns the cluster hash slot of the given key, honouring hash tags.
+// See https://redis.io/docs/reference/cluster-spec/#key-distribution-model.
+func keySlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s > -1 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+e+1]
+		}
+	}
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return int(crc) % 16384
+}
+
+// cmdString returns the command and its arguments separated by spaces.
+func cmdString(cmd redis.Cmder) string {
+	var b strings.Builder
+	for i, arg := range cmd.Args() {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprint(&b, arg)
+	}
+	return b.String()
+}
+
+// cmdsString returns a string representation of a slice of redis Commands, separated by newlines.
+func cmdsString(cmds []redis.Cmder) string {
+	var b bytes.Buffer
+	for _, cmd := range cmds {
+		b.WriteString(cmdString(cmd))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
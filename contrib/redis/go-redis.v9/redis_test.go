@@ -0,0 +1,224 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapClient(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// nothing listens on this port, so the command fails without a server
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DB: 2, MaxRetries: -1})
+	defer client.Close()
+	WrapClient(client, WithServiceName("my-redis"))
+	err := client.Set(context.Background(), "test_key", "test_value", 0).Err()
+	assert.Error(err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("redis.command", span.OperationName())
+	assert.Equal(ext.SpanTypeRedis, span.Tag(ext.SpanType))
+	assert.Equal("my-redis", span.Tag(ext.ServiceName))
+	assert.Equal("set", span.Tag(ext.ResourceName))
+	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
+	assert.Equal("1", span.Tag(ext.TargetPort))
+	assert.Equal("2", span.Tag("out.db"))
+	assert.Equal("set test_key test_value", span.Tag("redis.raw_command"))
+	assert.Equal("2", span.Tag("redis.args_length"))
+	assert.NotNil(span.Tag(ext.Error))
+}
+
+func TestNilIsNotAnError(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := &hook{config: &clientConfig{serviceName: "redis.client"}}
+	process := h.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error { return redis.Nil })
+	process(context.Background(), redis.NewStringCmd(context.Background(), "get", "missing"))
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Nil(t, spans[0].Tag(ext.Error))
+}
+
+func TestClusterShardTags(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := new(clientConfig)
+	defaults(cfg)
+	WithClusterShardTags(true)(cfg)
+	cluster := &hook{config: cfg}
+	shards := []*shardHook{
+		{config: cfg, addr: "10.0.0.1:7000"},
+		{config: cfg, addr: "10.0.0.2:7001"},
+	}
+
+	// simulate the fan-out of a multi-slot MGET to two nodes
+	ctx := context.Background()
+	process := cluster.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		noop := func(context.Context, redis.Cmder) error { return nil }
+		shards[0].ProcessHook(noop)(ctx, redis.NewStringSliceCmd(ctx, "mget", "foo", "{foo}.bar"))
+		shards[1].ProcessHook(noop)(ctx, redis.NewStringSliceCmd(ctx, "mget", "bar"))
+		return nil
+	})
+	process(ctx, redis.NewStringSliceCmd(ctx, "mget", "foo", "bar", "{foo}.bar"))
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	parent := spans[2]
+	assert.Equal("redis.command", parent.OperationName())
+	assert.Equal("mget", parent.Tag(ext.ResourceName))
+
+	for i, want := range []struct {
+		host  string
+		slot  int
+		count int
+	}{
+		{"10.0.0.1:7000", 12182, 2},
+		{"10.0.0.2:7001", 5061, 1},
+	} {
+		span := spans[i]
+		assert.Equal("redis.shard", span.OperationName())
+		assert.Equal(parent.SpanID(), span.ParentID())
+		assert.Equal("mget", span.Tag(ext.ResourceName))
+		assert.Equal(want.host, span.Tag("redis.shard_host"))
+		assert.Equal(want.slot, span.Tag("redis.shard_slot"))
+		assert.Equal(want.count, span.Tag("redis.key_count"))
+	}
+}
+
+func TestShardPipeline(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := new(clientConfig)
+	defaults(cfg)
+	h := &shardHook{config: cfg, addr: "10.0.0.1:7000"}
+	ctx := context.Background()
+	process := h.ProcessPipelineHook(func(context.Context, []redis.Cmder) error { return nil })
+	process(ctx, []redis.Cmder{
+		redis.NewStatusCmd(ctx, "mset", "foo", "1", "{foo}.bar", "2"),
+		redis.NewStringCmd(ctx, "get", "foo"),
+	})
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "redis", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, 3, spans[0].Tag("redis.key_count"))
+	assert.Equal(t, 12182, spans[0].Tag("redis.shard_slot"))
+	assert.Equal(t, "10.0.0.1", spans[0].Tag(ext.TargetHost))
+	assert.Equal(t, "7000", spans[0].Tag(ext.TargetPort))
+}
+
+func TestPipelineCommandSpans(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := new(clientConfig)
+	defaults(cfg)
+	WithPipelineCommandSpans(true)(cfg)
+	h := &hook{config: cfg}
+	ctx := context.Background()
+	fail := errors.New("WRONGTYPE")
+	process := h.ProcessPipelineHook(func(_ context.Context, cmds []redis.Cmder) error {
+		cmds[1].SetErr(fail)
+		cmds[2].SetErr(redis.Nil)
+		return fail
+	})
+	process(ctx, []redis.Cmder{
+		redis.NewStatusCmd(ctx, "set", "foo", "1"),
+		redis.NewIntCmd(ctx, "incr", "bar"),
+		redis.NewStringCmd(ctx, "get", "missing"),
+	})
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 4)
+	pipeline := spans[3]
+	assert.Equal("redis", pipeline.Tag(ext.ResourceName))
+	assert.Equal("3", pipeline.Tag("redis.pipeline_length"))
+	assert.Equal(fail, pipeline.Tag(ext.Error))
+	for i, name := range []string{"set", "incr", "get"} {
+		s := spans[i]
+		assert.Equal("redis.command", s.OperationName())
+		assert.Equal(name, s.Tag(ext.ResourceName))
+		assert.Equal(name, s.Tag("redis.command"))
+		assert.Equal(i, s.Tag("redis.pipeline_index"))
+		assert.Equal(pipeline.SpanID(), s.ParentID())
+	}
+	assert.Equal("2", spans[0].Tag("redis.args_length"))
+	assert.Equal("set foo 1", spans[0].Tag("redis.raw_command"))
+	assert.Nil(spans[0].Tag(ext.Error))
+	assert.Equal(fail, spans[1].Tag(ext.Error))
+	assert.Nil(spans[2].Tag(ext.Error))
+}
+
+func TestPipelineCommandSpansDisabled(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := new(clientConfig)
+	defaults(cfg)
+	h := &hook{config: cfg}
+	ctx := context.Background()
+	process := h.ProcessPipelineHook(func(context.Context, []redis.Cmder) error { return nil })
+	process(ctx, []redis.Cmder{redis.NewStringCmd(ctx, "get", "foo")})
+
+	assert.Len(t, mt.FinishedSpans(), 1)
+}
+
+func TestKeySlot(t *testing.T) {
+	assert.Equal(t, 12182, keySlot("foo"))
+	assert.Equal(t, 5061, keySlot("bar"))
+	assert.Equal(t, keySlot("user1000"), keySlot("{user1000}.following"))
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, rate interface{}, opts ...ClientOption) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		cfg := new(clientConfig)
+		defaults(cfg)
+		for _, fn := range opts {
+			fn(cfg)
+		}
+		h := &hook{config: cfg}
+		process := h.ProcessHook(func(context.Context, redis.Cmder) error { return nil })
+		process(context.Background(), redis.NewStringCmd(context.Background(), "get", "foo"))
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		assertRate(t, nil)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		assertRate(t, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		assertRate(t, 0.23, WithAnalyticsRate(0.23))
+	})
+}
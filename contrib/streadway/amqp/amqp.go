@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package amqp provides functions to trace the streadway/amqp package (https://github.com/streadway/amqp).
+package amqp // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/streadway/amqp"
+
+import (
+	"context"
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	tagExchange   = "amqp.exchange"
+	tagRoutingKey = "amqp.routing_key"
+	tagQueue      = "amqp.queue"
+)
+
+// A TracedChannel wraps an amqp.Channel, tracing published messages and propagating
+// trace contexts to consumers through message headers. All other methods are proxied
+// to the wrapped channel.
+type TracedChannel struct {
+	*amqp.Channel
+	cfg *config
+}
+
+// WrapChannel wraps an amqp.Channel so that published and consumed messages are traced.
+func WrapChannel(ch *amqp.Channel, opts ...Option) *TracedChannel {
+	cfg := new(config)
+	defaults(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &TracedChannel{Channel: ch, cfg: cfg}
+}
+
+// Publish calls the underlying Channel.Publish and traces the request, injecting the
+// span context into the headers of the message.
+func (ch *TracedChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	return ch.PublishWithContext(context.Background(), exchange, key, mandatory, immediate, msg)
+}
+
+// PublishWithContext is like Publish, using ctx as a basis for the started span.
+func (ch *TracedChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(ch.cfg.serviceName),
+		tracer.ResourceName("Publish Exchange " + exchange),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(tagExchange, exchange),
+		tracer.Tag(tagRoutingKey, key),
+	}
+	if !math.IsNaN(ch.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, ch.cfg.analyticsRate))
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, "amqp.publish", opts...)
+	msg.Headers = injectHeaders(span.Context(), msg.Headers)
+	err := ch.Channel.Publish(exchange, key, mandatory, immediate, msg)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// injectHeaders returns a copy of headers holding the given span context, leaving the
+// caller's table untouched.
+func injectHeaders(spanctx ddtrace.SpanContext, headers amqp.Table) amqp.Table {
+	h := make(amqp.Table, len(headers)+3)
+	for k, v := range headers {
+		h[k] = v
+	}
+	tracer.Inject(spanctx, HeadersCarrier(h))
+	return h
+}
+
+// Consume calls the underlying Channel.Consume. The returned channel receives each
+// delivery along with the span context found in its headers.
+func (ch *TracedChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan TracedDelivery, error) {
+	in, err := ch.Channel.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan TracedDelivery)
+	go func() {
+		defer close(out)
+		for d := range in {
+			out <- newTracedDelivery(d, queue, ch.cfg)
+		}
+	}()
+	return out, nil
+}
+
+// A TracedDelivery wraps an amqp.Delivery received by a TracedChannel.
+type TracedDelivery struct {
+	amqp.Delivery
+
+	queue   string
+	cfg     *config
+	spanctx ddtrace.SpanContext
+}
+
+func newTracedDelivery(d amqp.Delivery, queue string, cfg *config) TracedDelivery {
+	td := TracedDelivery{Delivery: d, queue: queue, cfg: cfg}
+	if spanctx, err := tracer.Extract(HeadersCarrier(d.Headers)); err == nil {
+		td.spanctx = spanctx
+	}
+	return td
+}
+
+// SpanContext returns the span context which was propagated in the headers of the
+// delivery, or nil if there is none.
+func (d TracedDelivery) SpanContext() ddtrace.SpanContext {
+	return d.spanctx
+}
+
+// StartSpan starts a span for the processing of the delivery. When the delivery headers
+// hold a span context, the span is its child; otherwise ctx is used as a basis for the
+// span. The returned context holds the started span, which the caller must finish.
+func (d TracedDelivery) StartSpan(ctx context.Context) (ddtrace.Span, context.Context) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(d.cfg.serviceName),
+		tracer.ResourceName("Consume Queue " + d.queue),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(tagExchange, d.Exchange),
+		tracer.Tag(tagRoutingKey, d.RoutingKey),
+		tracer.Tag(tagQueue, d.queue),
+	}
+	if !math.IsNaN(d.cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, d.cfg.analyticsRate))
+	}
+	if d.spanctx == nil {
+		return tracer.StartSpanFromContext(ctx, "amqp.consume", opts...)
+	}
+	// the propagated context takes precedence over any span in ctx
+	span := tracer.StartSpan("amqp.consume", append(opts, tracer.ChildOf(d.spanctx))...)
+	return span, tracer.ContextWithSpan(ctx, span)
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package amqp
+
+import (
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/streadway/amqp"
+)
+
+// A HeadersCarrier injects and extracts traces from the headers of an amqp.Publishing
+// or amqp.Delivery.
+type HeadersCarrier amqp.Table
+
+var _ interface {
+	tracer.TextMapReader
+	tracer.TextMapWriter
+} = (*HeadersCarrier)(nil)
+
+// ForeachKey iterates over every header holding a string value.
+func (c HeadersCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range c {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if err := handler(k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set sets a header.
+func (c HeadersCarrier) Set(key, val string) {
+	c[key] = val
+}
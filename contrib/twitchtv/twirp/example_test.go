@@ -31,6 +31,19 @@ func ExampleWrapClient() {
 	}
 }
 
+func ExampleNewHTTPClient() {
+	tracer.Start()
+	defer tracer.Stop()
+
+	client := example.NewHaberdasherJSONClient("http://localhost:8080", twirptrace.NewHTTPClient())
+	hat, err := client.MakeHat(context.Background(), &example.Size{Inches: 6})
+	if err != nil {
+		fmt.Println("error making hat:", err)
+		return
+	}
+	fmt.Println("made hat:", hat)
+}
+
 type hatmaker struct{}
 
 func (hatmaker) MakeHat(ctx context.Context, size *example.Size) (*example.Hat, error) {
@@ -50,9 +50,41 @@ func WrapClient(c HTTPClient, opts ...Option) HTTPClient {
 }
 
 func (wc *wrappedClient) Do(req *http.Request) (*http.Response, error) {
+	return traceClientRequest(wc.cfg, req, wc.c.Do)
+}
+
+// NewHTTPClient returns a new http.Client which adds distributed tracing to its requests.
+// It can be passed to the constructors of generated twirp clients.
+func NewHTTPClient(opts ...Option) *http.Client {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return &http.Client{Transport: &roundTripper{base: http.DefaultTransport, cfg: cfg}}
+}
+
+type roundTripper struct {
+	base http.RoundTripper
+	cfg  *config
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request, so the headers the span
+	// context is injected into are copied
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	return traceClientRequest(rt.cfg, r, rt.base.RoundTrip)
+}
+
+// traceClientRequest sends req using do, tracing it as a request of a twirp client.
+func traceClientRequest(cfg *config, req *http.Request, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
 	opts := []tracer.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeHTTP),
-		tracer.ServiceName(wc.cfg.clientServiceName()),
+		tracer.ServiceName(cfg.clientServiceName()),
 		tracer.Tag(ext.HTTPMethod, req.Method),
 		tracer.Tag(ext.HTTPURL, req.URL.Path),
 	}
@@ -66,8 +98,8 @@ func (wc *wrappedClient) Do(req *http.Request) (*http.Response, error) {
 	if method, ok := twirp.MethodName(ctx); ok {
 		opts = append(opts, tracer.Tag("twirp.method", method))
 	}
-	if !math.IsNaN(wc.cfg.analyticsRate) {
-		opts = append(opts, tracer.Tag(ext.EventSampleRate, wc.cfg.analyticsRate))
+	if !math.IsNaN(cfg.analyticsRate) {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
 	}
 	if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(req.Header)); err == nil {
 		opts = append(opts, tracer.ChildOf(spanctx))
@@ -82,7 +114,7 @@ func (wc *wrappedClient) Do(req *http.Request) (*http.Response, error) {
 	}
 
 	req = req.WithContext(ctx)
-	res, err := wc.c.Do(req)
+	res, err := do(req)
 	if err != nil {
 		span.SetTag(ext.Error, err)
 	} else {
@@ -176,10 +208,19 @@ func requestRoutedHook(cfg *config) func(context.Context) (context.Context, erro
 		if !ok {
 			return ctx, nil
 		}
-		if method, ok := twirp.MethodName(ctx); ok {
-			span.SetTag(ext.ResourceName, method)
-			span.SetTag("twirp.method", method)
+		method, ok := twirp.MethodName(ctx)
+		if !ok {
+			return ctx, nil
 		}
+		span.SetTag("twirp.method", method)
+		resource := method
+		pkg, okPkg := twirp.PackageName(ctx)
+		svc, okSvc := twirp.ServiceName(ctx)
+		if okPkg && okSvc {
+			// e.g. "twitch.twirp.example.Haberdasher/MakeHat"
+			resource = pkg + "." + svc + "/" + method
+		}
+		span.SetTag(ext.ResourceName, resource)
 		return ctx, nil
 	}
 }
@@ -199,7 +240,15 @@ func responseSentHook(cfg *config) func(context.Context) {
 		if sc, ok := twirp.StatusCode(ctx); ok {
 			span.SetTag(ext.HTTPCode, sc)
 		}
-		err, _ := ctx.Value(twirpErrorKey).(twirp.Error)
+		var err error
+		if twerr, ok := ctx.Value(twirpErrorKey).(twirp.Error); ok {
+			span.SetTag("twirp.error_code", string(twerr.Code()))
+			// only errors resulting in a 5XX server status are errors of the server;
+			// others, such as invalid_argument or not_found, are errors of the client
+			if twirp.ServerHTTPStatusFromErrorCode(twerr.Code()) >= 500 {
+				err = twerr
+			}
+		}
 		span.Finish(tracer.WithError(err))
 	}
 }
@@ -10,10 +10,12 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
 
 	"github.com/stretchr/testify/assert"
@@ -177,7 +179,9 @@ func TestServerHooks(t *testing.T) {
 		assert.Equal("twirp.test", span.Tag("twirp.package"))
 		assert.Equal("Example", span.Tag("twirp.service"))
 		assert.Equal("Method", span.Tag("twirp.method"))
+		assert.Equal("twirp.test.Example/Method", span.Tag(ext.ResourceName))
 		assert.Equal("200", span.Tag(ext.HTTPCode))
+		assert.Nil(span.Tag("twirp.error_code"))
 	})
 
 	t.Run("error", func(t *testing.T) {
@@ -196,8 +200,23 @@ func TestServerHooks(t *testing.T) {
 		assert.Equal("Example", span.Tag("twirp.service"))
 		assert.Equal("Method", span.Tag("twirp.method"))
 		assert.Equal("500", span.Tag(ext.HTTPCode))
+		assert.Equal("internal", span.Tag("twirp.error_code"))
 		assert.Equal("twirp error internal: something bad or unexpected happened", span.Tag(ext.Error).(error).Error())
 	})
+
+	t.Run("client error", func(t *testing.T) {
+		defer mt.Reset()
+		assert := assert.New(t)
+
+		mockServer(hooks, assert, twirp.NotFoundError("no such hat"))
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		span := spans[0]
+		assert.Equal("404", span.Tag(ext.HTTPCode))
+		assert.Equal("not_found", span.Tag("twirp.error_code"))
+		assert.Nil(span.Tag(ext.Error))
+	})
 }
 
 func TestAnalyticsSettings(t *testing.T) {
@@ -323,3 +342,34 @@ func TestHaberdash(t *testing.T) {
 	assert.Equal(ext.SpanTypeWeb, spans[1].Tag(ext.SpanType))
 	assert.Equal(ext.SpanTypeHTTP, spans[2].Tag(ext.SpanType))
 }
+
+func TestNewHTTPClient(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	assert := assert.New(t)
+
+	var parentID uint64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header))
+		assert.NoError(err)
+		parentID = spanctx.SpanID()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"size":6,"color":"purple"}`))
+	}))
+	defer srv.Close()
+
+	client := example.NewHaberdasherJSONClient(srv.URL, NewHTTPClient(WithServiceName("hat-client")))
+	hat, err := client.MakeHat(context.Background(), &example.Size{Inches: 6})
+	assert.NoError(err)
+	assert.Equal("purple", hat.Color)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("twirp.request", span.OperationName())
+	assert.Equal(ext.SpanTypeHTTP, span.Tag(ext.SpanType))
+	assert.Equal("hat-client", span.Tag(ext.ServiceName))
+	assert.Equal("MakeHat", span.Tag("twirp.method"))
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal(span.SpanID(), parentID)
+}
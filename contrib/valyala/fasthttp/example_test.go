@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package fasthttp_test
+
+import (
+	"github.com/valyala/fasthttp"
+
+	fasthttptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/valyala/fasthttp"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func Example() {
+	// Start the tracer
+	tracer.Start()
+	defer tracer.Stop()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		// Spans started as children of the request span are part of the same trace
+		if span, ok := fasthttptrace.SpanFromContext(ctx); ok {
+			child := tracer.StartSpan("encode", tracer.ChildOf(span.Context()))
+			defer child.Finish()
+		}
+		ctx.SetBodyString("Hello World!")
+	}
+
+	// Wrap the handler with the tracer using your desired service name.
+	fasthttp.ListenAndServe(":8080", fasthttptrace.TraceHandler(handler, fasthttptrace.WithServiceName("fasthttp-server")))
+}
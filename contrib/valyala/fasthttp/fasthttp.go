@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package fasthttp provides functions to trace the valyala/fasthttp package (https://github.com/valyala/fasthttp).
+package fasthttp // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/valyala/fasthttp"
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/valyala/fasthttp"
+)
+
+// spanKey is the key of the user value under which the span of a request is stored.
+type spanKey struct{}
+
+// TraceHandler returns a fasthttp.RequestHandler which traces the requests served by h.
+// The span of a request can be obtained by h using SpanFromContext.
+func TraceHandler(h fasthttp.RequestHandler, opts ...Option) fasthttp.RequestHandler {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return func(ctx *fasthttp.RequestCtx) {
+		opts := []ddtrace.StartSpanOption{
+			tracer.SpanType(ext.SpanTypeWeb),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.ResourceName(cfg.resourceNamer(ctx)),
+			tracer.Tag(ext.HTTPMethod, string(ctx.Method())),
+			tracer.Tag(ext.HTTPURL, string(ctx.Path())),
+		}
+		if !math.IsNaN(cfg.analyticsRate) {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		if spanctx, err := tracer.Extract(headerCarrier{&ctx.Request.Header}); err == nil {
+			opts = append(opts, tracer.ChildOf(spanctx))
+		}
+		opts = append(opts, cfg.spanOpts...)
+		span := tracer.StartSpan("http.request", opts...)
+		defer span.Finish()
+
+		// the RequestCtx is reused for other requests once h returns, so the
+		// span must not outlive the request on it
+		ctx.SetUserValue(spanKey{}, span)
+		defer ctx.RemoveUserValue(spanKey{})
+
+		h(ctx)
+
+		status := ctx.Response.StatusCode()
+		span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+		if status >= 500 && status < 600 {
+			// mark 5xx server error
+			span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+		}
+	}
+}
+
+// SpanFromContext returns the span of the request being served by ctx, if it
+// is traced by TraceHandler. It must only be called while serving the request.
+// To pass the span on to functions expecting a context.Context, use
+// tracer.ContextWithSpan.
+func SpanFromContext(ctx *fasthttp.RequestCtx) (ddtrace.Span, bool) {
+	span, ok := ctx.UserValue(spanKey{}).(ddtrace.Span)
+	return span, ok
+}
+
+// headerCarrier implements tracer.TextMapReader for the headers of fasthttp requests.
+type headerCarrier struct{ h *fasthttp.RequestHeader }
+
+// ForeachKey implements tracer.TextMapReader.
+func (c headerCarrier) ForeachKey(handler func(key, val string) error) error {
+	var err error
+	c.h.VisitAll(func(k, v []byte) {
+		if err == nil {
+			err = handler(string(k), string(v))
+		}
+	})
+	return err
+}
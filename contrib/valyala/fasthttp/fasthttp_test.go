@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package fasthttp
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func newRequestCtx(method, uri string) *fasthttp.RequestCtx {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	return &ctx
+}
+
+func TestTrace200(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	var called, traced bool
+
+	h := TraceHandler(func(ctx *fasthttp.RequestCtx) {
+		called = true
+		var span tracer.Span
+		span, traced = SpanFromContext(ctx)
+		span.SetTag("test.fasthttp", "fast")
+		ctx.SetBodyString("ok")
+	}, WithServiceName("foobar"))
+
+	root := tracer.StartSpan("root")
+	ctx := newRequestCtx("GET", "/user/123?page=2")
+	err := tracer.Inject(root.Context(), headerWriter{&ctx.Request.Header})
+	assert.Nil(err)
+	h(ctx)
+
+	assert.True(called)
+	assert.True(traced)
+	_, ok := SpanFromContext(ctx)
+	assert.False(ok)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("http.request", span.OperationName())
+	assert.Equal(ext.SpanTypeWeb, span.Tag(ext.SpanType))
+	assert.Equal("foobar", span.Tag(ext.ServiceName))
+	assert.Equal("GET /user/123", span.Tag(ext.ResourceName))
+	assert.Equal("GET", span.Tag(ext.HTTPMethod))
+	assert.Equal("/user/123", span.Tag(ext.HTTPURL))
+	assert.Equal("200", span.Tag(ext.HTTPCode))
+	assert.Equal("fast", span.Tag("test.fasthttp"))
+	assert.Equal(root.Context().SpanID(), span.ParentID())
+	assert.Nil(span.Tag(ext.Error))
+}
+
+func TestError(t *testing.T) {
+	for _, tt := range []struct {
+		status int
+		err    string
+	}{
+		{status: 500, err: "500: Internal Server Error"},
+		{status: 503, err: "503: Service Unavailable"},
+		{status: 404},
+	} {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			h := TraceHandler(func(ctx *fasthttp.RequestCtx) {
+				ctx.SetStatusCode(tt.status)
+			})
+			h(newRequestCtx("POST", "/err"))
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			span := spans[0]
+			assert.Equal("fasthttp", span.Tag(ext.ServiceName))
+			assert.Equal("POST /err", span.Tag(ext.ResourceName))
+			if tt.err == "" {
+				assert.Nil(span.Tag(ext.Error))
+			} else {
+				assert.Equal(tt.err, span.Tag(ext.Error).(error).Error())
+			}
+		})
+	}
+}
+
+func TestResourceNamer(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := TraceHandler(func(ctx *fasthttp.RequestCtx) {}, WithResourceNamer(func(ctx *fasthttp.RequestCtx) string {
+		return "users"
+	}))
+	h(newRequestCtx("GET", "/user/123"))
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("users", spans[0].Tag(ext.ResourceName))
+}
+
+// TestServer makes sure spans do not leak between the requests served by a server,
+// which reuses RequestCtx objects.
+func TestServer(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var seen []uint64
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+	srv := &fasthttp.Server{
+		Handler: func(ctx *fasthttp.RequestCtx) {
+			_, ok := SpanFromContext(ctx)
+			assert.False(ok)
+			TraceHandler(func(ctx *fasthttp.RequestCtx) {
+				span, ok := SpanFromContext(ctx)
+				assert.True(ok)
+				seen = append(seen, span.Context().SpanID())
+			})(ctx)
+		},
+	}
+	go srv.Serve(ln)
+
+	client := &fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) { return ln.Dial() },
+	}
+	for i := 0; i < 3; i++ {
+		code, _, err := client.Get(nil, "http://localhost/ping")
+		assert.NoError(err)
+		assert.Equal(200, code)
+	}
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	for i, span := range spans {
+		assert.Equal(seen[i], span.SpanID())
+	}
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
+		TraceHandler(func(ctx *fasthttp.RequestCtx) {}, opts...)(newRequestCtx("GET", "/"))
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, rate, spans[0].Tag(ext.EventSampleRate))
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, nil)
+	})
+
+	t.Run("global", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rate := globalconfig.AnalyticsRate()
+		defer globalconfig.SetAnalyticsRate(rate)
+		globalconfig.SetAnalyticsRate(0.4)
+
+		assertRate(t, mt, 0.4)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 1.0, WithAnalytics(true))
+	})
+
+	t.Run("override", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
+	})
+}
+
+// headerWriter implements tracer.TextMapWriter for the headers of fasthttp requests.
+type headerWriter struct{ h *fasthttp.RequestHeader }
+
+func (c headerWriter) Set(key, val string) { c.h.Set(key, val) }
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package fasthttp
+
+import (
+	"math"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"github.com/valyala/fasthttp"
+)
+
+type config struct {
+	serviceName   string
+	spanOpts      []ddtrace.StartSpanOption // additional span options to be applied
+	analyticsRate float64
+	resourceNamer func(ctx *fasthttp.RequestCtx) string
+}
+
+// Option represents an option that can be passed to TraceHandler.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = "fasthttp"
+	cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.resourceNamer = defaultResourceNamer
+}
+
+// WithServiceName sets the given service name for the handler.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithSpanOptions applies the given set of options to the spans started
+// by the handler.
+func WithSpanOptions(opts ...ddtrace.StartSpanOption) Option {
+	return func(cfg *config) {
+		cfg.spanOpts = opts
+	}
+}
+
+// WithResourceNamer specifies a function which will be used to obtain a resource name
+// for a given request. By default, the resource name is the request method followed
+// by its path.
+func WithResourceNamer(namer func(ctx *fasthttp.RequestCtx) string) Option {
+	return func(cfg *config) {
+		cfg.resourceNamer = namer
+	}
+}
+
+func defaultResourceNamer(ctx *fasthttp.RequestCtx) string {
+	return string(ctx.Method()) + " " + string(ctx.Path())
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	return func(cfg *config) {
+		if on {
+			cfg.analyticsRate = 1.0
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			cfg.analyticsRate = rate
+		} else {
+			cfg.analyticsRate = math.NaN()
+		}
+	}
+}
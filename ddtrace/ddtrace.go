@@ -42,6 +42,10 @@ type Span interface {
 	// SetTag sets a key/value pair as metadata on the span.
 	SetTag(key string, value interface{})
 
+	// SetMetric sets a numeric metric on the span. Unlike string tags, metrics
+	// can be used for alerting and as measures in search queries.
+	SetMetric(key string, value float64)
+
 	// SetOperationName sets the operation name for this span. An operation name should be
 	// a representative name for a group of spans (e.g. "grpc.server" or "http.request").
 	SetOperationName(operationName string)
@@ -53,6 +57,22 @@ type Span interface {
 	// item should propagate to all descendant spans, both in- and cross-process.
 	SetBaggageItem(key, val string)
 
+	// AddLink adds a link to another span, usually belonging to a different trace,
+	// without creating a parent-child relationship between the two.
+	AddLink(link SpanLink)
+
+	// RecordError marks the span as having had the given error, setting the error
+	// message, type and stack trace tags at once. It has no effect if err is nil.
+	RecordError(err error, opts ...ErrorOption)
+
+	// SetUser associates the span with the user identified by id, setting the
+	// standard "usr.*" tags.
+	SetUser(id string, opts ...UserOption)
+
+	// AddEvent adds a time-stamped event with the given name to the span, for
+	// example to record a retry or a checkpoint without ending the span.
+	AddEvent(name string, opts ...EventOption)
+
 	// Finish finishes the current span with the given options. Finish calls should be idempotent.
 	Finish(opts ...FinishOption)
 
@@ -104,6 +124,51 @@ type FinishConfig struct {
 	SkipStackFrames uint
 }
 
+// EventOption is a configuration option that can be used with a Span's AddEvent method.
+type EventOption func(cfg *EventConfig)
+
+// EventConfig holds the configuration of a span event. It is usually passed around by
+// reference to one or more EventOption functions which shape it into its final form.
+type EventConfig struct {
+	// Time holds the time at which the event occurred. Implementations should use
+	// the current time when Time.IsZero().
+	Time time.Time
+
+	// Attributes holds a set of key/value pairs describing the event.
+	Attributes map[string]interface{}
+}
+
+// ErrorOption is a configuration option that can be used with a Span's RecordError method.
+type ErrorOption func(cfg *ErrorConfig)
+
+// ErrorConfig holds the configuration for recording an error on a span. It is usually
+// passed around by reference to one or more ErrorOption functions which shape it into
+// its final form.
+type ErrorConfig struct {
+	// NoStack prevents the stack trace from being captured along with the error.
+	NoStack bool
+
+	// ErrorType overrides the error type tag. Implementations should use the
+	// type name of the error (e.g. "*errors.errorString") when it is empty.
+	ErrorType string
+}
+
+// UserOption is a configuration option that can be used with a Span's SetUser method.
+type UserOption func(cfg *UserConfig)
+
+// UserConfig holds the user information set on a span by SetUser. It is usually
+// passed around by reference to one or more UserOption functions which shape it into
+// its final form.
+type UserConfig struct {
+	// Email, Name, Role and Scope are set as the corresponding "usr.*" tags
+	// when not empty.
+	Email, Name, Role, Scope string
+
+	// Propagate specifies whether the user ID should be propagated to
+	// downstream services along with the span context.
+	Propagate bool
+}
+
 // StartSpanConfig holds the configuration for starting a new span. It is usually passed
 // around by reference to one or more StartSpanOption functions which shape it into its
 // final form.
@@ -123,6 +188,15 @@ type StartSpanConfig struct {
 	// Force-set the SpanID, rather than use a random number. If no Parent SpanContext is present,
 	// then this will also set the TraceID to the same value.
 	SpanID uint64
+
+	// ConditionalTags holds tags which should only be set on the span if its trace is
+	// sampled. Implementations should call the functions when the span finishes, once
+	// the sampling decision is known, and set their results as the values of the tags.
+	ConditionalTags map[string]func(Span) interface{}
+
+	// Baggage holds baggage items which should be set on the context of the new
+	// span, in addition to the ones inherited from its parent.
+	Baggage map[string]string
 }
 
 // Logger implementations are able to log given messages that the tracer might output.
@@ -28,6 +28,8 @@ func TestSpec(t *testing.T) {
 		SpanTypeElasticSearch, "elasticsearch",
 		SQLQuery, "sql.query",
 		HTTPURL, "http.url",
+		HTTPRoute, "http.route",
+		HTTPRetryAttempt, "http.retry_attempt",
 		Environment, "env",
 	}
 	if len(tests)%2 != 0 {
@@ -32,6 +32,15 @@ const (
 	// HTTPURL sets the HTTP URL for a span.
 	HTTPURL = "http.url"
 
+	// HTTPHost sets the host requested in an HTTP request for a span.
+	HTTPHost = "http.host"
+
+	// HTTPRoute sets the route pattern matched by the router for a span.
+	HTTPRoute = "http.route"
+
+	// HTTPRetryAttempt sets the attempt number of a retried HTTP request for a span.
+	HTTPRetryAttempt = "http.retry_attempt"
+
 	// TODO: In the next major version, prefix these constants (SpanType, etc)
 	// with "Key*" (KeySpanType, etc) to more easily differentiate between
 	// constants representing tag values and constants representing keys.
@@ -54,7 +63,7 @@ const (
 	Error = "error"
 
 	// ErrorMsg specifies the error message.
-	ErrorMsg = "error.msg"
+	ErrorMsg = "error.message"
 
 	// ErrorType specifies the error type.
 	ErrorType = "error.type"
@@ -84,3 +93,31 @@ const (
 	// belongs to should be dropped when set to true.
 	ManualDrop = "manual.drop"
 )
+
+const (
+	// OTELLibraryName specifies the name of the instrumentation library which
+	// created a span.
+	OTELLibraryName = "otel.library.name"
+
+	// OTELLibraryVersion specifies the version of the instrumentation library
+	// which created a span.
+	OTELLibraryVersion = "otel.library.version"
+)
+
+const (
+	// UserID specifies the identifier of the user associated with a span.
+	UserID = "usr.id"
+
+	// UserEmail specifies the email of the user associated with a span.
+	UserEmail = "usr.email"
+
+	// UserName specifies the name of the user associated with a span.
+	UserName = "usr.name"
+
+	// UserRole specifies the role of the user associated with a span.
+	UserRole = "usr.role"
+
+	// UserScope specifies the scopes or granted authorizations of the user
+	// associated with a span.
+	UserScope = "usr.scope"
+)
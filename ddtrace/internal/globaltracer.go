@@ -70,6 +70,9 @@ type NoopSpan struct{}
 // SetTag implements ddtrace.Span.
 func (NoopSpan) SetTag(key string, value interface{}) {}
 
+// SetMetric implements ddtrace.Span.
+func (NoopSpan) SetMetric(key string, value float64) {}
+
 // SetOperationName implements ddtrace.Span.
 func (NoopSpan) SetOperationName(operationName string) {}
 
@@ -79,6 +82,18 @@ func (NoopSpan) BaggageItem(key string) string { return "" }
 // SetBaggageItem implements ddtrace.Span.
 func (NoopSpan) SetBaggageItem(key, val string) {}
 
+// AddLink implements ddtrace.Span.
+func (NoopSpan) AddLink(link ddtrace.SpanLink) {}
+
+// RecordError implements ddtrace.Span.
+func (NoopSpan) RecordError(err error, opts ...ddtrace.ErrorOption) {}
+
+// SetUser implements ddtrace.Span.
+func (NoopSpan) SetUser(id string, opts ...ddtrace.UserOption) {}
+
+// AddEvent implements ddtrace.Span.
+func (NoopSpan) AddEvent(name string, opts ...ddtrace.EventOption) {}
+
 // Finish implements ddtrace.Span.
 func (NoopSpan) Finish(opts ...ddtrace.FinishOption) {}
 
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeStackTrace(t *testing.T) {
+	t.Run("n=12", func(t *testing.T) {
+		val := TakeStacktrace(12, 0)
+		// top frame should be runtime.main or runtime.goexit, in case of tests that's goexit
+		assert.Contains(t, val, "runtime.goexit")
+		assert.Contains(t, val, "testing.tRunner")
+		assert.Contains(t, val, "internal.TestTakeStackTrace")
+	})
+
+	t.Run("n=15,skip=2", func(t *testing.T) {
+		val := TakeStacktrace(3, 2)
+		// top frame should be runtime.main or runtime.goexit, in case of tests that's goexit
+		assert.Contains(t, val, "runtime.goexit")
+		numFrames := strings.Count(val, "\n\t")
+		assert.Equal(t, 1, numFrames)
+	})
+
+	t.Run("n=1", func(t *testing.T) {
+		val := TakeStacktrace(1, 0)
+		assert.Contains(t, val, "internal.TestTakeStackTrace", "should contain this function")
+		// each frame consists of two strings separated by \n\t, thus number of frames == number of \n\t
+		numFrames := strings.Count(val, "\n\t")
+		assert.Equal(t, 1, numFrames)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Empty(t, TakeStacktrace(100, 115))
+	})
+}
@@ -12,6 +12,7 @@ import (
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 )
 
 var _ ddtrace.Span = (*mockspan)(nil)
@@ -43,6 +44,13 @@ type Span interface {
 	// Tags returns a copy of all the tags in this span.
 	Tags() map[string]interface{}
 
+	// Links returns a copy of all the links added to this span.
+	Links() []ddtrace.SpanLink
+
+	// Events returns all the events added to this span, in the order in
+	// which they were added.
+	Events() []SpanEvent
+
 	// Context returns the span's SpanContext.
 	Context() ddtrace.SpanContext
 
@@ -50,6 +58,28 @@ type Span interface {
 	fmt.Stringer
 }
 
+// SpanEvent is an event which was added to a span returned by the mock tracer.
+type SpanEvent struct {
+	name       string
+	timestamp  time.Time
+	attributes map[string]interface{}
+}
+
+// Name returns the event's name.
+func (e SpanEvent) Name() string { return e.name }
+
+// Timestamp returns the time at which the event occurred.
+func (e SpanEvent) Timestamp() time.Time { return e.timestamp }
+
+// Attributes returns a copy of the event's attributes.
+func (e SpanEvent) Attributes() map[string]interface{} {
+	cp := make(map[string]interface{}, len(e.attributes))
+	for k, v := range e.attributes {
+		cp[k] = v
+	}
+	return cp
+}
+
 func newSpan(t *mocktracer, operationName string, cfg *ddtrace.StartSpanConfig) *mockspan {
 	if cfg.Tags == nil {
 		cfg.Tags = make(map[string]interface{})
@@ -89,9 +119,13 @@ func newSpan(t *mocktracer, operationName string, cfg *ddtrace.StartSpanConfig)
 			return true
 		})
 	}
+	for k, v := range cfg.Baggage {
+		s.context.setBaggageItem(k, v)
+	}
 	for k, v := range cfg.Tags {
 		s.SetTag(k, v)
 	}
+	s.conditionalTags = cfg.ConditionalTags
 	return s
 }
 
@@ -99,12 +133,15 @@ type mockspan struct {
 	sync.RWMutex // guards below fields
 	name         string
 	tags         map[string]interface{}
+	links        []ddtrace.SpanLink
+	events       []ddtrace.SpanEvent
 	finishTime   time.Time
 
-	startTime time.Time
-	parentID  uint64
-	context   *spanContext
-	tracer    *mocktracer
+	startTime       time.Time
+	parentID        uint64
+	context         *spanContext
+	tracer          *mocktracer
+	conditionalTags map[string]func(ddtrace.Span) interface{}
 }
 
 // SetTag sets a given tag on the span.
@@ -125,6 +162,12 @@ func (s *mockspan) SetTag(key string, value interface{}) {
 	s.tags[key] = value
 }
 
+// SetMetric sets a given metric on the span. Metrics are recorded along with
+// tags and can be retrieved using Tag.
+func (s *mockspan) SetMetric(key string, value float64) {
+	s.SetTag(key, value)
+}
+
 func (s *mockspan) FinishTime() time.Time {
 	s.RLock()
 	defer s.RUnlock()
@@ -150,6 +193,102 @@ func (s *mockspan) Tags() map[string]interface{} {
 	return cp
 }
 
+// AddLink adds a link to another span.
+func (s *mockspan) AddLink(link ddtrace.SpanLink) {
+	s.Lock()
+	defer s.Unlock()
+	s.links = append(s.links, link)
+}
+
+// RecordError sets the given error on the span, along with its message, type and,
+// unless disabled, stack trace tags.
+func (s *mockspan) RecordError(err error, opts ...ddtrace.ErrorOption) {
+	if err == nil {
+		return
+	}
+	var cfg ddtrace.ErrorConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.ErrorType == "" {
+		cfg.ErrorType = fmt.Sprintf("%T", err)
+	}
+	s.SetTag(ext.Error, err)
+	s.SetTag(ext.ErrorMsg, err.Error())
+	s.SetTag(ext.ErrorType, cfg.ErrorType)
+	if !cfg.NoStack {
+		// skip RecordError, to start at its caller like the tracer does
+		s.SetTag(ext.ErrorStack, internal.TakeStacktrace(internal.DefaultErrorStackFrames, 1))
+	}
+}
+
+// SetUser sets the "usr.id" tag on the span, along with any of the other "usr.*"
+// tags given as options.
+func (s *mockspan) SetUser(id string, opts ...ddtrace.UserOption) {
+	var cfg ddtrace.UserConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	s.SetTag(ext.UserID, id)
+	for k, v := range map[string]string{
+		ext.UserEmail: cfg.Email,
+		ext.UserName:  cfg.Name,
+		ext.UserRole:  cfg.Role,
+		ext.UserScope: cfg.Scope,
+	} {
+		if v != "" {
+			s.SetTag(k, v)
+		}
+	}
+}
+
+// AddEvent adds a time-stamped event to the span.
+func (s *mockspan) AddEvent(name string, opts ...ddtrace.EventOption) {
+	var cfg ddtrace.EventConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.Time.IsZero() {
+		cfg.Time = time.Now()
+	}
+	event := ddtrace.SpanEvent{
+		Name:         name,
+		TimeUnixNano: uint64(cfg.Time.UnixNano()),
+	}
+	if len(cfg.Attributes) > 0 {
+		event.Attributes = make(map[string]interface{}, len(cfg.Attributes))
+		for k, v := range cfg.Attributes {
+			event.Attributes[k] = v
+		}
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *mockspan) Links() []ddtrace.SpanLink {
+	s.RLock()
+	defer s.RUnlock()
+	// copy
+	cp := make([]ddtrace.SpanLink, len(s.links))
+	copy(cp, s.links)
+	return cp
+}
+
+func (s *mockspan) Events() []SpanEvent {
+	s.RLock()
+	defer s.RUnlock()
+	events := make([]SpanEvent, len(s.events))
+	for i, e := range s.events {
+		events[i] = SpanEvent{
+			name:       e.Name,
+			timestamp:  time.Unix(0, int64(e.TimeUnixNano)),
+			attributes: e.Attributes,
+		}
+	}
+	return events
+}
+
 func (s *mockspan) TraceID() uint64 { return s.context.traceID }
 
 func (s *mockspan) SpanID() uint64 { return s.context.spanID }
@@ -199,7 +338,16 @@ func (s *mockspan) Finish(opts ...ddtrace.FinishOption) {
 	}
 	s.Lock()
 	s.finishTime = t
+	tags := s.conditionalTags
+	s.conditionalTags = nil
 	s.Unlock()
+	// like the tracer, the conditional tags are set once the sampling
+	// priority of the finished span is final
+	if !s.context.hasSamplingPriority() || s.context.samplingPriority() >= ext.PriorityAutoKeep {
+		for k, fn := range tags {
+			s.SetTag(k, fn(s))
+		}
+	}
 	s.tracer.addFinishedSpan(s)
 }
 
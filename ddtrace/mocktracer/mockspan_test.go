@@ -7,11 +7,13 @@ package mocktracer
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
 	"github.com/stretchr/testify/assert"
@@ -78,6 +80,13 @@ func TestSpanSetTag(t *testing.T) {
 	assert.Equal("d", s.Tag("c"))
 }
 
+func TestSpanSetMetric(t *testing.T) {
+	s := basicSpan("http.request")
+	s.SetMetric("db.row_count", 42)
+
+	assert.Equal(t, 42.0, s.Tag("db.row_count"))
+}
+
 func TestSpanSetTagPriority(t *testing.T) {
 	assert := assert.New(t)
 	s := basicSpan("http.request")
@@ -99,6 +108,110 @@ func TestSpanTagImmutability(t *testing.T) {
 	assert.Zero(s.tags["b"])
 }
 
+func TestSpanLinks(t *testing.T) {
+	s := basicSpan("http.request")
+	s.AddLink(ddtrace.SpanLink{TraceID: 1, SpanID: 2})
+	links := s.Links()
+	links[0].SpanID = 3
+
+	assert := assert.New(t)
+	assert.Equal([]ddtrace.SpanLink{{TraceID: 1, SpanID: 2}}, s.Links())
+}
+
+func TestSpanConditionalTags(t *testing.T) {
+	assert := assert.New(t)
+	mt := &mocktracer{}
+	fn := func(s ddtrace.Span) interface{} { return "value" }
+
+	s := mt.StartSpan("http.request", tracer.WithConditionalTag("k", fn)).(*mockspan)
+	assert.Nil(s.Tag("k"))
+	s.Finish()
+	assert.Equal("value", s.Tag("k"))
+
+	s = mt.StartSpan("http.request", tracer.Tag(ext.SamplingPriority, ext.PriorityUserReject), tracer.WithConditionalTag("k", fn)).(*mockspan)
+	s.Finish()
+	assert.Nil(s.Tag("k"))
+}
+
+func TestSpanSetUser(t *testing.T) {
+	assert := assert.New(t)
+	s := basicSpan("http.request")
+	s.SetUser("user-1", tracer.WithUserEmail("user@example.com"), tracer.WithUserRole("admin"))
+	assert.Equal("user-1", s.Tag(ext.UserID))
+	assert.Equal("user@example.com", s.Tag(ext.UserEmail))
+	assert.Equal("admin", s.Tag(ext.UserRole))
+	assert.Nil(s.Tag(ext.UserName))
+	assert.Nil(s.Tag(ext.UserScope))
+}
+
+func TestSpanRecordError(t *testing.T) {
+	assert := assert.New(t)
+	err := errors.New("abc")
+
+	s := basicSpan("http.request")
+	s.RecordError(err)
+	assert.Equal(err, s.Tag(ext.Error))
+	assert.Equal("abc", s.Tag(ext.ErrorMsg))
+	assert.Equal("*errors.errorString", s.Tag(ext.ErrorType))
+	assert.True(strings.HasPrefix(s.Tag(ext.ErrorStack).(string), "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer.TestSpanRecordError\n"))
+
+	// the stack is capped like the tracer's
+	s = basicSpan("http.request")
+	var recurse func(n int)
+	recurse = func(n int) {
+		if n == 0 {
+			s.RecordError(err)
+			return
+		}
+		recurse(n - 1)
+	}
+	recurse(2 * internal.DefaultErrorStackFrames)
+	assert.Equal(internal.DefaultErrorStackFrames, strings.Count(s.Tag(ext.ErrorStack).(string), "\n\t"))
+
+	s = basicSpan("http.request")
+	s.RecordError(err, tracer.WithStack(false), tracer.WithErrorType("timeout"))
+	assert.Equal("timeout", s.Tag(ext.ErrorType))
+	assert.Nil(s.Tag(ext.ErrorStack))
+
+	s = basicSpan("http.request")
+	s.RecordError(nil)
+	assert.Nil(s.Tag(ext.Error))
+}
+
+func TestSpanAddEvent(t *testing.T) {
+	s := basicSpan("http.request")
+	attrs := map[string]interface{}{"key": "miss"}
+	s.AddEvent("cache.miss", tracer.WithAttributes(attrs))
+	attrs["key"] = "changed"
+
+	assert := assert.New(t)
+	assert.Len(s.events, 1)
+	assert.Equal("cache.miss", s.events[0].Name)
+	assert.Equal(map[string]interface{}{"key": "miss"}, s.events[0].Attributes)
+	assert.NotZero(s.events[0].TimeUnixNano)
+}
+
+func TestSpanEvents(t *testing.T) {
+	s := basicSpan("http.request")
+	ts := time.Unix(1, 2)
+	s.AddEvent("cache.miss", tracer.WithTimestamp(ts), tracer.WithAttributes(map[string]interface{}{"key": "user:1"}))
+	s.AddEvent("db.query")
+
+	assert := assert.New(t)
+	events := s.Events()
+	assert.Len(events, 2)
+	assert.Equal("cache.miss", events[0].Name())
+	assert.Equal(ts, events[0].Timestamp())
+	assert.Equal(map[string]interface{}{"key": "user:1"}, events[0].Attributes())
+	assert.Equal("db.query", events[1].Name())
+	assert.False(events[1].Timestamp().Before(ts))
+	assert.Empty(events[1].Attributes())
+
+	// the returned attributes are a copy
+	events[0].Attributes()["key"] = "changed"
+	assert.Equal("user:1", s.Events()[0].Attributes()["key"])
+}
+
 func TestSpanStartTime(t *testing.T) {
 	startTime := time.Now()
 	s := newSpan(&mocktracer{}, "http.request", &ddtrace.StartSpanConfig{StartTime: startTime})
@@ -13,9 +13,12 @@
 package mocktracer
 
 import (
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"testing"
+	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
@@ -30,9 +33,17 @@ type Tracer interface {
 	// FinishedSpans returns the set of finished spans.
 	FinishedSpans() []Span
 
-	// Reset resets the spans and services recorded in the tracer. This is
-	// especially useful when running tests in a loop, where a clean start
-	// is desired for FinishedSpans calls.
+	// SpansByOperationName returns the finished spans having the given
+	// operation name.
+	SpansByOperationName(name string) []Span
+
+	// SpansByTag returns the finished spans having the tag key set to
+	// a value equal to the given one.
+	SpansByTag(key string, value interface{}) []Span
+
+	// Reset resets the spans and services recorded in the tracer, along with
+	// the events of those spans. This is especially useful when running tests
+	// in a loop, where a clean start is desired for FinishedSpans calls.
 	Reset()
 
 	// Stop deactivates the mock tracer and allows a normal tracer to take over.
@@ -45,15 +56,68 @@ type Tracer interface {
 // to activate the mock tracer. When your test runs, use the returned
 // interface to query the tracer's state.
 func Start() Tracer {
-	var t mocktracer
-	internal.SetGlobalTracer(&t)
+	t := newMockTracer()
+	internal.SetGlobalTracer(t)
 	internal.Testing = true
-	return &t
+	return t
 }
 
 type mocktracer struct {
 	sync.RWMutex  // guards below spans
 	finishedSpans []Span
+
+	// finished is signaled every time a span finishes. It is used by
+	// WaitForSpan and may be nil when the tracer was not created using
+	// newMockTracer.
+	finished *sync.Cond
+}
+
+// newMockTracer returns a new mock tracer which signals finished spans.
+func newMockTracer() *mocktracer {
+	t := &mocktracer{}
+	t.finished = sync.NewCond(&t.RWMutex)
+	return t
+}
+
+// WaitForSpan blocks until a finished span of the currently running mock
+// tracer matches filter and returns it. If no such span is found within
+// the given timeout, t.Fatal is called. It is useful when spans are
+// finished asynchronously, such as by message consumers or other goroutines.
+//
+// The filter is called with the tracer's lock held and must not call back
+// into the tracer.
+func WaitForSpan(t testing.TB, filter func(Span) bool, timeout time.Duration) Span {
+	t.Helper()
+	mt, ok := internal.GetGlobalTracer().(*mocktracer)
+	if !ok {
+		t.Fatal("mocktracer: WaitForSpan called without a running mock tracer")
+		return nil
+	}
+	mt.Lock()
+	defer mt.Unlock()
+	if mt.finished == nil {
+		mt.finished = sync.NewCond(&mt.RWMutex)
+	}
+	var timedOut bool
+	timer := time.AfterFunc(timeout, func() {
+		mt.Lock()
+		defer mt.Unlock()
+		timedOut = true
+		mt.finished.Broadcast()
+	})
+	defer timer.Stop()
+	for {
+		for _, s := range mt.finishedSpans {
+			if filter(s) {
+				return s
+			}
+		}
+		if timedOut {
+			t.Fatalf("mocktracer: no matching span finished within %s", timeout)
+			return nil
+		}
+		mt.finished.Wait()
+	}
 }
 
 // Stop deactivates the mock tracer and sets the active tracer to a no-op.
@@ -76,6 +140,30 @@ func (t *mocktracer) FinishedSpans() []Span {
 	return t.finishedSpans
 }
 
+func (t *mocktracer) SpansByOperationName(name string) []Span {
+	return t.filterSpans(func(s Span) bool { return s.OperationName() == name })
+}
+
+func (t *mocktracer) SpansByTag(key string, value interface{}) []Span {
+	return t.filterSpans(func(s Span) bool {
+		v, ok := s.Tags()[key]
+		return ok && reflect.DeepEqual(v, value)
+	})
+}
+
+// filterSpans returns the finished spans for which keep returns true.
+func (t *mocktracer) filterSpans(keep func(Span) bool) []Span {
+	t.RLock()
+	defer t.RUnlock()
+	var spans []Span
+	for _, s := range t.finishedSpans {
+		if keep(s) {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
 func (t *mocktracer) Reset() {
 	t.Lock()
 	defer t.Unlock()
@@ -89,6 +177,9 @@ func (t *mocktracer) addFinishedSpan(s Span) {
 		t.finishedSpans = make([]Span, 0, 1)
 	}
 	t.finishedSpans = append(t.finishedSpans, s)
+	if t.finished != nil {
+		t.finished.Broadcast()
+	}
 }
 
 const (
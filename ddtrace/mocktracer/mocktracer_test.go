@@ -6,6 +6,7 @@
 package mocktracer
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -92,9 +93,46 @@ func TestTracerFinishedSpans(t *testing.T) {
 	assert.Equal(t, 2, found)
 }
 
+func TestTracerSpansBy(t *testing.T) {
+	var mt mocktracer
+	mt.StartSpan("http.request", tracer.Tag(ext.HTTPCode, "200")).Finish()
+	mt.StartSpan("db.query", tracer.Tag("rows", 2)).Finish()
+	mt.StartSpan("http.request", tracer.Tag(ext.HTTPCode, "500")).Finish()
+
+	t.Run("operation-name", func(t *testing.T) {
+		assert := assert.New(t)
+		spans := mt.SpansByOperationName("http.request")
+		assert.Len(spans, 2)
+		assert.Equal("200", spans[0].Tag(ext.HTTPCode))
+		assert.Equal("500", spans[1].Tag(ext.HTTPCode))
+		assert.Empty(mt.SpansByOperationName("grpc.client"))
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		assert := assert.New(t)
+		spans := mt.SpansByTag(ext.HTTPCode, "500")
+		assert.Len(spans, 1)
+		assert.Equal("http.request", spans[0].OperationName())
+		assert.Len(mt.SpansByTag("rows", 2), 1)
+		assert.Empty(mt.SpansByTag("rows", "2"))
+		assert.Empty(mt.SpansByTag("missing", nil))
+	})
+}
+
+func TestTracerStartSpanBaggage(t *testing.T) {
+	mt := Start()
+	defer mt.Stop()
+
+	ctx := tracer.ContextWithBaggage(context.Background(), "tenant", "t1")
+	span, _ := tracer.StartSpanFromContext(ctx, "http.request")
+	assert.Equal(t, "t1", span.BaggageItem("tenant"))
+}
+
 func TestTracerReset(t *testing.T) {
 	var mt mocktracer
-	mt.StartSpan("db.query").Finish()
+	span := mt.StartSpan("db.query")
+	span.AddEvent("cache.miss")
+	span.Finish()
 
 	assert := assert.New(t)
 	assert.Len(mt.finishedSpans, 1)
@@ -102,6 +140,76 @@ func TestTracerReset(t *testing.T) {
 	mt.Reset()
 
 	assert.Nil(mt.finishedSpans)
+	mt.StartSpan("db.query").Finish()
+	assert.Len(mt.FinishedSpans(), 1)
+	assert.Empty(mt.FinishedSpans()[0].Events())
+}
+
+// fatalRecorder is a testing.TB which records calls to Fatal and Fatalf
+// instead of stopping the test.
+type fatalRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatal(args ...interface{}) { r.failed = true }
+
+func (r *fatalRecorder) Fatalf(format string, args ...interface{}) { r.failed = true }
+
+func TestWaitForSpan(t *testing.T) {
+	byName := func(name string) func(Span) bool {
+		return func(s Span) bool { return s.OperationName() == name }
+	}
+
+	t.Run("async", func(t *testing.T) {
+		mt := Start()
+		defer mt.Stop()
+
+		go func() {
+			tracer.StartSpan("kafka.produce").Finish()
+			tracer.StartSpan("kafka.consume").Finish()
+		}()
+		span := WaitForSpan(t, byName("kafka.consume"), time.Second)
+		assert.Equal(t, "kafka.consume", span.OperationName())
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		mt := Start()
+		defer mt.Stop()
+
+		tracer.StartSpan("db.query").Finish()
+		span := WaitForSpan(t, byName("db.query"), time.Second)
+		assert.Equal(t, "db.query", span.OperationName())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		mt := Start()
+		defer mt.Stop()
+
+		tracer.StartSpan("db.query").Finish()
+		r := &fatalRecorder{TB: t}
+		span := WaitForSpan(r, byName("http.request"), 10*time.Millisecond)
+		assert.Nil(t, span)
+		assert.True(t, r.failed)
+	})
+
+	t.Run("zero-value", func(t *testing.T) {
+		mt := Start().(*mocktracer)
+		defer mt.Stop()
+		mt.finished = nil
+
+		go mt.StartSpan("db.query").Finish()
+		span := WaitForSpan(t, byName("db.query"), time.Second)
+		assert.Equal(t, "db.query", span.OperationName())
+	})
+
+	t.Run("no-mocktracer", func(t *testing.T) {
+		r := &fatalRecorder{TB: t}
+		assert.Nil(t, WaitForSpan(r, byName("db.query"), time.Millisecond))
+		assert.True(t, r.failed)
+	})
 }
 
 func TestTracerInject(t *testing.T) {
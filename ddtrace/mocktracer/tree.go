@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+)
+
+// SpanNode is a span along with the spans which are its direct children.
+type SpanNode struct {
+	// Span holds the span at this node. It is nil for the node returned by
+	// SpanTree when there is more than one root span.
+	Span Span
+
+	// Children holds the nodes of the span's children, in the order in which
+	// they appeared in the list given to SpanTree.
+	Children []*SpanNode
+}
+
+// String returns an indented representation of the tree, one span per line.
+func (n *SpanNode) String() string {
+	var b strings.Builder
+	n.write(&b, 0)
+	return b.String()
+}
+
+func (n *SpanNode) write(b *strings.Builder, depth int) {
+	if n.Span != nil {
+		fmt.Fprintf(b, "%s%s (id: %d, parent: %d)\n",
+			strings.Repeat("  ", depth), n.Span.OperationName(), n.Span.SpanID(), n.Span.ParentID())
+		depth++
+	}
+	for _, c := range n.Children {
+		c.write(b, depth)
+	}
+}
+
+// SpanTree reconstructs the hierarchy of the given spans. Root spans are
+// those with a parent ID of 0, or whose parent is not part of spans. If
+// there is exactly one root span, its node is returned. Otherwise, the
+// returned node has no span and holds all the root spans as its children.
+func SpanTree(spans []Span) *SpanNode {
+	nodes := make(map[uint64]*SpanNode, len(spans))
+	for _, s := range spans {
+		nodes[s.SpanID()] = &SpanNode{Span: s}
+	}
+	var roots []*SpanNode
+	for _, s := range spans {
+		n := nodes[s.SpanID()]
+		if p, ok := nodes[s.ParentID()]; ok && s.ParentID() != 0 && p != n {
+			p.Children = append(p.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	if len(roots) == 1 {
+		return roots[0]
+	}
+	return &SpanNode{Children: roots}
+}
+
+// AssertParentChild asserts that child is a direct child of parent. On
+// failure, it reports an error on t which includes the span tree of the
+// running mock tracer's finished spans, and returns false.
+func AssertParentChild(t testing.TB, parent, child Span) bool {
+	t.Helper()
+	if child.ParentID() != 0 && child.ParentID() == parent.SpanID() {
+		return true
+	}
+	var spans []Span
+	if mt, ok := internal.GetGlobalTracer().(*mocktracer); ok {
+		spans = mt.FinishedSpans()
+	}
+	spans = appendMissing(spans, parent, child)
+	reason := fmt.Sprintf("its parent is %d", child.ParentID())
+	if child.ParentID() == 0 {
+		reason = "it is a root span"
+	}
+	t.Errorf("mocktracer: span %q (id: %d) is not a child of span %q (id: %d): %s\nspan tree:\n%s",
+		child.OperationName(), child.SpanID(), parent.OperationName(), parent.SpanID(), reason, SpanTree(spans))
+	return false
+}
+
+// appendMissing appends to spans those of add which it does not yet contain.
+func appendMissing(spans []Span, add ...Span) []Span {
+	for _, a := range add {
+		var found bool
+		for _, s := range spans {
+			if s.SpanID() == a.SpanID() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			spans = append(spans, a)
+		}
+	}
+	return spans
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"fmt"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// errorRecorder is a testing.TB which records the messages passed to Errorf.
+type errorRecorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *errorRecorder) Helper() {}
+
+func (r *errorRecorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestSpanTree(t *testing.T) {
+	t.Run("single-root", func(t *testing.T) {
+		mt := Start()
+		defer mt.Stop()
+
+		root := tracer.StartSpan("http.request")
+		child := tracer.StartSpan("db.query", tracer.ChildOf(root.Context()))
+		grandchild := tracer.StartSpan("db.fetch", tracer.ChildOf(child.Context()))
+		sibling := tracer.StartSpan("cache.get", tracer.ChildOf(root.Context()))
+		grandchild.Finish()
+		child.Finish()
+		sibling.Finish()
+		root.Finish()
+
+		assert := assert.New(t)
+		tree := SpanTree(mt.FinishedSpans())
+		assert.Equal("http.request", tree.Span.OperationName())
+		assert.Len(tree.Children, 2)
+		assert.Equal("db.query", tree.Children[0].Span.OperationName())
+		assert.Equal("cache.get", tree.Children[1].Span.OperationName())
+		assert.Len(tree.Children[0].Children, 1)
+		assert.Equal("db.fetch", tree.Children[0].Children[0].Span.OperationName())
+		assert.Empty(tree.Children[1].Children)
+		assert.Contains(tree.String(), "\n    db.fetch (id: ")
+	})
+
+	t.Run("many-roots", func(t *testing.T) {
+		mt := Start()
+		defer mt.Stop()
+
+		tracer.StartSpan("a").Finish()
+		tracer.StartSpan("b").Finish()
+
+		tree := SpanTree(mt.FinishedSpans())
+		assert.Nil(t, tree.Span)
+		assert.Len(t, tree.Children, 2)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		tree := SpanTree(nil)
+		assert.Nil(t, tree.Span)
+		assert.Empty(t, tree.Children)
+		assert.Empty(t, tree.String())
+	})
+}
+
+func TestAssertParentChild(t *testing.T) {
+	mt := Start()
+	defer mt.Stop()
+
+	root := tracer.StartSpan("http.request")
+	child := tracer.StartSpan("db.query", tracer.ChildOf(root.Context()))
+	other := tracer.StartSpan("cache.get")
+	child.Finish()
+	root.Finish()
+	other.Finish()
+	spans := mt.FinishedSpans()
+	childSpan, rootSpan, otherSpan := spans[0], spans[1], spans[2]
+
+	t.Run("ok", func(t *testing.T) {
+		r := &errorRecorder{TB: t}
+		assert.True(t, AssertParentChild(r, rootSpan, childSpan))
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("not-a-child", func(t *testing.T) {
+		r := &errorRecorder{TB: t}
+		assert.False(t, AssertParentChild(r, otherSpan, childSpan))
+		assert.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], `span "db.query"`)
+		assert.Contains(t, r.errors[0], "span tree:\nhttp.request")
+		assert.Contains(t, r.errors[0], "\n  db.query")
+	})
+
+	t.Run("root", func(t *testing.T) {
+		r := &errorRecorder{TB: t}
+		assert.False(t, AssertParentChild(r, childSpan, rootSpan))
+		assert.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], "it is a root span")
+	})
+}
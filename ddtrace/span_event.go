@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+//go:generate msgp -unexported -marshal=false -o=span_event_msgp.go -tests=false
+
+package ddtrace
+
+// SpanEvent represents a time-stamped annotation of a span, such as a retry, a
+// checkpoint or an error which did not end the span's operation. These are the
+// equivalent of OpenTelemetry span events.
+type SpanEvent struct {
+	// Name holds the name of the event.
+	Name string `msg:"name"`
+
+	// TimeUnixNano holds the time at which the event occurred, in nanoseconds
+	// since the Unix epoch.
+	TimeUnixNano uint64 `msg:"time_unix_nano"`
+
+	// Attributes holds optional metadata describing the event.
+	Attributes map[string]interface{} `msg:"attributes"`
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+//go:generate msgp -unexported -marshal=false -o=span_link_msgp.go -tests=false
+
+package ddtrace
+
+// SpanLink represents a reference from one span to another span, usually
+// belonging to a different trace, without implying a parent-child relationship.
+// Links are useful in fan-in scenarios, such as a message consumer processing
+// a batch of events that were produced by several unrelated traces.
+type SpanLink struct {
+	// TraceID holds the lower 64 bits of the linked span's trace ID.
+	TraceID uint64 `msg:"trace_id"`
+
+	// TraceIDHigh holds the upper 64 bits of the linked span's trace ID, when
+	// known. It is zero for 64-bit trace IDs.
+	TraceIDHigh uint64 `msg:"trace_id_high"`
+
+	// SpanID holds the linked span's ID.
+	SpanID uint64 `msg:"span_id"`
+
+	// Attributes holds optional metadata describing the link.
+	Attributes map[string]string `msg:"attributes"`
+
+	// Tracestate holds the W3C tracestate of the linked span, if any.
+	Tracestate string `msg:"tracestate"`
+
+	// Flags holds the W3C trace flags of the linked span. When set, the high
+	// bit (bit 31) should also be set to distinguish it from an unset value.
+	Flags uint32 `msg:"flags"`
+}
@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultSite is the Datadog site to which traces are sent in agentless mode,
+	// unless overridden by the DD_SITE environment variable.
+	defaultSite = "datadoghq.com"
+
+	// agentlessMaxRPS is the maximum number of requests per second sent to the
+	// trace intake in agentless mode.
+	agentlessMaxRPS = 100
+
+	// agentlessMaxRetries is the number of times a request is retried when it
+	// is rate limited by the trace intake.
+	agentlessMaxRetries = 5
+
+	// agentlessBackoff is the time waited before retrying a request which was
+	// rate limited by the trace intake. It doubles on each retry.
+	agentlessBackoff = 100 * time.Millisecond
+)
+
+// agentlessURL returns the URL of the trace intake of the given Datadog site.
+func agentlessURL(site string) string {
+	if site == "" {
+		site = defaultSite
+	}
+	return fmt.Sprintf("https://trace.agent.%s/api/v0.2/traces", site)
+}
+
+// agentlessTransport implements transport by sending traces directly to the
+// Datadog trace intake, without going through an agent.
+type agentlessTransport struct {
+	url     string            // the delivery URL for traces
+	apiKey  string            // the Datadog API key
+	client  *http.Client      // the HTTP client used in the POST
+	headers map[string]string // the Transport headers
+	limiter *rate.Limiter     // limits the rate of requests sent to the intake
+	backoff time.Duration     // the time waited before the first retry
+}
+
+// newAgentlessTransport returns an agentlessTransport sending traces to the
+// given intake URL, authenticated with apiKey. If roundTripper is nil, a
+// default is used.
+func newAgentlessTransport(url, apiKey string, roundTripper http.RoundTripper) *agentlessTransport {
+	if roundTripper == nil {
+		roundTripper = defaultRoundTripper
+	}
+	headers := metaHeaders()
+	headers["Content-Type"] = "application/json"
+	return &agentlessTransport{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{
+			Transport: roundTripper,
+			Timeout:   defaultHTTPTimeout,
+		},
+		headers: headers,
+		limiter: rate.NewLimiter(agentlessMaxRPS, agentlessMaxRPS),
+		backoff: agentlessBackoff,
+	}
+}
+
+// intakePayload is the JSON format of the traces accepted by the trace intake.
+type intakePayload struct {
+	Traces [][]intakeSpan `json:"traces"`
+}
+
+// intakeSpan is the JSON format of a span accepted by the trace intake.
+type intakeSpan struct {
+	Name     string             `json:"name"`
+	Service  string             `json:"service"`
+	Resource string             `json:"resource"`
+	Type     string             `json:"type"`
+	Start    int64              `json:"start"`
+	Duration int64              `json:"duration"`
+	Meta     map[string]string  `json:"meta,omitempty"`
+	Metrics  map[string]float64 `json:"metrics,omitempty"`
+	SpanID   uint64             `json:"span_id"`
+	TraceID  uint64             `json:"trace_id"`
+	ParentID uint64             `json:"parent_id"`
+	Error    int32              `json:"error"`
+}
+
+// newIntakePayload converts the given traces to the intake's format.
+func newIntakePayload(traces spanLists) intakePayload {
+	out := intakePayload{Traces: make([][]intakeSpan, len(traces))}
+	for i, trace := range traces {
+		out.Traces[i] = make([]intakeSpan, len(trace))
+		for j, s := range trace {
+			out.Traces[i][j] = intakeSpan{
+				Name:     s.Name,
+				Service:  s.Service,
+				Resource: s.Resource,
+				Type:     s.Type,
+				Start:    s.Start,
+				Duration: s.Duration,
+				Meta:     s.Meta,
+				Metrics:  s.Metrics,
+				SpanID:   s.SpanID,
+				TraceID:  s.TraceID,
+				ParentID: s.ParentID,
+				Error:    s.Error,
+			}
+		}
+	}
+	return out
+}
+
+func (t *agentlessTransport) send(p *payload) (body io.ReadCloser, err error) {
+	var traces spanLists
+	if err := msgp.Decode(p, &traces); err != nil {
+		return nil, fmt.Errorf("cannot decode payload: %v", err)
+	}
+	data, err := json.Marshal(newIntakePayload(traces))
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode payload: %v", err)
+	}
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest("POST", t.url, bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create http request: %v", err)
+		}
+		for header, value := range t.headers {
+			req.Header.Set(header, value)
+		}
+		req.Header.Set("DD-API-KEY", t.apiKey)
+		response, err := t.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		io.Copy(ioutil.Discard, response.Body)
+		response.Body.Close()
+		code := response.StatusCode
+		if code == http.StatusTooManyRequests && attempt < agentlessMaxRetries {
+			time.Sleep(t.backoff << uint(attempt))
+			continue
+		}
+		if code >= 400 {
+			return nil, &statusError{code: code, msg: http.StatusText(code)}
+		}
+		// the intake doesn't return sampling rates like the agent does
+		return ioutil.NopCloser(strings.NewReader("{}")), nil
+	}
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentlessURL(t *testing.T) {
+	assert.Equal(t, "https://trace.agent.datadoghq.com/api/v0.2/traces", agentlessURL(""))
+	assert.Equal(t, "https://trace.agent.datadoghq.eu/api/v0.2/traces", agentlessURL("datadoghq.eu"))
+}
+
+func TestAgentlessTransport(t *testing.T) {
+	t.Run("send", func(t *testing.T) {
+		assert := assert.New(t)
+		var got intakePayload
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal("key", r.Header.Get("DD-API-KEY"))
+			assert.Equal("application/json", r.Header.Get("Content-Type"))
+			assert.Equal("go", r.Header.Get("Datadog-Meta-Lang"))
+			assert.NoError(json.NewDecoder(r.Body).Decode(&got))
+		}))
+		defer srv.Close()
+
+		traces := getTestTrace(2, 3)
+		traces[0][0].Meta = map[string]string{"k": "v"}
+		p, err := encode(traces)
+		assert.NoError(err)
+		body, err := newAgentlessTransport(srv.URL, "key", nil).send(p)
+		assert.NoError(err)
+		body.Close()
+
+		assert.Len(got.Traces, 2)
+		assert.Len(got.Traces[0], 3)
+		s := got.Traces[0][0]
+		assert.Equal(traces[0][0].Name, s.Name)
+		assert.Equal(traces[0][0].SpanID, s.SpanID)
+		assert.Equal(traces[0][0].TraceID, s.TraceID)
+		assert.Equal("v", s.Meta["k"])
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		assert := assert.New(t)
+		var n int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&n, 1) <= 2 {
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		}))
+		defer srv.Close()
+
+		transport := newAgentlessTransport(srv.URL, "key", nil)
+		transport.backoff = time.Millisecond
+		p, err := encode(getTestTrace(1, 1))
+		assert.NoError(err)
+		_, err = transport.send(p)
+		assert.NoError(err)
+		assert.Equal(int32(3), atomic.LoadInt32(&n))
+	})
+
+	t.Run("rate-limited", func(t *testing.T) {
+		assert := assert.New(t)
+		var n int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&n, 1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		transport := newAgentlessTransport(srv.URL, "key", nil)
+		transport.backoff = time.Millisecond
+		p, err := encode(getTestTrace(1, 1))
+		assert.NoError(err)
+		_, err = transport.send(p)
+		assert.Error(err)
+		assert.Equal(int32(agentlessMaxRetries+1), atomic.LoadInt32(&n))
+	})
+}
+
+func TestAgentlessMode(t *testing.T) {
+	os.Setenv("DD_SITE", "datadoghq.eu")
+	defer os.Unsetenv("DD_SITE")
+
+	tracer := newTracer(WithAgentlessMode("key"))
+	defer tracer.Stop()
+	transport, ok := tracer.config.transport.(*agentlessTransport)
+	if assert.True(t, ok) {
+		assert.Equal(t, "key", transport.apiKey)
+		assert.Equal(t, agentlessURL("datadoghq.eu"), transport.url)
+	}
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+
+	"github.com/petermattis/goid"
+)
+
+// autoSpans maps goroutine IDs to their current span (*span), when
+// WithAutoContext is enabled.
+var autoSpans sync.Map
+
+// AutoSpanFromContext returns the current span of the calling goroutine, as
+// tracked when the tracer is started with WithAutoContext. A second return
+// value indicates if a span was found. If no span is found, a no-op span is
+// returned.
+//
+// EXPERIMENTAL: see the warnings of WithAutoContext.
+func AutoSpanFromContext() (Span, bool) {
+	if s := autoSpan(); s != nil {
+		return s, true
+	}
+	return &internal.NoopSpan{}, false
+}
+
+// autoSpan returns the current span of the calling goroutine, or nil if there
+// is none.
+func autoSpan() *span {
+	if v, ok := autoSpans.Load(goid.Get()); ok {
+		return v.(*span)
+	}
+	return nil
+}
+
+// setAutoSpan makes s the current span of the calling goroutine, replacing
+// parent, which becomes current again once s finishes.
+func setAutoSpan(s, parent *span) {
+	s.goid = goid.Get()
+	s.autoParent = parent
+	autoSpans.Store(s.goid, s)
+}
+
+// releaseAutoSpan restores the span which was current before s in the goroutine
+// of which s is the current span, skipping the ones which already finished. It
+// has no effect if s is not the current span, e.g. when spans finish out of order.
+// The lock of s must be held.
+func releaseAutoSpan(s *span) {
+	if v, ok := autoSpans.Load(s.goid); !ok || v.(*span) != s {
+		return
+	}
+	p := s.autoParent
+	for p != nil && p.isFinished() {
+		p = p.autoParent
+	}
+	if p != nil {
+		autoSpans.Store(s.goid, p)
+	} else {
+		autoSpans.Delete(s.goid)
+	}
+}
+
+// isFinished reports whether s is finished.
+func (s *span) isFinished() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.finished
+}
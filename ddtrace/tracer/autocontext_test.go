@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"sync"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoContext(t *testing.T) {
+	_, _, stop := startTestTracer(WithAutoContext(true))
+	defer stop()
+
+	t.Run("nesting", func(t *testing.T) {
+		assert := assert.New(t)
+		_, ok := AutoSpanFromContext()
+		assert.False(ok)
+
+		root := StartSpan("root").(*span)
+		got, ok := AutoSpanFromContext()
+		assert.True(ok)
+		assert.Equal(root, got)
+
+		child := StartSpan("child").(*span)
+		assert.Equal(root.SpanID, child.ParentID)
+		got, _ = AutoSpanFromContext()
+		assert.Equal(child, got)
+
+		child.Finish()
+		got, _ = AutoSpanFromContext()
+		assert.Equal(root, got)
+
+		root.Finish()
+		got, ok = AutoSpanFromContext()
+		assert.False(ok)
+		_, ok = got.(*internal.NoopSpan)
+		assert.True(ok)
+	})
+
+	t.Run("out-of-order", func(t *testing.T) {
+		assert := assert.New(t)
+		root := StartSpan("root").(*span)
+		child := StartSpan("child").(*span)
+		root.Finish()
+		got, _ := AutoSpanFromContext()
+		assert.Equal(child, got)
+		child.Finish()
+		_, ok := AutoSpanFromContext()
+		assert.False(ok)
+	})
+
+	t.Run("explicit-parent", func(t *testing.T) {
+		assert := assert.New(t)
+		root := StartSpan("root").(*span)
+		other := StartSpan("other", ChildOf(&spanContext{traceID: 1, spanID: 2})).(*span)
+		assert.Equal(uint64(2), other.ParentID)
+		other.Finish()
+		got, _ := AutoSpanFromContext()
+		assert.Equal(root, got)
+		root.Finish()
+	})
+
+	t.Run("goroutines", func(t *testing.T) {
+		assert := assert.New(t)
+		root := StartSpan("root").(*span)
+		defer root.Finish()
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok := AutoSpanFromContext()
+			assert.False(ok)
+			s := StartSpan("async").(*span)
+			assert.Equal(uint64(0), s.ParentID)
+			s.Finish()
+		}()
+		wg.Wait()
+		got, _ := AutoSpanFromContext()
+		assert.Equal(root, got)
+	})
+}
+
+func TestAutoContextDisabled(t *testing.T) {
+	_, _, stop := startTestTracer()
+	defer stop()
+
+	root := StartSpan("root").(*span)
+	defer root.Finish()
+	_, ok := AutoSpanFromContext()
+	assert.False(t, ok)
+	child := StartSpan("child").(*span)
+	assert.Equal(t, uint64(0), child.ParentID)
+	child.Finish()
+}
@@ -7,12 +7,17 @@ package tracer
 
 import (
 	"context"
+	"net/http"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 )
 
-type contextKey struct{}
+type (
+	contextKey struct{}
+	baggageKey struct{}
+)
 
 var activeSpanKey = contextKey{}
 
@@ -35,6 +40,13 @@ func SpanFromContext(ctx context.Context) (Span, bool) {
 	return &internal.NoopSpan{}, false
 }
 
+// SpanFromContextOrNoop returns the span contained in the given context, or a no-op
+// span if there is none, allowing callers to use the result without checking it.
+func SpanFromContextOrNoop(ctx context.Context) Span {
+	s, _ := SpanFromContext(ctx)
+	return s
+}
+
 // StartSpanFromContext returns a new span with the given operation name and options. If a span
 // is found in the context, it will be used as the parent of the resulting span. If the ChildOf
 // option is passed, the span from context will take precedence over it as the parent span.
@@ -42,6 +54,89 @@ func StartSpanFromContext(ctx context.Context, operationName string, opts ...Sta
 	if s, ok := SpanFromContext(ctx); ok {
 		opts = append(opts, ChildOf(s.Context()))
 	}
+	return startSpanInContext(ctx, operationName, opts...)
+}
+
+// startSpanInContext starts a new span with the given options, carrying the baggage
+// items added to ctx using ContextWithBaggage, and returns it along with a copy of
+// ctx which includes it. Unlike StartSpanFromContext, it ignores the span that ctx
+// may already hold.
+func startSpanInContext(ctx context.Context, operationName string, opts ...StartSpanOption) (Span, context.Context) {
+	if ctx != nil {
+		if b, ok := ctx.Value(baggageKey{}).(map[string]string); ok {
+			opts = append(opts, withContextBaggage(b))
+		}
+	}
 	s := StartSpan(operationName, opts...)
 	return s, ContextWithSpan(ctx, s)
 }
+
+// withContextBaggage sets the given baggage items on the context of the started span.
+func withContextBaggage(b map[string]string) StartSpanOption {
+	return func(cfg *ddtrace.StartSpanConfig) {
+		cfg.Baggage = b
+	}
+}
+
+// StartSpanFromHTTPRequest returns a new server span with the given operation name for
+// the incoming request r, along with a copy of the request's context which includes it.
+// The span is a child of the span context extracted from the request headers, if any,
+// even when the request's context holds a span. Otherwise, it is a child of the span
+// found in the request's context, or a root span if there is none. It is tagged with
+// the request's method, URL path and host; the given options are applied afterwards
+// and may override these.
+func StartSpanFromHTTPRequest(operationName string, r *http.Request, opts ...StartSpanOption) (Span, context.Context) {
+	cfg := []StartSpanOption{
+		SpanType(ext.SpanTypeWeb),
+		Tag(ext.HTTPMethod, r.Method),
+		Tag(ext.HTTPURL, r.URL.Path),
+		Tag(ext.HTTPHost, r.Host),
+	}
+	sctx, err := ExtractHTTP(r)
+	if err != nil {
+		return StartSpanFromContext(r.Context(), operationName, append(cfg, opts...)...)
+	}
+	cfg = append(cfg, ChildOf(sctx))
+	return startSpanInContext(r.Context(), operationName, append(cfg, opts...)...)
+}
+
+// ContextWithBaggage returns a copy of the given context which includes the given
+// baggage item. Spans started from the returned context using StartSpanFromContext
+// carry the item, so that it is propagated when they are injected. Spans which
+// were already started are not modified.
+func ContextWithBaggage(ctx context.Context, key, value string) context.Context {
+	old, _ := ctx.Value(baggageKey{}).(map[string]string)
+	b := make(map[string]string, len(old)+1)
+	for k, v := range old {
+		b[k] = v
+	}
+	b[key] = value
+	return context.WithValue(ctx, baggageKey{}, b)
+}
+
+// BaggageFromContext returns the baggage items found in the given context. These
+// are the items carried by the span contained in the context, such as the ones
+// extracted from a carrier, along with the ones added using ContextWithBaggage,
+// which take precedence. It returns nil if there are none.
+func BaggageFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	var b map[string]string
+	set := func(k, v string) bool {
+		if b == nil {
+			b = make(map[string]string)
+		}
+		b[k] = v
+		return true
+	}
+	if s, ok := SpanFromContext(ctx); ok {
+		s.Context().ForeachBaggageItem(set)
+	}
+	if items, ok := ctx.Value(baggageKey{}).(map[string]string); ok {
+		for k, v := range items {
+			set(k, v)
+		}
+	}
+	return b
+}
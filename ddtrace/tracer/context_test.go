@@ -7,9 +7,11 @@ package tracer
 
 import (
 	"context"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 )
 
@@ -44,6 +46,21 @@ func TestSpanFromContext(t *testing.T) {
 	})
 }
 
+func TestSpanFromContextOrNoop(t *testing.T) {
+	assert := assert.New(t)
+	want := &span{SpanID: 123}
+	assert.Equal(want, SpanFromContextOrNoop(ContextWithSpan(context.Background(), want)))
+
+	got := SpanFromContextOrNoop(context.Background())
+	_, ok := got.(*internal.NoopSpan)
+	assert.True(ok)
+	got.SetTag("k", "v")
+	got.Finish()
+
+	_, ok = SpanFromContextOrNoop(nil).(*internal.NoopSpan)
+	assert.True(ok)
+}
+
 func TestStartSpanFromContext(t *testing.T) {
 	_, _, stop := startTestTracer()
 	defer stop()
@@ -74,3 +91,92 @@ func TestStartSpanFromContext(t *testing.T) {
 	assert.Equal("gin", got.Service)
 	assert.Equal("/", got.Resource)
 }
+
+func TestStartSpanFromHTTPRequest(t *testing.T) {
+	_, _, stop := startTestTracer()
+	defer stop()
+
+	t.Run("root", func(t *testing.T) {
+		assert := assert.New(t)
+		r := httptest.NewRequest("GET", "http://example.com/users?id=1", nil)
+		s, ctx := StartSpanFromHTTPRequest("web.request", r, ResourceName("GET /users"))
+		got := s.(*span)
+		gotctx, ok := SpanFromContext(ctx)
+		assert.True(ok)
+		assert.Equal(s, gotctx)
+		assert.Equal(uint64(0), got.ParentID)
+		assert.Equal("web.request", got.Name)
+		assert.Equal("GET /users", got.Resource)
+		assert.Equal(ext.SpanTypeWeb, got.Type)
+		assert.Equal("GET", got.Meta[ext.HTTPMethod])
+		assert.Equal("/users", got.Meta[ext.HTTPURL])
+		assert.Equal("example.com", got.Meta[ext.HTTPHost])
+	})
+
+	t.Run("child", func(t *testing.T) {
+		assert := assert.New(t)
+		r := httptest.NewRequest("POST", "/", nil)
+		r.Header.Set(DefaultTraceIDHeader, "456")
+		r.Header.Set(DefaultParentIDHeader, "123")
+		s, _ := StartSpanFromHTTPRequest("web.request", r, Tag(ext.HTTPMethod, "PUT"))
+		got := s.(*span)
+		assert.Equal(uint64(456), got.TraceID)
+		assert.Equal(uint64(123), got.ParentID)
+		assert.Equal("PUT", got.Meta[ext.HTTPMethod])
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		assert := assert.New(t)
+		parent, ctx := StartSpanFromContext(context.Background(), "middleware")
+		r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+		// without propagation headers, the span of the context is the parent
+		s, _ := StartSpanFromHTTPRequest("web.request", r)
+		assert.Equal(parent.Context().SpanID(), s.(*span).ParentID)
+
+		// the extracted context takes precedence over it
+		r.Header.Set(DefaultTraceIDHeader, "456")
+		r.Header.Set(DefaultParentIDHeader, "123")
+		s, ctx = StartSpanFromHTTPRequest("web.request", r)
+		got := s.(*span)
+		assert.Equal(uint64(456), got.TraceID)
+		assert.Equal(uint64(123), got.ParentID)
+		gotctx, _ := SpanFromContext(ctx)
+		assert.Equal(s, gotctx)
+	})
+}
+
+func TestContextWithBaggage(t *testing.T) {
+	_, _, stop := startTestTracer()
+	defer stop()
+	assert := assert.New(t)
+
+	assert.Nil(BaggageFromContext(context.Background()))
+
+	root, ctx := StartSpanFromContext(context.Background(), "root")
+	root.SetBaggageItem("user", "bob")
+	ctx2 := ContextWithBaggage(ctx, "tenant", "t1")
+	ctx2 = ContextWithBaggage(ctx2, "user", "alice")
+	assert.Equal(map[string]string{"user": "bob"}, BaggageFromContext(ctx))
+	assert.Equal(map[string]string{"user": "alice", "tenant": "t1"}, BaggageFromContext(ctx2))
+
+	child, _ := StartSpanFromContext(ctx2, "child")
+	assert.Equal("alice", child.BaggageItem("user"))
+	assert.Equal("t1", child.BaggageItem("tenant"))
+	// the existing span is not modified
+	assert.Equal("", root.BaggageItem("tenant"))
+}
+
+func TestContextWithBaggageAsSpanTags(t *testing.T) {
+	_, _, stop := startTestTracer(WithBaggageAsSpanTags(true))
+	defer stop()
+	assert := assert.New(t)
+
+	ctx := ContextWithBaggage(context.Background(), "tenant", "t1")
+	root, ctx := StartSpanFromContext(ctx, "root")
+	assert.Equal("t1", root.(*span).Meta["tenant"])
+
+	child, _ := StartSpanFromContext(ContextWithBaggage(ctx, "user", "alice"), "child")
+	assert.Equal("t1", child.(*span).Meta["tenant"])
+	assert.Equal("alice", child.(*span).Meta["user"])
+}
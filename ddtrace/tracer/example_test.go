@@ -8,6 +8,7 @@ package tracer
 import (
 	"io/ioutil"
 	"log"
+	"net/http"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 )
@@ -37,3 +38,47 @@ func Example() {
 		log.Fatal(err)
 	}
 }
+
+// InjectHTTP propagates the current span to an outgoing HTTP request.
+func ExampleInjectHTTP() {
+	span := StartSpan("http.request")
+	defer span.Finish()
+
+	req, err := http.NewRequest("GET", "http://example.com", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := InjectHTTP(span, req); err != nil {
+		log.Fatal(err)
+	}
+	http.DefaultClient.Do(req)
+}
+
+// ExtractHTTP continues a trace received with an incoming HTTP request.
+func ExampleExtractHTTP() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		var opts []StartSpanOption
+		if sctx, err := ExtractHTTP(r); err == nil {
+			opts = append(opts, ChildOf(sctx))
+		}
+		span := StartSpan("web.request", opts...)
+		defer span.Finish()
+
+		w.Write([]byte("Hello World!"))
+	})
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
+
+// StartSpanFromHTTPRequest starts a server span continuing the trace received
+// with an incoming HTTP request, if any.
+func ExampleStartSpanFromHTTPRequest() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		span, ctx := StartSpanFromHTTPRequest("web.request", r, ResourceName("/"))
+		defer span.Finish()
+
+		child, _ := StartSpanFromContext(ctx, "template.render")
+		w.Write([]byte("Hello World!"))
+		child.Finish()
+	})
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
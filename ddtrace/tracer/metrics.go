@@ -22,6 +22,7 @@ type statsdClient interface {
 	Incr(name string, tags []string, rate float64) error
 	Count(name string, value int64, tags []string, rate float64) error
 	Gauge(name string, value float64, tags []string, rate float64) error
+	Distribution(name string, value float64, tags []string, rate float64) error
 	Timing(name string, value time.Duration, tags []string, rate float64) error
 	Close() error
 }
@@ -36,6 +37,12 @@ func (t *tracer) reportRuntimeMetrics(interval time.Duration) {
 		// for (runtime/debug).ReadGCStats.
 		PauseQuantiles: make([]time.Duration, 5),
 	}
+	sched := newSchedMetrics()
+	// numGC holds the number of completed GC cycles as of the previous report.
+	runtime.ReadMemStats(&ms)
+	numGC := ms.NumGC
+	// allocs holds the allocation counters as of the previous report.
+	allocs := newAllocStats(&ms, time.Now())
 
 	tick := time.NewTicker(interval)
 	defer tick.Stop()
@@ -86,6 +93,21 @@ func (t *tracer) reportRuntimeMetrics(interval time.Duration) {
 			for i, p := range []string{"min", "25p", "50p", "75p", "max"} {
 				statsd.Gauge("runtime.go.gc_stats.pause_quantiles."+p, float64(gc.PauseQuantiles[i]), nil, 1)
 			}
+			if t.config.gcPauseDistribution {
+				forEachGCPause(&ms, numGC, func(ns uint64) {
+					statsd.Distribution("runtime.go.gc_stats.pause_ns", float64(ns), nil, 1)
+				})
+			}
+			numGC = ms.NumGC
+			if t.config.allocationRateMetrics {
+				now := newAllocStats(&ms, time.Now())
+				bytes, objects := allocs.rates(now)
+				statsd.Gauge("runtime.go.mem.alloc_bytes_rate", bytes, nil, 1)
+				statsd.Gauge("runtime.go.mem.allocs_per_sec", objects, nil, 1)
+				allocs = now
+			}
+			// Goroutine, scheduler and mutex statistics
+			sched.report(statsd)
 
 		case <-t.exitChan:
 			return
@@ -93,6 +115,42 @@ func (t *tracer) reportRuntimeMetrics(interval time.Duration) {
 	}
 }
 
+// forEachGCPause calls fn with the duration of each GC pause recorded in ms which
+// occurred after the first numGC GC cycles. Only the most recent pauses are held
+// by ms, so pauses may be missed when more GC cycles than len(ms.PauseNs) completed
+// since then.
+func forEachGCPause(ms *runtime.MemStats, numGC uint32, fn func(ns uint64)) {
+	n := uint32(len(ms.PauseNs))
+	if ms.NumGC-numGC > n {
+		numGC = ms.NumGC - n
+	}
+	for i := numGC; i < ms.NumGC; i++ {
+		// the pause of the (i+1)-th GC cycle is recorded at ms.PauseNs[i%n]
+		fn(ms.PauseNs[i%n])
+	}
+}
+
+// allocStats holds the cumulative allocation counters of the runtime at a given time.
+type allocStats struct {
+	bytes   uint64 // bytes allocated for heap objects
+	objects uint64 // heap objects allocated
+	at      time.Time
+}
+
+func newAllocStats(ms *runtime.MemStats, at time.Time) allocStats {
+	return allocStats{bytes: ms.TotalAlloc, objects: ms.Mallocs, at: at}
+}
+
+// rates returns the number of bytes and heap objects allocated per second between
+// a and the later stats b.
+func (a allocStats) rates(b allocStats) (bytes, objects float64) {
+	d := b.at.Sub(a.at).Seconds()
+	if d <= 0 {
+		return 0, 0
+	}
+	return float64(b.bytes-a.bytes) / d, float64(b.objects-a.objects) / d
+}
+
 func (t *tracer) reportHealthMetrics(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build go1.16
+
+package tracer
+
+import (
+	"math"
+	"runtime/metrics"
+)
+
+// schedLatencyQuantiles holds the names and values of the quantiles reported for
+// the scheduling latency.
+var schedLatencyQuantiles = []struct {
+	name string
+	q    float64
+}{
+	{"50p", 0.50},
+	{"95p", 0.95},
+	{"99p", 0.99},
+	{"max", 1},
+}
+
+// schedMetrics reports the goroutine, scheduler and mutex metrics which are only
+// available via the runtime/metrics package, starting with Go 1.16.
+type schedMetrics struct {
+	samples []metrics.Sample
+	// latencies holds the bucket counts of the scheduling latency histogram as
+	// of the previous report, so that only the latencies of the last interval
+	// are taken into account.
+	latencies []uint64
+}
+
+func newSchedMetrics() *schedMetrics {
+	return &schedMetrics{
+		samples: []metrics.Sample{
+			{Name: "/sched/goroutines:goroutines"},
+			{Name: "/sched/latencies:seconds"},
+			{Name: "/sync/mutex/wait/total:seconds"},
+		},
+	}
+}
+
+// report reads the runtime metrics and reports them using the given client.
+// Metrics which are not supported by the running Go version are skipped.
+func (m *schedMetrics) report(statsd statsdClient) {
+	metrics.Read(m.samples)
+	for _, s := range m.samples {
+		switch s.Name {
+		case "/sched/goroutines:goroutines":
+			if s.Value.Kind() == metrics.KindUint64 {
+				statsd.Gauge("runtime.go.goroutines", float64(s.Value.Uint64()), nil, 1)
+			}
+		case "/sched/latencies:seconds":
+			if s.Value.Kind() == metrics.KindFloat64Histogram {
+				m.reportLatencies(statsd, s.Value.Float64Histogram())
+			}
+		case "/sync/mutex/wait/total:seconds":
+			if s.Value.Kind() == metrics.KindFloat64 {
+				statsd.Gauge("runtime.go.mutex_wait", s.Value.Float64()*1e9, nil, 1)
+			}
+		}
+	}
+}
+
+// reportLatencies reports the quantiles of the scheduling latencies observed since
+// the previous report, in nanoseconds.
+func (m *schedMetrics) reportLatencies(statsd statsdClient, h *metrics.Float64Histogram) {
+	delta := make([]uint64, len(h.Counts))
+	var total uint64
+	for i, c := range h.Counts {
+		delta[i] = c
+		if i < len(m.latencies) {
+			delta[i] -= m.latencies[i]
+		}
+		total += delta[i]
+	}
+	m.latencies = append(m.latencies[:0], h.Counts...)
+	if total == 0 {
+		// no goroutine was scheduled during this interval
+		return
+	}
+	for _, q := range schedLatencyQuantiles {
+		v := histogramQuantile(h.Buckets, delta, total, q.q)
+		statsd.Gauge("runtime.go.sched_latency."+q.name, v*1e9, nil, 1)
+	}
+}
+
+// histogramQuantile returns an estimation of the q-quantile (0 < q <= 1) of the
+// histogram having the given buckets and counts, adding up to total. Bucket i
+// counts the values in the range [buckets[i], buckets[i+1]). The upper bound of
+// the bucket containing the quantile is returned, or its lower bound when the
+// bucket is unbounded.
+func histogramQuantile(buckets []float64, counts []uint64, total uint64, q float64) float64 {
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var n uint64
+	for i, c := range counts {
+		n += c
+		if n < rank {
+			continue
+		}
+		if hi := buckets[i+1]; !math.IsInf(hi, 1) {
+			return hi
+		}
+		return buckets[i]
+	}
+	return buckets[len(buckets)-1]
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build go1.16
+
+package tracer
+
+import (
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	assert := assert.New(t)
+	buckets := []float64{0, 1, 2, 4, math.Inf(1)}
+	counts := []uint64{5, 3, 1, 1}
+
+	assert.Equal(1.0, histogramQuantile(buckets, counts, 10, 0.5))
+	assert.Equal(2.0, histogramQuantile(buckets, counts, 10, 0.8))
+	assert.Equal(4.0, histogramQuantile(buckets, counts, 10, 0.9))
+	assert.Equal(4.0, histogramQuantile(buckets, counts, 10, 1))
+	assert.Equal(1.0, histogramQuantile(buckets, []uint64{1, 0, 0, 0}, 1, 1))
+}
+
+func TestSchedMetrics(t *testing.T) {
+	assert := assert.New(t)
+	var tg testStatsdClient
+	m := newSchedMetrics()
+
+	done := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		go func() {
+			runtime.Gosched()
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+	m.report(&tg)
+
+	calls := tg.CallsByName()
+	assert.Equal(1, calls["runtime.go.goroutines"])
+	assert.Equal(1, calls["runtime.go.sched_latency.50p"])
+	assert.Equal(1, calls["runtime.go.sched_latency.max"])
+	for _, c := range tg.GaugeCalls() {
+		if c.name == "runtime.go.goroutines" {
+			assert.True(c.floatVal >= 1)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !go1.16
+
+package tracer
+
+// schedMetrics is a no-op on Go versions prior to 1.16, which lack the
+// runtime/metrics package.
+type schedMetrics struct{}
+
+func newSchedMetrics() *schedMetrics { return &schedMetrics{} }
+
+func (*schedMetrics) report(_ statsdClient) {}
@@ -8,6 +8,7 @@ package tracer
 import (
 	"errors"
 	"fmt"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -24,6 +25,7 @@ const (
 	callTypeIncr
 	callTypeCount
 	callTypeTiming
+	callTypeDistribution
 )
 
 type testStatsdClient struct {
@@ -32,6 +34,7 @@ type testStatsdClient struct {
 	incrCalls   []testStatsdCall
 	countCalls  []testStatsdCall
 	timingCalls []testStatsdCall
+	distCalls   []testStatsdCall
 	counts      map[string]int64
 	tags        []string
 	waitCh      chan struct{}
@@ -72,6 +75,15 @@ func (tg *testStatsdClient) Gauge(name string, value float64, tags []string, rat
 	})
 }
 
+func (tg *testStatsdClient) Distribution(name string, value float64, tags []string, rate float64) error {
+	return tg.addMetric(callTypeDistribution, tags, testStatsdCall{
+		name:     name,
+		floatVal: value,
+		tags:     make([]string, len(tags)),
+		rate:     rate,
+	})
+}
+
 func (tg *testStatsdClient) Incr(name string, tags []string, rate float64) error {
 	tg.addCount(name, 1)
 	return tg.addMetric(callTypeIncr, tags, testStatsdCall{
@@ -113,6 +125,8 @@ func (tg *testStatsdClient) addMetric(ct callType, tags []string, c testStatsdCa
 		tg.countCalls = append(tg.countCalls, c)
 	case callTypeTiming:
 		tg.timingCalls = append(tg.timingCalls, c)
+	case callTypeDistribution:
+		tg.distCalls = append(tg.distCalls, c)
 	}
 	tg.tags = tags
 	if tg.n > 0 {
@@ -137,6 +151,14 @@ func (tg *testStatsdClient) GaugeCalls() []testStatsdCall {
 	return c
 }
 
+func (tg *testStatsdClient) DistributionCalls() []testStatsdCall {
+	tg.mu.RLock()
+	defer tg.mu.RUnlock()
+	c := make([]testStatsdCall, len(tg.distCalls))
+	copy(c, tg.distCalls)
+	return c
+}
+
 func (tg *testStatsdClient) IncrCalls() []testStatsdCall {
 	tg.mu.RLock()
 	defer tg.mu.RUnlock()
@@ -169,6 +191,9 @@ func (tg *testStatsdClient) CallNames() []string {
 	for _, c := range tg.timingCalls {
 		n = append(n, c.name)
 	}
+	for _, c := range tg.distCalls {
+		n = append(n, c.name)
+	}
 	return n
 }
 
@@ -188,6 +213,9 @@ func (tg *testStatsdClient) CallsByName() map[string]int {
 	for _, c := range tg.timingCalls {
 		counts[c.name]++
 	}
+	for _, c := range tg.distCalls {
+		counts[c.name]++
+	}
 	return counts
 }
 
@@ -216,6 +244,7 @@ func (tg *testStatsdClient) Reset() {
 	tg.incrCalls = tg.incrCalls[:0]
 	tg.countCalls = tg.countCalls[:0]
 	tg.timingCalls = tg.timingCalls[:0]
+	tg.distCalls = tg.distCalls[:0]
 	tg.counts = make(map[string]int64)
 	tg.tags = tg.tags[:0]
 	if tg.waitCh != nil {
@@ -271,14 +300,136 @@ func TestReportRuntimeMetrics(t *testing.T) {
 	assert.Contains(calls, "runtime.go.gc_stats.pause_quantiles.75p")
 }
 
+func TestGCPauseDistribution(t *testing.T) {
+	var tg testStatsdClient
+	trc := &tracer{
+		stopped:  make(chan struct{}),
+		exitChan: make(chan struct{}),
+		config: &config{
+			statsd:              &tg,
+			gcPauseDistribution: true,
+		},
+	}
+
+	trc.wg.Add(1)
+	go func() {
+		defer trc.wg.Done()
+		trc.reportRuntimeMetrics(time.Millisecond)
+	}()
+	runtime.GC()
+	runtime.GC()
+	deadline := time.Now().Add(time.Second)
+	for len(tg.DistributionCalls()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(trc.exitChan)
+	trc.wg.Wait()
+	assert := assert.New(t)
+	assert.True(len(tg.DistributionCalls()) >= 2)
+	for _, c := range tg.DistributionCalls() {
+		assert.Equal("runtime.go.gc_stats.pause_ns", c.name)
+		assert.True(c.floatVal > 0)
+	}
+}
+
+func TestForEachGCPause(t *testing.T) {
+	collect := func(ms *runtime.MemStats, numGC uint32) []uint64 {
+		var pauses []uint64
+		forEachGCPause(ms, numGC, func(ns uint64) { pauses = append(pauses, ns) })
+		return pauses
+	}
+
+	t.Run("new", func(t *testing.T) {
+		var ms runtime.MemStats
+		ms.NumGC = 3
+		ms.PauseNs[0], ms.PauseNs[1], ms.PauseNs[2] = 10, 20, 30
+		assert.Equal(t, []uint64{20, 30}, collect(&ms, 1))
+		assert.Len(t, collect(&ms, 3), 0)
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		var ms runtime.MemStats
+		ms.NumGC = 258
+		ms.PauseNs[255], ms.PauseNs[0], ms.PauseNs[1] = 10, 20, 30
+		assert.Equal(t, []uint64{10, 20, 30}, collect(&ms, 255))
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		var ms runtime.MemStats
+		ms.NumGC = 1000
+		assert.Len(t, collect(&ms, 10), len(ms.PauseNs))
+	})
+}
+
+func TestAllocStatsRates(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Now()
+
+	t.Run("fixed", func(t *testing.T) {
+		a := allocStats{bytes: 1000, objects: 10, at: start}
+		b := allocStats{bytes: 5000, objects: 50, at: start.Add(2 * time.Second)}
+		bytes, objects := a.rates(b)
+		assert.Equal(2000.0, bytes)
+		assert.Equal(20.0, objects)
+
+		bytes, objects = a.rates(a)
+		assert.Zero(bytes)
+		assert.Zero(objects)
+	})
+
+	t.Run("allocations", func(t *testing.T) {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		a := newAllocStats(&ms, start)
+
+		const n, size = 1000, 1024
+		bufs := make([][]byte, n)
+		for i := range bufs {
+			bufs[i] = make([]byte, size)
+		}
+		runtime.ReadMemStats(&ms)
+		bytes, objects := a.rates(newAllocStats(&ms, start.Add(time.Second/2)))
+		assert.True(bytes >= 2*n*size, "bytes: %f", bytes)
+		assert.True(objects >= 2*n, "objects: %f", objects)
+		runtime.KeepAlive(bufs)
+	})
+}
+
+func TestReportAllocationRateMetrics(t *testing.T) {
+	var tg testStatsdClient
+	trc := &tracer{
+		stopped:  make(chan struct{}),
+		exitChan: make(chan struct{}),
+		config: &config{
+			statsd:                &tg,
+			allocationRateMetrics: true,
+		},
+	}
+
+	trc.wg.Add(1)
+	go func() {
+		defer trc.wg.Done()
+		trc.reportRuntimeMetrics(time.Millisecond)
+	}()
+	err := tg.Wait(37, time.Second)
+	close(trc.exitChan)
+	trc.wg.Wait()
+	assert := assert.New(t)
+	assert.NoError(err)
+	calls := tg.CallNames()
+	assert.Contains(calls, "runtime.go.mem.alloc_bytes_rate")
+	assert.Contains(calls, "runtime.go.mem.allocs_per_sec")
+}
+
 func TestReportHealthMetrics(t *testing.T) {
 	assert := assert.New(t)
 	var tg testStatsdClient
 	trc := &tracer{
 		config: &config{
-			statsd:    &tg,
-			sampler:   NewAllSampler(),
-			transport: newDummyTransport(),
+			statsd:        &tg,
+			sampler:       NewAllSampler(),
+			transport:     newDummyTransport(),
+			flushInterval: defaultFlushInterval,
 		},
 		payload:          newPayload(),
 		flushChan:        make(chan struct{}),
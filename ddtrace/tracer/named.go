@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+var _ ddtrace.Tracer = (*namedTracer)(nil)
+
+// namedTracer implements ddtrace.Tracer on top of the global tracer, tagging the
+// spans it starts with the name and version of an instrumentation library.
+type namedTracer struct {
+	name    string // instrumentation library name
+	version string // instrumentation library version
+}
+
+// GetTracer returns a tracer scoped to the instrumentation library with the given
+// name and version. Spans started through it are created by the global tracer and
+// carry the "otel.library.name" and "otel.library.version" tags, allowing them to
+// be told apart from the spans created by other libraries. The version is omitted
+// when empty.
+//
+// The returned tracer doesn't own the global tracer: its lifecycle is still
+// controlled using Start and Stop, and calling Stop on the returned tracer has
+// no effect.
+func GetTracer(name, version string) ddtrace.Tracer {
+	return &namedTracer{name: name, version: version}
+}
+
+// StartSpan implements ddtrace.Tracer.
+func (t *namedTracer) StartSpan(operationName string, opts ...ddtrace.StartSpanOption) ddtrace.Span {
+	all := make([]ddtrace.StartSpanOption, 0, len(opts)+2)
+	all = append(all, opts...)
+	all = append(all, Tag(ext.OTELLibraryName, t.name))
+	if t.version != "" {
+		all = append(all, Tag(ext.OTELLibraryVersion, t.version))
+	}
+	return StartSpan(operationName, all...)
+}
+
+// Extract implements ddtrace.Tracer.
+func (t *namedTracer) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	return Extract(carrier)
+}
+
+// Inject implements ddtrace.Tracer.
+func (t *namedTracer) Inject(ctx ddtrace.SpanContext, carrier interface{}) error {
+	return Inject(ctx, carrier)
+}
+
+// Stop implements ddtrace.Tracer. It is a no-op; use the package-level Stop
+// function to stop the global tracer.
+func (t *namedTracer) Stop() {}
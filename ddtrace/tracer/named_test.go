@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTracer(t *testing.T) {
+	Start(withTransport(newDefaultTransport()))
+	defer Stop()
+
+	t.Run("tags", func(t *testing.T) {
+		assert := assert.New(t)
+		tr := GetTracer("github.com/org/lib", "v1.2.3")
+		s := tr.StartSpan("lib.op", ServiceName("lib")).(*span)
+		assert.Equal("lib", s.Service)
+		assert.Equal("github.com/org/lib", s.Meta[ext.OTELLibraryName])
+		assert.Equal("v1.2.3", s.Meta[ext.OTELLibraryVersion])
+
+		child := tr.StartSpan("lib.child", ChildOf(s.Context())).(*span)
+		assert.Equal(s.TraceID, child.TraceID)
+		assert.Equal(s.SpanID, child.ParentID)
+	})
+
+	t.Run("no-version", func(t *testing.T) {
+		s := GetTracer("lib", "").StartSpan("lib.op").(*span)
+		assert.NotContains(t, s.Meta, ext.OTELLibraryVersion)
+	})
+
+	t.Run("propagation", func(t *testing.T) {
+		assert := assert.New(t)
+		tr := GetTracer("lib", "")
+		s := tr.StartSpan("lib.op")
+		carrier := TextMapCarrier{}
+		assert.Nil(tr.Inject(s.Context(), carrier))
+		sctx, err := tr.Extract(carrier)
+		assert.Nil(err)
+		assert.Equal(s.Context().SpanID(), sctx.SpanID())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		GetTracer("lib", "").Stop()
+		_, ok := internal.GetGlobalTracer().(*tracer)
+		assert.True(t, ok)
+	})
+}
@@ -32,6 +32,10 @@ type config struct {
 	// sampler specifies the sampler that will be used for sampling traces.
 	sampler Sampler
 
+	// prioritySampler, when set, decides on the sampling priority of traces
+	// in place of the built-in priority sampling.
+	prioritySampler PrioritySampler
+
 	// agentAddr specifies the hostname and port of the agent where the traces
 	// are sent to.
 	agentAddr string
@@ -46,6 +50,14 @@ type config struct {
 	// propagator propagates span context cross-process
 	propagator Propagator
 
+	// agentlessAPIKey holds the API key used to send traces directly to the
+	// Datadog trace intake. Traces are sent to the agent when empty.
+	agentlessAPIKey string
+
+	// udsPath specifies the unix domain socket used to send traces to the agent.
+	// When empty, traces are sent over TCP to agentAddr.
+	udsPath string
+
 	// httpRoundTripper defines the http.RoundTripper used by the agent transport.
 	httpRoundTripper http.RoundTripper
 
@@ -60,6 +72,14 @@ type config struct {
 	// runtimeMetrics specifies whether collection of runtime metrics is enabled.
 	runtimeMetrics bool
 
+	// gcPauseDistribution specifies whether the durations of GC pauses are
+	// reported as a distribution along with the runtime metrics.
+	gcPauseDistribution bool
+
+	// allocationRateMetrics specifies whether heap allocation rates are reported
+	// along with the runtime metrics.
+	allocationRateMetrics bool
+
 	// dogstatsdAddr specifies the address to connect for sending metrics to the
 	// Datadog Agent. If not set, it defaults to "localhost:8125" or to the
 	// combination of the environment variables DD_AGENT_HOST and DD_DOGSTATSD_PORT.
@@ -68,11 +88,98 @@ type config struct {
 	// statsd is used for tracking metrics associated with the runtime and the tracer.
 	statsd statsdClient
 
+	// propagateB3 specifies whether the default propagator should also use
+	// B3 headers.
+	propagateB3 bool
+
+	// partialFlushMinSpans is the number of finished spans in an unfinished
+	// trace which triggers flushing them. Partial flushing is disabled when zero.
+	partialFlushMinSpans int
+
+	// consistentSampling specifies whether traces are sampled at
+	// consistentSamplingRate based on their trace ID alone.
+	consistentSampling     bool
+	consistentSamplingRate float64
+
+	// errorSamplingRate is the rate at which traces containing errors are
+	// kept, regardless of their sampling priority. Zero disables it.
+	errorSamplingRate float64
+
+	// slowSpanThreshold is the duration from which finished spans cause their
+	// trace to be kept, regardless of its sampling priority. Zero disables it.
+	slowSpanThreshold time.Duration
+
+	// tailSamplingRules holds the rules of the tail sampler. Tail sampling is
+	// disabled when empty.
+	tailSamplingRules []TailSamplingRule
+
+	// tailSamplingBufferSize is the number of completed traces held while
+	// waiting for a tail sampling decision.
+	tailSamplingBufferSize int
+
+	// flushInterval is the interval at which finished traces are flushed to
+	// the agent.
+	flushInterval time.Duration
+
+	// maxBufferSize is the maximum number of finished traces queued to be
+	// encoded into the payload before they are dropped.
+	maxBufferSize int
+
+	// healthCheckInterval is the interval at which the agent is pinged to check
+	// whether it is reachable. Health checks are disabled when it is zero.
+	healthCheckInterval time.Duration
+
+	// spoolDir is the directory in which traces are stored while the agent is
+	// unreachable, to be replayed later. Spooling is disabled when empty.
+	spoolDir string
+
+	// maxSpoolBytes is the maximum total size of the traces held in spoolDir.
+	maxSpoolBytes int64
+
+	// autoContext specifies whether the current span of each goroutine is
+	// tracked, to be used as the parent of the spans it starts.
+	autoContext bool
+
+	// spanStats specifies whether the stats of finished spans are collected,
+	// to be retrieved using Stats.
+	spanStats bool
+
+	// baggageAsTags specifies whether baggage items should be set as tags on
+	// the spans carrying them.
+	baggageAsTags bool
+
 	// samplingRules contains user-defined rules determine the sampling rate to apply
 	// to spans.
 	samplingRules []SamplingRule
 }
 
+// agentSocket returns the unix domain socket to be used for sending traces to
+// the agent, or an empty string if traces should be sent over TCP.
+func (c *config) agentSocket() string {
+	if c.udsPath != "" {
+		return c.udsPath
+	}
+	if c.agentAddr != defaultAddress || c.httpRoundTripper != nil {
+		return ""
+	}
+	if os.Getenv("DD_AGENT_HOST") != "" || os.Getenv("DD_TRACE_AGENT_PORT") != "" {
+		return ""
+	}
+	if _, err := os.Stat(defaultSocketAPM); err == nil {
+		return defaultSocketAPM
+	}
+	return ""
+}
+
+// transportName returns the name of the network transport used to reach the
+// agent, for tagging metrics.
+func (c *config) transportName() string {
+	if c.udsPath != "" {
+		return "uds"
+	}
+	return "tcp"
+}
+
 // StartOption represents a function that can be provided as a parameter to Start.
 type StartOption func(*config)
 
@@ -81,6 +188,10 @@ func defaults(c *config) {
 	c.serviceName = filepath.Base(os.Args[0])
 	c.sampler = NewAllSampler()
 	c.agentAddr = defaultAddress
+	c.gcPauseDistribution = true
+	c.flushInterval = defaultFlushInterval
+	c.maxBufferSize = payloadQueueSize
+	c.maxSpoolBytes = defaultMaxSpoolBytes
 
 	statsdHost, statsdPort := "localhost", "8125"
 	if v := os.Getenv("DD_AGENT_HOST"); v != "" {
@@ -149,12 +260,32 @@ func WithDebugMode(enabled bool) StartOption {
 }
 
 // WithPropagator sets an alternative propagator to be used by the tracer.
+// To handle several formats at once, see CompositePropagator.
 func WithPropagator(p Propagator) StartOption {
 	return func(c *config) {
 		c.propagator = p
 	}
 }
 
+// WithB3Propagation enables propagation using B3 headers (as used by Zipkin and
+// many service meshes) in addition to Datadog headers. The B3 multi-header format
+// is injected, while both the multi and single-header formats are extracted.
+// It has no effect when a propagator is set via WithPropagator.
+func WithB3Propagation() StartOption {
+	return func(c *config) {
+		c.propagateB3 = true
+	}
+}
+
+// WithBaggageAsSpanTags specifies whether baggage items should be set as tags
+// on new spans, making them searchable. Items are set using their key as the tag
+// name, and tags passed when starting the span take precedence over them.
+func WithBaggageAsSpanTags(enabled bool) StartOption {
+	return func(c *config) {
+		c.baggageAsTags = enabled
+	}
+}
+
 // WithServiceName sets the default service name to be used with the tracer.
 func WithServiceName(name string) StartOption {
 	return func(c *config) {
@@ -170,6 +301,30 @@ func WithAgentAddr(addr string) StartOption {
 	}
 }
 
+// WithUDSPath sets the unix domain socket on which the agent receives traces,
+// to be used instead of TCP. It takes precedence over WithAgentAddr and
+// WithHTTPRoundTripper. By default, the socket found at
+// /var/run/datadog/apm.socket is used when it exists, unless an agent address
+// or round tripper is configured, or the DD_AGENT_HOST or DD_TRACE_AGENT_PORT
+// environment variables are set.
+func WithUDSPath(path string) StartOption {
+	return func(c *config) {
+		c.udsPath = path
+	}
+}
+
+// WithAgentlessMode sends traces directly to the Datadog trace intake using the
+// given API key, for environments where no agent can be run. The intake of the
+// Datadog site set in the DD_SITE environment variable is used, defaulting to
+// datadoghq.com. Requests are limited to 100 per second and retried with an
+// exponential backoff when rate limited by the intake. Sampling rates are not
+// received from the agent in this mode.
+func WithAgentlessMode(apiKey string) StartOption {
+	return func(c *config) {
+		c.agentlessAPIKey = apiKey
+	}
+}
+
 // WithEnv sets the environment to which all traces started by the tracer will be submitted.
 // The default value is the environment variable DD_ENV, if it is set.
 func WithEnv(env string) StartOption {
@@ -195,6 +350,181 @@ func WithSampler(s Sampler) StartOption {
 	}
 }
 
+// WithPartialFlush enables flushing the finished spans of a trace before the
+// whole trace has finished, once at least minSpans of them have accumulated.
+// This bounds the memory used by long-running traces, such as batch jobs, and
+// lets their spans show up while they are still in progress. Values smaller
+// than 1 disable partial flushing, which is the default.
+func WithPartialFlush(minSpans int) StartOption {
+	return func(c *config) {
+		if minSpans < 1 {
+			minSpans = 0
+		}
+		c.partialFlushMinSpans = minSpans
+	}
+}
+
+// WithConsistentProbabilisticSampling samples new traces at the given rate
+// (between 0 and 1) using a hash of their trace ID, in place of the sampling
+// rules and the rates provided by the Datadog Agent. Every service configured
+// with the same rate therefore comes to the same decision for a given trace.
+// The decision is propagated to downstream services along with the sampling
+// priority, using the "_dd.p.dm" trace tag. Decisions made by a sampler set via
+// WithPrioritySampler take precedence.
+func WithConsistentProbabilisticSampling(rate float64) StartOption {
+	return func(c *config) {
+		if rate < 0 || rate > 1 {
+			log.Warn("ignoring consistent sampling rate %f: out of range", rate)
+			return
+		}
+		c.consistentSampling = true
+		c.consistentSamplingRate = rate
+	}
+}
+
+// WithErrorSampling keeps traces containing spans with errors at the given rate
+// (between 0 and 1), by upgrading their sampling priority to ext.PriorityUserKeep,
+// even when they would otherwise be dropped by priority sampling. This ensures
+// error traces are available for debugging in services sampled at low rates.
+// Traces with a priority inherited from a remote parent can not be changed; the
+// erroneous span is then tagged with "_dd.p.usr.priority" instead.
+func WithErrorSampling(rate float64) StartOption {
+	return func(c *config) {
+		if rate < 0 || rate > 1 {
+			log.Warn("ignoring error sampling rate %f: out of range", rate)
+			return
+		}
+		c.errorSamplingRate = rate
+	}
+}
+
+// WithSlowSpanSampling keeps traces containing spans which took at least the
+// given threshold to complete, by upgrading their sampling priority to
+// ext.PriorityUserKeep. The duration is measured using the monotonic clock
+// between the calls to StartSpan and Finish, ignoring any custom start or finish
+// times. It can be used together with WithErrorSampling.
+func WithSlowSpanSampling(threshold time.Duration) StartOption {
+	return func(c *config) {
+		c.slowSpanThreshold = threshold
+	}
+}
+
+// WithTailSampling enables tail-based sampling using the given rules. Completed
+// traces are buffered by the tracer until they are flushed, and traces having
+// any span matching any of the rules are always kept, regardless of the sampling
+// decision made when they started. This includes traces rejected by the sampler
+// set via WithSampler, which are otherwise discarded without being completed.
+// See WithTailSamplingBufferSize for controlling how many traces are buffered.
+func WithTailSampling(rules []TailSamplingRule) StartOption {
+	return func(c *config) {
+		c.tailSamplingRules = rules
+	}
+}
+
+// WithTailSamplingBufferSize sets the maximum number of completed traces held
+// by the tracer while waiting for a tail sampling decision. When the buffer is
+// full, the decision is made for the oldest trace to make room for new ones.
+// It defaults to 1000 and has no effect unless WithTailSampling is used.
+func WithTailSamplingBufferSize(size int) StartOption {
+	return func(c *config) {
+		c.tailSamplingBufferSize = size
+	}
+}
+
+// WithFlushInterval sets the interval at which finished traces are flushed to
+// the agent. It defaults to 2 seconds; values lower than or equal to zero are
+// ignored.
+func WithFlushInterval(d time.Duration) StartOption {
+	return func(c *config) {
+		if d > 0 {
+			c.flushInterval = d
+		}
+	}
+}
+
+// WithMaxBufferSize sets the maximum number of finished traces which are queued
+// to be sent to the agent. When the queue is full, new traces are dropped, a
+// flush is triggered and the "datadog.tracer.buffer_overflow" metric is emitted.
+// It defaults to 1000; values lower than 1 are ignored.
+func WithMaxBufferSize(n int) StartOption {
+	return func(c *config) {
+		if n > 0 {
+			c.maxBufferSize = n
+		}
+	}
+}
+
+// WithAgentHealthCheck enables checking whether the agent is reachable by
+// requesting its /info endpoint at the given interval. When it isn't, traces
+// are spooled to disk if WithSpoolDir is used.
+func WithAgentHealthCheck(interval time.Duration) StartOption {
+	return func(c *config) {
+		c.healthCheckInterval = interval
+	}
+}
+
+// WithSpoolDir sets the directory in which traces are stored while the agent
+// is unreachable, or when sending them fails with an error which may not occur
+// again, such as a connection error. They are sent and removed from it once the
+// agent is reachable again, including the ones left in it by a previous run.
+// The directory and the files in it are only accessible by their owner. It has
+// no effect unless WithAgentHealthCheck is used.
+func WithSpoolDir(path string) StartOption {
+	return func(c *config) {
+		c.spoolDir = path
+	}
+}
+
+// WithMaxSpoolBytes sets the maximum total size of the traces held in the spool
+// directory. Traces which would exceed it are dropped. It defaults to 100MB.
+func WithMaxSpoolBytes(n int64) StartOption {
+	return func(c *config) {
+		c.maxSpoolBytes = n
+	}
+}
+
+// WithAutoContext enables tracking the current span of each goroutine, for code
+// bases which don't pass a context.Context down their call stacks. When enabled,
+// a started span becomes the current span of the goroutine which started it
+// until it finishes, at which point the span which was current before it is
+// restored. Spans started without a parent use the current span of their
+// goroutine as their parent, and AutoSpanFromContext returns it.
+//
+// EXPERIMENTAL: this relies on goroutine IDs and is unsound across goroutine
+// boundaries: spans started in new goroutines do not inherit the current span
+// of the goroutine which started them. Spans which are never finished remain
+// referenced forever, along with their trace, leaking memory; this is also the
+// case for the span which was current when a goroutine exits. Prefer passing
+// spans using context.Context whenever possible.
+func WithAutoContext(enabled bool) StartOption {
+	return func(c *config) {
+		c.autoContext = enabled
+	}
+}
+
+// WithSpanStats enables collecting the stats (service, resource, type, error and
+// duration) of every finished span, regardless of its sampling decision, so that
+// they can be retrieved by calling Stats, for example to derive metrics from them.
+// Up to 10000 span stats are held between calls to Stats; further ones are
+// discarded.
+func WithSpanStats(enabled bool) StartOption {
+	return func(c *config) {
+		c.spanStats = enabled
+	}
+}
+
+// WithPrioritySampler sets a custom sampler deciding on the sampling priority
+// of every trace started by this tracer, in place of the built-in priority
+// sampling. When it returns SamplingDecisionDeferToAgent, the sampling rules
+// set via WithSamplingRules and the rates provided by the Datadog Agent are
+// used instead. Traces rejected by the sampler set via WithSampler are never
+// passed to it.
+func WithPrioritySampler(s PrioritySampler) StartOption {
+	return func(c *config) {
+		c.prioritySampler = s
+	}
+}
+
 // WithHTTPRoundTripper allows customizing the underlying HTTP transport for
 // emitting spans. This is useful for advanced customization such as emitting
 // spans to a unix domain socket. The default should be used in most cases.
@@ -234,6 +564,26 @@ func WithRuntimeMetrics() StartOption {
 	}
 }
 
+// WithGCPauseDistribution specifies whether the duration of each GC pause is reported
+// as the "runtime.go.gc_stats.pause_ns" distribution, allowing percentiles to be
+// computed by Datadog. It is enabled by default and is in effect when
+// WithRuntimeMetrics is enabled.
+func WithGCPauseDistribution(enabled bool) StartOption {
+	return func(cfg *config) {
+		cfg.gcPauseDistribution = enabled
+	}
+}
+
+// WithAllocationRateMetrics specifies whether the rates at which bytes and objects
+// are allocated on the heap are reported, as "runtime.go.mem.alloc_bytes_rate" and
+// "runtime.go.mem.allocs_per_sec". They are computed over each reporting interval.
+// This option is in effect when WithRuntimeMetrics is enabled.
+func WithAllocationRateMetrics(enabled bool) StartOption {
+	return func(cfg *config) {
+		cfg.allocationRateMetrics = enabled
+	}
+}
+
 // WithDogstatsdAddress specifies the address to connect to for sending metrics
 // to the Datadog Agent. If not set, it defaults to "localhost:8125" or to the
 // combination of the environment variables DD_AGENT_HOST and DD_DOGSTATSD_PORT.
@@ -267,6 +617,20 @@ func Tag(k string, v interface{}) StartSpanOption {
 	}
 }
 
+// WithConditionalTag sets the tag with the given key on the started span when it
+// finishes, provided that its trace is sampled (i.e. its sampling priority is at
+// least 1), using the value returned by fn. fn is never called for traces which are
+// not sampled, making it suitable for tags which are expensive to compute, such as
+// full query texts or request bodies.
+func WithConditionalTag(key string, fn func(span ddtrace.Span) interface{}) StartSpanOption {
+	return func(cfg *ddtrace.StartSpanConfig) {
+		if cfg.ConditionalTags == nil {
+			cfg.ConditionalTags = map[string]func(ddtrace.Span) interface{}{}
+		}
+		cfg.ConditionalTags[key] = fn
+	}
+}
+
 // ServiceName sets the given service name on the started span. For example "http.server".
 func ServiceName(name string) StartSpanOption {
 	return Tag(ext.ServiceName, name)
@@ -340,6 +704,91 @@ func NoDebugStack() FinishOption {
 	}
 }
 
+// ErrorOption is a configuration option for RecordError. It is aliased in order
+// to help godoc group all the functions returning it together. It is considered
+// more correct to refer to it as the type as the origin, ddtrace.ErrorOption.
+type ErrorOption = ddtrace.ErrorOption
+
+// WithStack specifies whether the stack trace is captured along with the recorded
+// error. It is captured by default.
+func WithStack(enabled bool) ErrorOption {
+	return func(cfg *ddtrace.ErrorConfig) {
+		cfg.NoStack = !enabled
+	}
+}
+
+// WithErrorType sets the given name as the type of the recorded error, in place
+// of the type name of the error.
+func WithErrorType(typeName string) ErrorOption {
+	return func(cfg *ddtrace.ErrorConfig) {
+		cfg.ErrorType = typeName
+	}
+}
+
+// EventOption is a configuration option for AddEvent. It is aliased in order
+// to help godoc group all the functions returning it together. It is considered
+// more correct to refer to it as the type as the origin, ddtrace.EventOption.
+type EventOption = ddtrace.EventOption
+
+// WithTimestamp sets the given time as the time at which the event occurred. By
+// default, the current time is used.
+func WithTimestamp(t time.Time) EventOption {
+	return func(cfg *ddtrace.EventConfig) {
+		cfg.Time = t
+	}
+}
+
+// WithAttributes sets the given attributes on the event.
+func WithAttributes(attrs map[string]interface{}) EventOption {
+	return func(cfg *ddtrace.EventConfig) {
+		cfg.Attributes = attrs
+	}
+}
+
+// UserOption is a configuration option for SetUser. It is aliased in order
+// to help godoc group all the functions returning it together. It is considered
+// more correct to refer to it as the type as the origin, ddtrace.UserOption.
+type UserOption = ddtrace.UserOption
+
+// WithUserEmail sets the user's email as the "usr.email" tag.
+func WithUserEmail(email string) UserOption {
+	return func(cfg *ddtrace.UserConfig) {
+		cfg.Email = email
+	}
+}
+
+// WithUserName sets the user's name as the "usr.name" tag.
+func WithUserName(name string) UserOption {
+	return func(cfg *ddtrace.UserConfig) {
+		cfg.Name = name
+	}
+}
+
+// WithUserRole sets the user's role as the "usr.role" tag.
+func WithUserRole(role string) UserOption {
+	return func(cfg *ddtrace.UserConfig) {
+		cfg.Role = role
+	}
+}
+
+// WithUserScope sets the user's scopes or granted authorizations as the
+// "usr.scope" tag.
+func WithUserScope(scope string) UserOption {
+	return func(cfg *ddtrace.UserConfig) {
+		cfg.Scope = scope
+	}
+}
+
+// WithUserPropagation specifies whether the user ID should be propagated to
+// downstream services using the "x-datadog-user-id" header. Receiving services
+// which enable PropagatorConfig.ExtractUserID set it as the "usr.id" tag on
+// their first span. It is disabled by default.
+func WithUserPropagation(enabled bool) UserOption {
+	return func(cfg *ddtrace.UserConfig) {
+		cfg.Propagate = enabled
+	}
+}
+
 // StackFrames limits the number of stack frames included into erroneous spans to n, starting from skip.
 func StackFrames(n, skip uint) FinishOption {
 	if n == 0 {
@@ -9,6 +9,7 @@ import (
 	"math"
 	"os"
 	"testing"
+	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
@@ -32,6 +33,31 @@ func TestTracerOptionsDefaults(t *testing.T) {
 		assert.Equal("localhost:8126", c.agentAddr)
 		assert.Equal("localhost:8125", c.dogstatsdAddr)
 		assert.Equal(nil, c.httpRoundTripper)
+		assert.True(c.gcPauseDistribution)
+		assert.Equal(2*time.Second, c.flushInterval)
+		assert.Equal(1000, c.maxBufferSize)
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer(WithFlushInterval(time.Second), WithMaxBufferSize(10))
+		defer tracer.Stop()
+		assert.Equal(time.Second, tracer.config.flushInterval)
+		assert.Equal(10, tracer.config.maxBufferSize)
+		assert.Equal(10, cap(tracer.payloadChan))
+
+		var c config
+		defaults(&c)
+		WithFlushInterval(0)(&c)
+		WithMaxBufferSize(-1)(&c)
+		assert.Equal(defaultFlushInterval, c.flushInterval)
+		assert.Equal(payloadQueueSize, c.maxBufferSize)
+	})
+
+	t.Run("gc-pause-distribution", func(t *testing.T) {
+		tracer := newTracer(WithRuntimeMetrics(), WithGCPauseDistribution(false))
+		defer tracer.Stop()
+		assert.False(t, tracer.config.gcPauseDistribution)
 	})
 
 	t.Run("analytics", func(t *testing.T) {
@@ -28,6 +28,32 @@ type Sampler interface {
 	Sample(span Span) bool
 }
 
+// SamplingDecision holds the sampling priority to apply to a trace, such as
+// ext.PriorityUserKeep or ext.PriorityUserReject, along with an optional reason
+// explaining the decision, which is attached to the trace's root span.
+type SamplingDecision struct {
+	// Priority is the sampling priority to apply.
+	Priority int
+
+	// Reason optionally describes why the decision was made.
+	Reason string
+
+	deferToAgent bool
+}
+
+// SamplingDecisionDeferToAgent is returned by a PrioritySampler to leave the
+// decision to the tracer's built-in sampling, which uses the sampling rules
+// and the rates provided by the Datadog Agent.
+var SamplingDecisionDeferToAgent = SamplingDecision{deferToAgent: true}
+
+// PrioritySampler allows implementing custom sampling algorithms which decide
+// on the sampling priority of traces. It must be safe for concurrent use.
+type PrioritySampler interface {
+	// Sample returns the sampling decision for the trace started by the given
+	// root span.
+	Sample(span Span) SamplingDecision
+}
+
 // RateSampler is a sampler implementation which randomly selects spans using a
 // provided rate. For example, a rate of 0.75 will permit 75% of the spans.
 // RateSampler implementations should be safe for concurrent use.
@@ -169,6 +169,79 @@ func TestPrioritySampler(t *testing.T) {
 	})
 }
 
+// decisionSampler is a PrioritySampler deciding based on the operation name.
+type decisionSampler map[string]SamplingDecision
+
+func (ds decisionSampler) Sample(s Span) SamplingDecision {
+	if d, ok := ds[s.(*span).Name]; ok {
+		return d
+	}
+	return SamplingDecisionDeferToAgent
+}
+
+func TestCustomPrioritySampler(t *testing.T) {
+	assert := assert.New(t)
+	os.Setenv("DD_TRACE_SAMPLING_RULES", `[{"name": "rule.op", "sample_rate": 0.0}]`)
+	defer os.Unsetenv("DD_TRACE_SAMPLING_RULES")
+	tracer := newTracer(WithPrioritySampler(decisionSampler{
+		"keep.op":   {Priority: ext.PriorityUserKeep, Reason: "slo"},
+		"reject.op": {Priority: ext.PriorityUserReject},
+	}))
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("keep.op").(*span)
+	assert.EqualValues(ext.PriorityUserKeep, root.Metrics[keySamplingPriority])
+	assert.Equal("slo", root.Meta[keySamplingReason])
+
+	root = tracer.StartSpan("reject.op").(*span)
+	assert.EqualValues(ext.PriorityUserReject, root.Metrics[keySamplingPriority])
+	assert.NotContains(root.Meta, keySamplingReason)
+
+	child := tracer.StartSpan("keep.op", ChildOf(root.Context())).(*span)
+	assert.EqualValues(ext.PriorityUserReject, child.Metrics[keySamplingPriority], "only root spans are sampled")
+
+	// deferring to the built-in sampling applies rules, then agent rates
+	root = tracer.StartSpan("rule.op").(*span)
+	assert.EqualValues(ext.PriorityAutoReject, root.Metrics[keySamplingPriority])
+	assert.Contains(root.Metrics, keyRulesSamplerAppliedRate)
+
+	root = tracer.StartSpan("other.op").(*span)
+	assert.EqualValues(ext.PriorityAutoKeep, root.Metrics[keySamplingPriority])
+	assert.Contains(root.Metrics, keySamplingPriorityRate)
+}
+
+func TestConsistentProbabilisticSampling(t *testing.T) {
+	assert := assert.New(t)
+	upstream := newTracer(WithConsistentProbabilisticSampling(0.5))
+	defer upstream.Stop()
+	other := newTracer(WithConsistentProbabilisticSampling(0.5))
+	defer other.Stop()
+	downstream := newTracer()
+	defer downstream.Stop()
+
+	var kept int
+	for i := uint64(1); i <= 1000; i++ {
+		root := upstream.StartSpan("web.request", WithSpanID(i)).(*span)
+		priority := root.context.samplingPriority()
+		assert.Equal(priority, other.StartSpan("web.request", WithSpanID(i)).(*span).context.samplingPriority(), "same trace ID, same decision")
+		assert.Equal(samplingMechanismRule, root.Meta[keyDecisionMaker])
+		assert.EqualValues(0.5, root.Metrics[keyRulesSamplerAppliedRate])
+		if priority == ext.PriorityAutoKeep {
+			kept++
+		}
+
+		headers := TextMapCarrier{}
+		assert.NoError(upstream.Inject(root.Context(), headers))
+		assert.Equal("_dd.p.dm=-3", headers[traceTagsHeader])
+		ctx, err := downstream.Extract(headers)
+		assert.NoError(err)
+		child := downstream.StartSpan("db.query", ChildOf(ctx)).(*span)
+		assert.Equal(priority, child.context.samplingPriority(), "downstream uses the propagated decision")
+		assert.Equal(samplingMechanismRule, child.Meta[keyDecisionMaker])
+	}
+	assert.InDelta(500, kept, 100)
+}
+
 func TestRateSampler(t *testing.T) {
 	assert := assert.New(t)
 	assert.True(NewRateSampler(1).Sample(newBasicSpan("test")))
@@ -10,15 +10,14 @@ package tracer
 import (
 	"fmt"
 	"reflect"
-	"runtime"
 	"runtime/debug"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 
 	"github.com/tinylib/msgp/msgp"
 	"golang.org/x/xerrors"
@@ -39,6 +38,10 @@ var (
 	_ msgp.Decodable = (*spanLists)(nil)
 )
 
+// defaultErrorStackFrames is the maximum number of frames captured in the
+// error.stack tag when an error is set on a span using SetTag or RecordError.
+const defaultErrorStackFrames = internal.DefaultErrorStackFrames
+
 // errorConfig holds customization options for setting error tags.
 type errorConfig struct {
 	noDebugStack bool
@@ -64,9 +67,26 @@ type span struct {
 	ParentID uint64             `msg:"parent_id"`         // identifier of the span's direct parent
 	Error    int32              `msg:"error"`             // error status of the span; 0 means no errors
 
+	SpanLinks  []ddtrace.SpanLink  `msg:"span_links"` // links to spans in other traces
+	SpanEvents []ddtrace.SpanEvent `msg:"span_log"`   // time-stamped events which occurred during the span
+
 	finished bool         `msg:"-"` // true if the span has been submitted to a tracer.
 	context  *spanContext `msg:"-"` // span propagation context
 	taskEnd  func()       // ends execution tracer (runtime/trace) task, if started
+
+	// startMono holds the time at which the span was started, including a
+	// monotonic clock reading. It is only set when slow span sampling is enabled.
+	startMono time.Time
+
+	// goid holds the ID of the goroutine of which the span is the current span,
+	// when WithAutoContext is enabled. autoParent holds the span which was current
+	// before it, and which becomes current again once it finishes.
+	goid       int64
+	autoParent *span
+
+	// conditionalTags holds the tags set when the span finishes if its trace
+	// is sampled, see WithConditionalTag.
+	conditionalTags map[string]func(ddtrace.Span) interface{}
 }
 
 // Context yields the SpanContext for this Span. Note that the return
@@ -87,6 +107,58 @@ func (s *span) BaggageItem(key string) string {
 	return s.context.baggageItem(key)
 }
 
+// AddLink links this span to the span described by link, which usually belongs
+// to a different trace. Links added after the span has finished are ignored.
+func (s *span) AddLink(link ddtrace.SpanLink) {
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
+	s.SpanLinks = append(s.SpanLinks, link)
+}
+
+// AddEvent adds a time-stamped event with the given name to the span. Attribute
+// values which are not strings, booleans or numbers are converted to strings.
+// Events added after the span has finished are ignored.
+func (s *span) AddEvent(name string, opts ...ddtrace.EventOption) {
+	var cfg ddtrace.EventConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.Time.IsZero() {
+		cfg.Time = time.Now()
+	}
+	event := ddtrace.SpanEvent{
+		Name:         name,
+		TimeUnixNano: uint64(cfg.Time.UnixNano()),
+	}
+	if len(cfg.Attributes) > 0 {
+		event.Attributes = make(map[string]interface{}, len(cfg.Attributes))
+		for k, v := range cfg.Attributes {
+			event.Attributes[k] = eventAttribute(v)
+		}
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
+	s.SpanEvents = append(s.SpanEvents, event)
+}
+
+// eventAttribute returns v in a form which can be encoded as a span event attribute.
+func eventAttribute(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string, bool:
+		return v
+	}
+	if f, ok := toFloat64(v); ok {
+		return f
+	}
+	return fmt.Sprint(v)
+}
+
 // SetTag adds a set of key/value metadata to the span.
 func (s *span) SetTag(key string, value interface{}) {
 	s.Lock()
@@ -99,7 +171,8 @@ func (s *span) SetTag(key string, value interface{}) {
 	}
 	switch key {
 	case ext.Error:
-		s.setTagError(value, &errorConfig{})
+		// skip setTagError and SetTag so that the stack starts at the caller
+		s.setTagError(value, &errorConfig{stackFrames: defaultErrorStackFrames, stackSkip: 2})
 		return
 	}
 	if v, ok := value.(bool); ok {
@@ -118,6 +191,71 @@ func (s *span) SetTag(key string, value interface{}) {
 	s.setMeta(key, fmt.Sprint(value))
 }
 
+// SetMetric sets the given numeric metric on the span.
+func (s *span) SetMetric(key string, value float64) {
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
+	s.setMetric(key, value)
+}
+
+// RecordError marks the span as having had the given error, setting the error
+// message, type and stack trace tags. It has no effect if err is nil.
+func (s *span) RecordError(err error, opts ...ddtrace.ErrorOption) {
+	if err == nil {
+		return
+	}
+	var cfg ddtrace.ErrorConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
+	s.setTagError(err, &errorConfig{
+		noDebugStack: cfg.NoStack,
+		stackFrames:  defaultErrorStackFrames,
+		stackSkip:    2,
+	})
+	if cfg.ErrorType != "" {
+		s.setMeta(ext.ErrorType, cfg.ErrorType)
+	}
+}
+
+// SetUser associates the span with the user identified by id, setting the
+// "usr.id" tag along with any of the other "usr.*" tags given as options.
+func (s *span) SetUser(id string, opts ...ddtrace.UserOption) {
+	var cfg ddtrace.UserConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
+	s.setMeta(ext.UserID, id)
+	if cfg.Email != "" {
+		s.setMeta(ext.UserEmail, cfg.Email)
+	}
+	if cfg.Name != "" {
+		s.setMeta(ext.UserName, cfg.Name)
+	}
+	if cfg.Role != "" {
+		s.setMeta(ext.UserRole, cfg.Role)
+	}
+	if cfg.Scope != "" {
+		s.setMeta(ext.UserScope, cfg.Scope)
+	}
+	if cfg.Propagate {
+		s.context.setUserID(id)
+	}
+}
+
 // setTagError sets the error tag. It accounts for various valid scenarios.
 // This method is not safe for concurrent use.
 func (s *span) setTagError(value interface{}, cfg *errorConfig) {
@@ -142,7 +280,7 @@ func (s *span) setTagError(value interface{}, cfg *errorConfig) {
 			if cfg.stackFrames == 0 {
 				s.setMeta(ext.ErrorStack, string(debug.Stack()))
 			} else {
-				s.setMeta(ext.ErrorStack, takeStacktrace(cfg.stackFrames, cfg.stackSkip))
+				s.setMeta(ext.ErrorStack, internal.TakeStacktrace(cfg.stackFrames, cfg.stackSkip))
 			}
 		}
 		switch v.(type) {
@@ -160,35 +298,37 @@ func (s *span) setTagError(value interface{}, cfg *errorConfig) {
 		// is the result of an error.
 		s.Error = 1
 	}
+	if s.Error == 1 {
+		s.sampleError()
+	}
 }
 
-// takeStacktrace takes stacktrace
-func takeStacktrace(n, skip uint) string {
-	var builder strings.Builder
-	pcs := make([]uintptr, n)
-
-	// +2 to exclude runtime.Callers and takeStacktrace
-	numFrames := runtime.Callers(2+int(skip), pcs)
-	if numFrames == 0 {
-		return ""
-	}
-	frames := runtime.CallersFrames(pcs[:numFrames])
-	for i := 0; ; i++ {
-		frame, more := frames.Next()
-		if i != 0 {
-			builder.WriteByte('\n')
-		}
-		builder.WriteString(frame.Function)
-		builder.WriteByte('\n')
-		builder.WriteByte('\t')
-		builder.WriteString(frame.File)
-		builder.WriteByte(':')
-		builder.WriteString(strconv.Itoa(frame.Line))
-		if !more {
-			break
-		}
+// sampleError upgrades the trace's sampling priority to ext.PriorityUserKeep
+// when error sampling is enabled and the trace is selected at the configured
+// rate. Callers must hold the span's lock.
+func (s *span) sampleError() {
+	tr, ok := internal.GetGlobalTracer().(*tracer)
+	if !ok || tr.config.errorSamplingRate <= 0 {
+		return
+	}
+	if s.context == nil || s.context.trace == nil {
+		return
+	}
+	if tr.config.errorSamplingRate < 1 && random.Float64() >= tr.config.errorSamplingRate {
+		return
+	}
+	s.context.trace.keep(s)
+}
+
+// sampleSlow upgrades the trace's sampling priority to ext.PriorityUserKeep when
+// the span's duration d reaches the slow span sampling threshold. Callers must
+// hold the span's lock.
+func (s *span) sampleSlow(d time.Duration) {
+	tr, ok := internal.GetGlobalTracer().(*tracer)
+	if !ok || tr.config.slowSpanThreshold <= 0 || d < tr.config.slowSpanThreshold {
+		return
 	}
-	return builder.String()
+	s.context.trace.keep(s)
 }
 
 // setMeta sets a string tag. This method is not safe for concurrent use.
@@ -280,6 +420,38 @@ func (s *span) Finish(opts ...ddtrace.FinishOption) {
 	s.finish(t)
 }
 
+// setConditionalTags sets the tags given using WithConditionalTag if the trace may
+// be kept. It is called after the span's sampling priority has been upgraded by
+// error or slow span sampling, and before the span is marked as finished. The tag
+// functions are called without holding the lock of s, as they are given the span.
+func (s *span) setConditionalTags() {
+	s.Lock()
+	tags := s.conditionalTags
+	s.conditionalTags = nil
+	keep := !s.finished && s.mayBeKept()
+	s.Unlock()
+	if !keep {
+		return
+	}
+	for k, fn := range tags {
+		s.SetTag(k, fn(s))
+	}
+}
+
+// mayBeKept reports whether the trace of s may be kept, given its current
+// sampling priority. All traces may be kept when tail sampling is enabled,
+// as the tail sampler can keep traces which were otherwise rejected. Callers
+// must hold the span's lock.
+func (s *span) mayBeKept() bool {
+	if tr, ok := internal.GetGlobalTracer().(*tracer); ok && tr.tailSampling != nil {
+		return true
+	}
+	if s.context.drop {
+		return false
+	}
+	return !s.context.hasSamplingPriority() || s.context.samplingPriority() >= ext.PriorityAutoKeep
+}
+
 // SetOperationName sets or changes the operation name.
 func (s *span) SetOperationName(operationName string) {
 	s.Lock()
@@ -290,22 +462,42 @@ func (s *span) SetOperationName(operationName string) {
 
 func (s *span) finish(finishTime int64) {
 	s.Lock()
-	defer s.Unlock()
 	// We don't lock spans when flushing, so we could have a data race when
 	// modifying a span as it's being flushed. This protects us against that
 	// race, since spans are marked `finished` before we flush them.
 	if s.finished {
 		// already finished
+		s.Unlock()
 		return
 	}
 	if s.Duration == 0 {
 		s.Duration = finishTime - s.Start
 	}
+	if !s.startMono.IsZero() {
+		s.sampleSlow(time.Since(s.startMono))
+	}
+	s.Unlock()
+	s.setConditionalTags()
+
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
 	s.finished = true
+	if s.goid != 0 {
+		releaseAutoSpan(s)
+	}
+	if tr, ok := internal.GetGlobalTracer().(*tracer); ok && tr.stats != nil {
+		tr.stats.add(s)
+	}
 
 	if s.context.drop {
-		// not sampled by local sampler
-		return
+		// not sampled by local sampler; the trace is only completed when
+		// the tail sampler may still decide to keep it.
+		if tr, ok := internal.GetGlobalTracer().(*tracer); !ok || tr.tailSampling == nil {
+			return
+		}
 	}
 	s.context.finish()
 }
@@ -344,4 +536,7 @@ const (
 	keyHostname                = "_dd.hostname"
 	keyRulesSamplerAppliedRate = "_dd.rule_psr"
 	keyRulesSamplerLimiterRate = "_dd.limit_psr"
+	keySamplingReason          = "_dd.sampling_reason"
+	keyUserPriority            = "_dd.p.usr.priority"
+	keyDecisionMaker           = "_dd.p.dm"
 )
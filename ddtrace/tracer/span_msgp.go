@@ -10,6 +10,8 @@ package tracer
 // DO NOT EDIT
 
 import (
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+
 	"github.com/tinylib/msgp/msgp"
 )
 
@@ -133,6 +135,40 @@ func (z *span) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "span_links":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.SpanLinks) >= int(zb0004) {
+				z.SpanLinks = (z.SpanLinks)[:zb0004]
+			} else {
+				z.SpanLinks = make([]ddtrace.SpanLink, zb0004)
+			}
+			for za0005 := range z.SpanLinks {
+				err = z.SpanLinks[za0005].DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
+		case "span_log":
+			var zb0005 uint32
+			zb0005, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.SpanEvents) >= int(zb0005) {
+				z.SpanEvents = (z.SpanEvents)[:zb0005]
+			} else {
+				z.SpanEvents = make([]ddtrace.SpanEvent, zb0005)
+			}
+			for za0006 := range z.SpanEvents {
+				err = z.SpanEvents[za0006].DecodeMsg(dc)
+				if err != nil {
+					return
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -145,9 +181,9 @@ func (z *span) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *span) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 12
+	// map header, size 14
 	// write "name"
-	err = en.Append(0x8c, 0xa4, 0x6e, 0x61, 0x6d, 0x65)
+	err = en.Append(0x8e, 0xa4, 0x6e, 0x61, 0x6d, 0x65)
 	if err != nil {
 		return
 	}
@@ -274,6 +310,36 @@ func (z *span) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "span_links"
+	err = en.Append(0xaa, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x6c, 0x69, 0x6e, 0x6b, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.SpanLinks)))
+	if err != nil {
+		return
+	}
+	for za0005 := range z.SpanLinks {
+		err = z.SpanLinks[za0005].EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
+	// write "span_log"
+	err = en.Append(0xa8, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x6c, 0x6f, 0x67)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.SpanEvents)))
+	if err != nil {
+		return
+	}
+	for za0006 := range z.SpanEvents {
+		err = z.SpanEvents[za0006].EncodeMsg(en)
+		if err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -293,7 +359,14 @@ func (z *span) Msgsize() (s int) {
 			s += msgp.StringPrefixSize + len(za0003) + msgp.Float64Size
 		}
 	}
-	s += 8 + msgp.Uint64Size + 9 + msgp.Uint64Size + 10 + msgp.Uint64Size + 6 + msgp.Int32Size
+	s += 8 + msgp.Uint64Size + 9 + msgp.Uint64Size + 10 + msgp.Uint64Size + 6 + msgp.Int32Size + 11 + msgp.ArrayHeaderSize
+	for za0005 := range z.SpanLinks {
+		s += z.SpanLinks[za0005].Msgsize()
+	}
+	s += 9 + msgp.ArrayHeaderSize
+	for za0006 := range z.SpanEvents {
+		s += z.SpanEvents[za0006].Msgsize()
+	}
 	return
 }
 
@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 
 	"github.com/stretchr/testify/assert"
@@ -216,6 +217,223 @@ func TestSpanSetDatadogTags(t *testing.T) {
 	assert.Equal("SELECT * FROM users;", span.Resource)
 }
 
+func TestSpanLinks(t *testing.T) {
+	assert := assert.New(t)
+	span := newBasicSpan("web.request")
+	link := ddtrace.SpanLink{
+		TraceID:     1,
+		TraceIDHigh: 2,
+		SpanID:      3,
+		Attributes:  map[string]string{"reason": "batch"},
+		Tracestate:  "dd=s:1",
+		Flags:       1<<31 | 1,
+	}
+	span.AddLink(link)
+	span.AddLink(ddtrace.SpanLink{TraceID: 4, SpanID: 5})
+	span.Finish()
+	span.AddLink(ddtrace.SpanLink{TraceID: 6, SpanID: 7})
+	assert.Len(span.SpanLinks, 2)
+
+	t.Run("round-trip", func(t *testing.T) {
+		p := newPayload()
+		assert.NoError(p.push(spanList{span}))
+		traces, err := decode(p)
+		assert.NoError(err)
+		assert.Len(traces, 1)
+		assert.Len(traces[0], 1)
+		got := traces[0][0]
+		assert.Equal(span.SpanID, got.SpanID)
+		assert.Equal([]ddtrace.SpanLink{link, {TraceID: 4, SpanID: 5}}, got.SpanLinks)
+	})
+}
+
+func TestSpanConditionalTags(t *testing.T) {
+	tracer, _, stop := startTestTracer()
+	defer stop()
+
+	var calls int
+	fn := func(s ddtrace.Span) interface{} {
+		calls++
+		s.SetTag("evaluated", true) // must not deadlock
+		return "SELECT * FROM users WHERE id = 1"
+	}
+
+	t.Run("sampled", func(t *testing.T) {
+		assert := assert.New(t)
+		calls = 0
+		s := tracer.StartSpan("db.query", WithConditionalTag(ext.SQLQuery, fn)).(*span)
+		_, ok := s.Meta[ext.SQLQuery]
+		assert.False(ok)
+		s.Finish()
+		s.Finish()
+		assert.Equal(1, calls)
+		assert.Equal("SELECT * FROM users WHERE id = 1", s.Meta[ext.SQLQuery])
+		assert.Equal("true", s.Meta["evaluated"])
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		assert := assert.New(t)
+		calls = 0
+		root := tracer.StartSpan("web.request", Tag(ext.SamplingPriority, ext.PriorityUserReject)).(*span)
+		child := tracer.StartSpan("db.query", ChildOf(root.Context()), WithConditionalTag(ext.SQLQuery, fn)).(*span)
+		child.Finish()
+		root.Finish()
+		assert.Equal(0, calls)
+		_, ok := child.Meta[ext.SQLQuery]
+		assert.False(ok)
+	})
+
+	t.Run("kept-later", func(t *testing.T) {
+		calls = 0
+		s := tracer.StartSpan("db.query", Tag(ext.SamplingPriority, ext.PriorityUserReject), WithConditionalTag(ext.SQLQuery, fn)).(*span)
+		s.SetTag(ext.ManualKeep, true)
+		s.Finish()
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("slow-kept", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, stop := startTestTracer(WithSlowSpanSampling(10 * time.Millisecond))
+		defer stop()
+
+		calls = 0
+		root := tracer.StartSpan("web.request", Tag(ext.SamplingPriority, ext.PriorityAutoReject)).(*span)
+		child := tracer.StartSpan("db.query", ChildOf(root.Context()), WithConditionalTag(ext.SQLQuery, fn)).(*span)
+		time.Sleep(15 * time.Millisecond)
+		child.Finish()
+		root.Finish()
+		assert.Equal(1, calls)
+		assert.Equal("SELECT * FROM users WHERE id = 1", child.Meta[ext.SQLQuery])
+	})
+
+	t.Run("tail-sampling", func(t *testing.T) {
+		tracer, _, stop := startTestTracer(
+			WithSampler(NewRateSampler(0)),
+			WithTailSampling([]TailSamplingRule{{Service: "db"}}),
+		)
+		defer stop()
+
+		calls = 0
+		s := tracer.StartSpan("db.query", ServiceName("db"), WithConditionalTag(ext.SQLQuery, fn)).(*span)
+		assert.True(t, s.context.drop)
+		s.Finish()
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestSpanSetUser(t *testing.T) {
+	assert := assert.New(t)
+	span := newBasicSpan("web.request")
+	span.SetUser("user-1",
+		WithUserEmail("user@example.com"),
+		WithUserName("User"),
+		WithUserRole("admin"),
+		WithUserScope("read:all"),
+	)
+	assert.Equal("user-1", span.Meta[ext.UserID])
+	assert.Equal("user@example.com", span.Meta[ext.UserEmail])
+	assert.Equal("User", span.Meta[ext.UserName])
+	assert.Equal("admin", span.Meta[ext.UserRole])
+	assert.Equal("read:all", span.Meta[ext.UserScope])
+	assert.Equal("", span.context.userID())
+
+	span = newBasicSpan("web.request")
+	span.SetUser("user-2", WithUserPropagation(true))
+	assert.Equal("user-2", span.Meta[ext.UserID])
+	assert.NotContains(span.Meta, ext.UserEmail)
+	assert.Equal("user-2", span.context.userID())
+
+	span.Finish()
+	span.SetUser("user-3")
+	assert.Equal("user-2", span.Meta[ext.UserID])
+}
+
+func TestSpanRecordError(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		assert := assert.New(t)
+		span := newBasicSpan("web.request")
+		span.RecordError(errors.New("abc"))
+		assert.Equal(int32(1), span.Error)
+		assert.Equal("abc", span.Meta[ext.ErrorMsg])
+		assert.Equal("*errors.errorString", span.Meta[ext.ErrorType])
+		assert.Contains(span.Meta[ext.ErrorStack], "TestSpanRecordError")
+	})
+
+	t.Run("options", func(t *testing.T) {
+		assert := assert.New(t)
+		span := newBasicSpan("web.request")
+		span.RecordError(errors.New("abc"), WithStack(false), WithErrorType("timeout"))
+		assert.Equal(int32(1), span.Error)
+		assert.Equal("abc", span.Meta[ext.ErrorMsg])
+		assert.Equal("timeout", span.Meta[ext.ErrorType])
+		_, ok := span.Meta[ext.ErrorStack]
+		assert.False(ok)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		assert := assert.New(t)
+		span := newBasicSpan("web.request")
+		allocs := testing.AllocsPerRun(100, func() {
+			span.RecordError(nil)
+		})
+		assert.Zero(allocs)
+		assert.Equal(int32(0), span.Error)
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		assert := assert.New(t)
+		span := newBasicSpan("web.request")
+		span.Finish()
+		span.RecordError(errors.New("abc"))
+		assert.Equal(int32(0), span.Error)
+	})
+}
+
+func TestSpanEvents(t *testing.T) {
+	assert := assert.New(t)
+	span := newBasicSpan("web.request")
+	ts := time.Unix(1600000000, 42)
+	span.AddEvent("retry", WithTimestamp(ts), WithAttributes(map[string]interface{}{
+		"attempt": 2,
+		"reason":  "timeout",
+		"final":   false,
+		"backoff": 150 * time.Millisecond,
+	}))
+	before := time.Now()
+	span.AddEvent("checkpoint")
+	span.Finish()
+	span.AddEvent("late")
+	assert.Len(span.SpanEvents, 2)
+
+	retry := ddtrace.SpanEvent{
+		Name:         "retry",
+		TimeUnixNano: uint64(ts.UnixNano()),
+		Attributes: map[string]interface{}{
+			"attempt": 2.0,
+			"reason":  "timeout",
+			"final":   false,
+			"backoff": "150ms",
+		},
+	}
+	assert.Equal(retry, span.SpanEvents[0])
+	assert.Equal("checkpoint", span.SpanEvents[1].Name)
+	assert.True(span.SpanEvents[1].TimeUnixNano >= uint64(before.UnixNano()))
+	assert.Nil(span.SpanEvents[1].Attributes)
+
+	t.Run("round-trip", func(t *testing.T) {
+		p := newPayload()
+		assert.NoError(p.push(spanList{span}))
+		traces, err := decode(p)
+		assert.NoError(err)
+		assert.Len(traces, 1)
+		assert.Len(traces[0], 1)
+		got := traces[0][0]
+		assert.Len(got.SpanEvents, 2)
+		assert.Equal(retry, got.SpanEvents[0])
+		assert.Equal("checkpoint", got.SpanEvents[1].Name)
+	})
+}
+
 func TestSpanStart(t *testing.T) {
 	assert := assert.New(t)
 	tracer := newTracer(withTransport(newDefaultTransport()))
@@ -282,6 +500,23 @@ func TestSpanSetMetric(t *testing.T) {
 			_, ok := span.Metrics["finished.test"]
 			assert.False(ok)
 		},
+		"method": func(assert *assert.Assertions, span *span) {
+			span.SetMetric("db.row_count", 42)
+			assert.Equal(42.0, span.Metrics["db.row_count"])
+			_, ok := span.Meta["db.row_count"]
+			assert.False(ok)
+		},
+		"method-priority": func(assert *assert.Assertions, span *span) {
+			span.SetMetric(ext.SamplingPriority, ext.PriorityUserKeep)
+			assert.Equal(float64(ext.PriorityUserKeep), span.Metrics[keySamplingPriority])
+			assert.Equal(ext.PriorityUserKeep, span.context.samplingPriority())
+		},
+		"method-finished": func(assert *assert.Assertions, span *span) {
+			span.Finish()
+			span.SetMetric("cache.hit_ratio", 0.5)
+			_, ok := span.Metrics["cache.hit_ratio"]
+			assert.False(ok)
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
@@ -301,7 +536,7 @@ func TestSpanError(t *testing.T) {
 	err := errors.New("Something wrong")
 	span.SetTag(ext.Error, err)
 	assert.Equal(int32(1), span.Error)
-	assert.Equal("Something wrong", span.Meta["error.msg"])
+	assert.Equal("Something wrong", span.Meta["error.message"])
 	assert.Equal("*errors.errorString", span.Meta["error.type"])
 	assert.NotEqual("", span.Meta["error.stack"])
 
@@ -312,7 +547,7 @@ func TestSpanError(t *testing.T) {
 	span.SetTag(ext.Error, err)
 	assert.Equal(int32(0), span.Error)
 	assert.Equal(nMeta, len(span.Meta))
-	assert.Equal("", span.Meta["error.msg"])
+	assert.Equal("", span.Meta["error.message"])
 	assert.Equal("", span.Meta["error.type"])
 	assert.Equal("", span.Meta["error.stack"])
 }
@@ -326,7 +561,7 @@ func TestSpanError_Typed(t *testing.T) {
 	err := &boomError{}
 	span.SetTag(ext.Error, err)
 	assert.Equal(int32(1), span.Error)
-	assert.Equal("boom", span.Meta["error.msg"])
+	assert.Equal("boom", span.Meta["error.message"])
 	assert.Equal("*tracer.boomError", span.Meta["error.type"])
 	assert.NotEqual("", span.Meta["error.stack"])
 }
@@ -343,6 +578,32 @@ func TestSpanErrorNil(t *testing.T) {
 	assert.Equal(nMeta, len(span.Meta))
 }
 
+func TestSpanErrorStack(t *testing.T) {
+	tracer := newTracer(withTransport(newDefaultTransport()))
+
+	t.Run("caller", func(t *testing.T) {
+		span := tracer.newRootSpan("pylons.request", "pylons", "/")
+		span.SetTag(ext.Error, errors.New("abc"))
+		stack := span.Meta["error.stack"]
+		assert.True(t, strings.HasPrefix(stack, "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer.TestSpanErrorStack"))
+		assert.NotContains(t, stack, "setTagError")
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		span := tracer.newRootSpan("pylons.request", "pylons", "/")
+		var recurse func(n int)
+		recurse = func(n int) {
+			if n == 0 {
+				span.SetTag(ext.Error, errors.New("abc"))
+				return
+			}
+			recurse(n - 1)
+		}
+		recurse(2 * defaultErrorStackFrames)
+		assert.Equal(t, defaultErrorStackFrames, strings.Count(span.Meta["error.stack"], "\n\t"))
+	})
+}
+
 // Prior to a bug fix, this failed when running `go test -race`
 func TestSpanModifyWhileFlushing(t *testing.T) {
 	tracer, _, stop := startTestTracer()
@@ -6,10 +6,12 @@
 package tracer
 
 import (
+	"strconv"
 	"sync"
 	"sync/atomic"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
@@ -32,6 +34,16 @@ type spanContext struct {
 	traceID uint64
 	spanID  uint64
 
+	// traceIDHigh holds the upper 64 bits of a 128-bit trace ID and tracestate
+	// holds vendor-specific trace data, when these were received via W3C trace
+	// context headers. They are propagated unchanged.
+	traceIDHigh uint64
+	tracestate  string
+
+	// tags holds tags extracted from a carrier, to be set on the first local
+	// span started from this context. It is not modified once extracted.
+	tags map[string]string
+
 	mu      sync.RWMutex // guards below fields
 	baggage map[string]string
 	origin  string // e.g. "synthetics"
@@ -52,6 +64,8 @@ func newSpanContext(span *span, parent *spanContext) *spanContext {
 		context.trace = parent.trace
 		context.drop = parent.drop
 		context.origin = parent.origin
+		context.traceIDHigh = parent.traceIDHigh
+		context.tracestate = parent.tracestate
 		parent.ForeachBaggageItem(func(k, v string) bool {
 			context.setBaggageItem(k, v)
 			return true
@@ -104,6 +118,34 @@ func (c *spanContext) hasSamplingPriority() bool {
 	return c.trace != nil && c.trace.hasSamplingPriority()
 }
 
+func (c *spanContext) setDecisionMaker(dm string) {
+	if c.trace == nil {
+		c.trace = newTrace()
+	}
+	c.trace.setDecisionMaker(dm)
+}
+
+func (c *spanContext) decisionMaker() string {
+	if c.trace == nil {
+		return ""
+	}
+	return c.trace.getDecisionMaker()
+}
+
+func (c *spanContext) setUserID(id string) {
+	if c.trace == nil {
+		c.trace = newTrace()
+	}
+	c.trace.setUserID(id)
+}
+
+func (c *spanContext) userID() string {
+	if c.trace == nil {
+		return ""
+	}
+	return c.trace.getUserID()
+}
+
 func (c *spanContext) setBaggageItem(key, val string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -131,6 +173,8 @@ type trace struct {
 	finished int          // the number of finished spans
 	full     bool         // signifies that the span buffer is full
 	priority *float64     // sampling priority
+	dm       string       // sampling decision maker, propagated as "_dd.p.dm"
+	uid      string       // user ID, propagated as "x-datadog-user-id" when set
 	locked   bool         // specifies if the sampling priority can be altered
 
 	// root specifies the root of the trace, if known; it is nil when a span
@@ -173,6 +217,30 @@ func (t *trace) samplingPriority() int {
 	return int(*t.priority)
 }
 
+func (t *trace) setDecisionMaker(dm string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dm = dm
+}
+
+func (t *trace) getDecisionMaker() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.dm
+}
+
+func (t *trace) setUserID(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.uid = id
+}
+
+func (t *trace) getUserID() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.uid
+}
+
 func (t *trace) setSamplingPriority(p float64) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -194,6 +262,24 @@ func (t *trace) setSamplingPriorityLocked(p float64) {
 	*t.priority = p
 }
 
+// keep upgrades the trace's sampling priority to ext.PriorityUserKeep on behalf
+// of the span s, unless it already has a higher priority. When the priority can
+// no longer be changed, because it was inherited from a remote parent or because
+// the local root has already finished, the decision is recorded on s using the
+// keyUserPriority tag instead. Callers must hold the lock of s.
+func (t *trace) keep(s *span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.priority != nil && *t.priority >= ext.PriorityUserKeep {
+		return
+	}
+	if t.locked {
+		s.setMeta(keyUserPriority, strconv.Itoa(ext.PriorityUserKeep))
+		return
+	}
+	t.setSamplingPriorityLocked(ext.PriorityUserKeep)
+}
+
 // push pushes a new span into the trace. If the buffer is full, it returns
 // a errBufferFull error.
 func (t *trace) push(sp *span) {
@@ -243,10 +329,14 @@ func (t *trace) finishedOne(s *span) {
 		t.root.setMetric(keySamplingPriority, *t.priority)
 		t.locked = true
 	}
+	tr, haveTracer := internal.GetGlobalTracer().(*tracer)
 	if len(t.spans) != t.finished {
+		if haveTracer && tr.config.partialFlushMinSpans > 0 {
+			t.partialFlush(tr, s)
+		}
 		return
 	}
-	if tr, ok := internal.GetGlobalTracer().(*tracer); ok {
+	if haveTracer {
 		// we have a tracer that can receive completed traces.
 		tr.pushTrace(t.spans)
 		atomic.AddInt64(&tr.spansFinished, int64(len(t.spans)))
@@ -254,3 +344,29 @@ func (t *trace) finishedOne(s *span) {
 	t.spans = nil
 	t.finished = 0 // important, because a buffer can be used for several flushes
 }
+
+// partialFlush moves the newly finished span s to the front of the buffer, so
+// that the first t.finished spans are always the finished ones. Once the number
+// of finished spans reaches the configured threshold, they are flushed to the
+// given tracer and removed from the buffer. The first span of each flushed chunk
+// carries the trace's sampling priority, as known at the time of flushing.
+// It must be called while holding t.mu.
+func (t *trace) partialFlush(tr *tracer, s *span) {
+	for i := t.finished - 1; i < len(t.spans); i++ {
+		if t.spans[i] == s {
+			t.spans[i], t.spans[t.finished-1] = t.spans[t.finished-1], t.spans[i]
+			break
+		}
+	}
+	if t.finished < tr.config.partialFlushMinSpans {
+		return
+	}
+	flushed := t.spans[:t.finished]
+	if t.priority != nil {
+		flushed[0].setMetric(keySamplingPriority, *t.priority)
+	}
+	t.spans = append(make([]*span, 0, len(t.spans)-t.finished+traceStartSize), t.spans[t.finished:]...)
+	t.finished = 0
+	tr.pushTrace(flushed)
+	atomic.AddInt64(&tr.spansFinished, int64(len(flushed)))
+}
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// defaultMaxSpoolBytes is the default maximum total size of the spooled payloads.
+const defaultMaxSpoolBytes = 100 * 1024 * 1024 // 100 MB
+
+// spoolFileExt is the extension of the files holding spooled payloads. Their
+// name is made of the time at which they were written and the number of traces
+// they contain, e.g. "1600000000000000000-12.spool".
+const spoolFileExt = ".spool"
+
+// rejectedFileExt is appended to the name of spooled payload files which the
+// agent rejected when they were replayed. They are kept for inspection, but
+// are not replayed again nor accounted for in the spool size.
+const rejectedFileExt = ".rejected"
+
+// errSpoolFull is returned when writing a payload would exceed the spool size limit.
+var errSpoolFull = errors.New("spool is full")
+
+// pinger is implemented by transports which are able to check whether the agent
+// is reachable.
+type pinger interface {
+	// ping returns an error if the agent can not be reached.
+	ping() error
+}
+
+// spooler stores payloads as files in a directory while they can't be sent to the
+// agent, so that they can be replayed once it comes back online. As payloads may
+// hold sensitive data, the directory and files are only accessible by their owner.
+type spooler struct {
+	dir string // directory holding the spooled payloads
+	max int64  // maximum total size of the spooled payloads
+
+	replayMu sync.Mutex // serializes replays
+
+	mu   sync.Mutex // guards below field
+	size int64      // total size of the spooled payloads
+}
+
+// newSpooler returns a spooler storing payloads in dir, creating it if needed.
+// Payloads left in dir by a previous run are accounted for and replayed.
+func newSpooler(dir string, max int64) (*spooler, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &spooler{dir: dir, max: max}
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			s.size += fi.Size()
+		}
+	}
+	return s, nil
+}
+
+// files returns the spooled payload files, oldest first.
+func (s *spooler) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+spoolFileExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// write stores data, the encoded contents of a payload holding count traces, in
+// a new file. It returns errSpoolFull if doing so would exceed the spool size limit.
+func (s *spooler) write(data []byte, count int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size+int64(len(data)) > s.max {
+		return errSpoolFull
+	}
+	name := filepath.Join(s.dir, fmt.Sprintf("%d-%d%s", time.Now().UnixNano(), count, spoolFileExt))
+	// write to a temporary file first, so that partially written payloads are
+	// never replayed
+	if err := ioutil.WriteFile(name+".tmp", data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		os.Remove(name + ".tmp")
+		return err
+	}
+	s.size += int64(len(data))
+	return nil
+}
+
+// replay sends the spooled payloads using send, oldest first, removing them as
+// they are sent successfully. Payloads which fail with an error which is not
+// retryable are set aside. Replaying stops at the first other error, which it
+// returns. Payloads may be spooled concurrently, as no lock is held while sending.
+func (s *spooler) replay(send func(p *payload) error) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if count, ok := spooledCount(f); !ok {
+			log.Error("discarding spooled payload with an invalid file name %q", f)
+			err = os.Remove(f)
+		} else {
+			p := newPayload()
+			p.buf.Write(data)
+			p.count = count
+			p.updateHeader()
+			if err = send(p); err == nil {
+				err = os.Remove(f)
+			} else if !isRetryable(err) {
+				log.Error("spooled payload %q was rejected, setting it aside: %v", f, err)
+				err = os.Rename(f, f+rejectedFileExt)
+			} else {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.size -= int64(len(data))
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// spooledCount returns the number of traces held in the spooled payload file f,
+// as found in its name.
+func spooledCount(f string) (count uint64, ok bool) {
+	name := strings.TrimSuffix(filepath.Base(f), spoolFileExt)
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 {
+		return 0, false
+	}
+	count, err := strconv.ParseUint(name[i+1:], 10, 32)
+	return count, err == nil
+}
+
+// checkAgentHealth pings the agent at the given interval until the tracer is
+// stopped, marking it as unreachable when the ping fails. When the agent is
+// reachable, the payloads spooled while it wasn't are replayed.
+func (t *tracer) checkAgentHealth(interval time.Duration) {
+	p, ok := t.config.transport.(pinger)
+	if !ok {
+		log.Warn("agent health check is not supported by the configured transport")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		t.updateAgentHealth(p.ping())
+		select {
+		case <-ticker.C:
+		case <-t.exitChan:
+			return
+		}
+	}
+}
+
+// updateAgentHealth records the result of an agent health check.
+func (t *tracer) updateAgentHealth(err error) {
+	if err != nil {
+		if atomic.SwapInt32(&t.agentDown, 1) == 0 {
+			log.Warn("agent is unreachable: %v", err)
+		}
+		return
+	}
+	if atomic.SwapInt32(&t.agentDown, 0) == 1 {
+		log.Warn("agent is reachable again")
+	}
+	if t.spool == nil {
+		return
+	}
+	err = t.spool.replay(func(p *payload) error {
+		count := p.itemCount()
+		rc, err := t.config.transport.send(p)
+		if err != nil {
+			return err
+		}
+		rc.Close()
+		t.config.statsd.Count("datadog.tracer.flush_traces", int64(count), nil, 1)
+		return nil
+	})
+	if err != nil {
+		log.Error("error replaying spooled traces: %v", err)
+	}
+}
+
+// spoolPayload stores data, the encoded contents of a payload holding count traces,
+// in the spool, to be sent once the agent is reachable.
+func (t *tracer) spoolPayload(data []byte, count int) {
+	if err := t.spool.write(data, count); err != nil {
+		reason := "reason:spool_error"
+		if err == errSpoolFull {
+			reason = "reason:spool_full"
+		}
+		t.config.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{reason}, 1)
+		log.Error("lost %d traces: error spooling payload: %v", count, err)
+		return
+	}
+	t.config.statsd.Count("datadog.tracer.spooled_traces", int64(count), nil, 1)
+}
@@ -0,0 +1,310 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func newSpoolTestPayload(t *testing.T, traces [][]*span) *payload {
+	p := newPayload()
+	for _, trace := range traces {
+		if err := p.push(trace); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+func TestSpooler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("replay", func(t *testing.T) {
+		assert := assert.New(t)
+		s, err := newSpooler(dir, defaultMaxSpoolBytes)
+		assert.NoError(err)
+		p1 := newSpoolTestPayload(t, getTestTrace(2, 3))
+		p2 := newSpoolTestPayload(t, getTestTrace(1, 1))
+		size := int64(p1.buf.Len() + p2.buf.Len())
+		assert.NoError(s.write(p1.buf.Bytes(), p1.itemCount()))
+		assert.NoError(s.write(p2.buf.Bytes(), p2.itemCount()))
+		assert.Equal(size, s.size)
+
+		// failed sends keep the payloads
+		assert.Error(s.replay(func(*payload) error { return errors.New("unreachable") }))
+		files, _ := s.files()
+		assert.Len(files, 2)
+
+		// payloads left by a previous run are accounted for
+		s, err = newSpooler(dir, defaultMaxSpoolBytes)
+		assert.NoError(err)
+		assert.Equal(size, s.size)
+
+		var got [][]*span
+		assert.NoError(s.replay(func(p *payload) error {
+			var traces spanLists
+			if err := msgp.Decode(p, &traces); err != nil {
+				return err
+			}
+			assert.Equal(len(traces), p.itemCount())
+			for _, trace := range traces {
+				got = append(got, trace)
+			}
+			return nil
+		}))
+		assert.Len(got, 3)
+		assert.Len(got[0], 3)
+		assert.Len(got[2], 1)
+		assert.Equal(int64(0), s.size)
+		files, _ = s.files()
+		assert.Len(files, 0)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		assert := assert.New(t)
+		p := newSpoolTestPayload(t, getTestTrace(1, 1))
+		s, err := newSpooler(dir, int64(p.buf.Len()))
+		assert.NoError(err)
+		assert.NoError(s.write(p.buf.Bytes(), p.itemCount()))
+		assert.Equal(errSpoolFull, s.write(p.buf.Bytes(), p.itemCount()))
+		assert.NoError(s.replay(func(*payload) error { return nil }))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		assert := assert.New(t)
+		s, err := newSpooler(dir, defaultMaxSpoolBytes)
+		assert.NoError(err)
+		assert.NoError(s.write([]byte{0x90}, 1))
+		assert.NoError(s.write([]byte{0x90}, 2))
+		files, _ := s.files()
+		assert.Len(files, 2)
+
+		// a rejected payload does not prevent the next ones from being sent
+		var sent int
+		assert.NoError(s.replay(func(p *payload) error {
+			sent++
+			if p.itemCount() == 1 {
+				return &statusError{code: http.StatusBadRequest, msg: "Bad Request"}
+			}
+			return nil
+		}))
+		assert.Equal(2, sent)
+		assert.Equal(int64(0), s.size)
+		left, _ := s.files()
+		assert.Len(left, 0)
+		_, err = os.Stat(files[0] + rejectedFileExt)
+		assert.NoError(err)
+		os.Remove(files[0] + rejectedFileExt)
+
+		// retryable errors stop the replay
+		assert.NoError(s.write([]byte{0x90}, 1))
+		assert.Error(s.replay(func(*payload) error {
+			return &statusError{code: http.StatusServiceUnavailable, msg: "Service Unavailable"}
+		}))
+		left, _ = s.files()
+		assert.Len(left, 1)
+		assert.NoError(s.replay(func(*payload) error { return nil }))
+	})
+
+	t.Run("write-while-replaying", func(t *testing.T) {
+		assert := assert.New(t)
+		s, err := newSpooler(dir, defaultMaxSpoolBytes)
+		assert.NoError(err)
+		assert.NoError(s.write([]byte{0x90}, 1))
+		assert.Error(s.replay(func(*payload) error {
+			// must not deadlock
+			assert.NoError(s.write([]byte{0x90}, 2))
+			return errors.New("unreachable")
+		}))
+		files, _ := s.files()
+		assert.Len(files, 2)
+		assert.NoError(s.replay(func(*payload) error { return nil }))
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("file permissions are not supported on Windows")
+		}
+		assert := assert.New(t)
+		sub := filepath.Join(dir, "sub")
+		s, err := newSpooler(sub, defaultMaxSpoolBytes)
+		assert.NoError(err)
+		assert.NoError(s.write([]byte{0x90}, 1))
+		fi, err := os.Stat(sub)
+		assert.NoError(err)
+		assert.Equal(os.FileMode(0700), fi.Mode().Perm())
+		files, _ := s.files()
+		assert.Len(files, 1)
+		fi, err = os.Stat(files[0])
+		assert.NoError(err)
+		assert.Equal(os.FileMode(0600), fi.Mode().Perm())
+		os.RemoveAll(sub)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		name := filepath.Join(dir, "invalid"+spoolFileExt)
+		if err := ioutil.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := newSpooler(dir, defaultMaxSpoolBytes)
+		assert.NoError(t, err)
+		assert.NoError(t, s.replay(func(*payload) error {
+			t.Fatal("invalid payloads should not be sent")
+			return nil
+		}))
+		_, err = os.Stat(name)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestAgentHealthCheck(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		healthy  int32
+		received int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/v0.4/traces" {
+			atomic.AddInt32(&received, 1)
+		}
+	}))
+	defer srv.Close()
+
+	tracer := newTracer(
+		WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")),
+		WithAgentHealthCheck(10*time.Millisecond),
+		WithSpoolDir(dir),
+	)
+	internal.SetGlobalTracer(tracer)
+	defer internal.SetGlobalTracer(&internal.NoopTracer{})
+	defer tracer.Stop()
+
+	waitFor := func(cond func() bool) bool {
+		for i := 0; i < 200; i++ {
+			if cond() {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+	spooled := func() int {
+		files, _ := tracer.spool.files()
+		return len(files)
+	}
+
+	assert.True(waitFor(func() bool { return atomic.LoadInt32(&tracer.agentDown) == 1 }), "agent should be down")
+	tracer.StartSpan("operation").Finish()
+	assert.True(waitFor(func() bool {
+		select {
+		case tracer.flushChan <- struct{}{}:
+		default:
+		}
+		return spooled() == 1
+	}), "trace should be spooled")
+	assert.Equal(int32(0), atomic.LoadInt32(&received))
+
+	atomic.StoreInt32(&healthy, 1)
+	assert.True(waitFor(func() bool { return atomic.LoadInt32(&received) == 1 }), "trace should be replayed")
+	assert.True(waitFor(func() bool { return spooled() == 0 }), "spool should be empty")
+}
+
+func TestSpoolOnSendFailure(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		status   int32 = http.StatusServiceUnavailable
+		attempts int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0.4/traces" {
+			// the health check always succeeds
+			return
+		}
+		defer atomic.AddInt32(&attempts, 1)
+		if code := atomic.LoadInt32(&status); code != http.StatusOK {
+			w.WriteHeader(int(code))
+		}
+	}))
+	defer srv.Close()
+
+	tracer := newTracer(
+		WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")),
+		WithAgentHealthCheck(time.Hour),
+		WithSpoolDir(dir),
+	)
+	internal.SetGlobalTracer(tracer)
+	defer internal.SetGlobalTracer(&internal.NoopTracer{})
+	defer tracer.Stop()
+
+	spooled := func() int {
+		files, _ := tracer.spool.files()
+		return len(files)
+	}
+	// flush sends a trace and waits until the n-th attempt to send traces was
+	// handled and its outcome is visible in the spool directory.
+	flush := func(n int32, wantSpooled int) bool {
+		tracer.StartSpan("operation").Finish()
+		for i := 0; i < 200; i++ {
+			select {
+			case tracer.flushChan <- struct{}{}:
+			default:
+			}
+			if atomic.LoadInt32(&attempts) >= n && spooled() == wantSpooled {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	// retryable failures are spooled even though the agent is deemed healthy
+	assert.True(flush(1, 1), "trace should be spooled")
+	assert.Equal(int32(0), atomic.LoadInt32(&tracer.agentDown))
+
+	// payloads rejected by the agent are dropped
+	atomic.StoreInt32(&status, http.StatusBadRequest)
+	assert.True(flush(2, 1), "trace should be dropped")
+
+	atomic.StoreInt32(&status, http.StatusOK)
+	tracer.updateAgentHealth(nil)
+	assert.Equal(int32(3), atomic.LoadInt32(&attempts))
+	assert.Equal(0, spooled())
+}
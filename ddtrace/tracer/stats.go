@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+)
+
+// spanStatsBufferSize specifies the maximum number of span stats held by the
+// tracer until they are retrieved using Stats.
+const spanStatsBufferSize = 10000
+
+// SpanStats summarizes a finished span. Span stats are collected when the tracer
+// is started using WithSpanStats and can be retrieved by calling Stats.
+type SpanStats struct {
+	// Service is the service name of the span.
+	Service string
+
+	// Resource is the resource name of the span.
+	Resource string
+
+	// Type is the type of the span.
+	Type string
+
+	// Error reports whether the span was marked as an error.
+	Error bool
+
+	// Duration is the duration of the span.
+	Duration time.Duration
+}
+
+// Stats returns the stats of the spans finished since the previous call, in the
+// order in which they finished. It returns nil unless the running tracer was
+// started using WithSpanStats.
+func Stats() []SpanStats {
+	t, ok := internal.GetGlobalTracer().(*tracer)
+	if !ok || t.stats == nil {
+		return nil
+	}
+	return t.stats.drain()
+}
+
+// statsBuffer holds the stats of finished spans until they are drained. Stats
+// are discarded while the buffer is full.
+type statsBuffer struct {
+	mu    sync.Mutex // guards stats
+	stats []SpanStats
+	size  int
+}
+
+func newStatsBuffer(size int) *statsBuffer {
+	return &statsBuffer{size: size}
+}
+
+// add adds the stats of the finished span s to the buffer. The caller must hold
+// the lock of s.
+func (b *statsBuffer) add(s *span) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.stats) >= b.size {
+		return
+	}
+	b.stats = append(b.stats, SpanStats{
+		Service:  s.Service,
+		Resource: s.Resource,
+		Type:     s.Type,
+		Error:    s.Error != 0,
+		Duration: time.Duration(s.Duration),
+	})
+}
+
+// drain returns the buffered stats and empties the buffer.
+func (b *statsBuffer) drain() []SpanStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.stats
+	b.stats = nil
+	return stats
+}
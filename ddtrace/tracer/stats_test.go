@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		tracer, _, stop := startTestTracer()
+		defer stop()
+
+		tracer.StartSpan("op").Finish()
+		assert.Nil(t, Stats())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, stop := startTestTracer(WithSpanStats(true), WithSampler(NewRateSampler(0)))
+		defer stop()
+
+		tracer.StartSpan("http.request", ServiceName("web"), ResourceName("/"), SpanType(ext.SpanTypeWeb)).Finish()
+		tracer.StartSpan("db.query", ServiceName("db"), ResourceName("SELECT")).Finish(WithError(errors.New("oops")))
+
+		stats := Stats()
+		assert.Len(stats, 2)
+		assert.Equal("web", stats[0].Service)
+		assert.Equal("/", stats[0].Resource)
+		assert.Equal(ext.SpanTypeWeb, stats[0].Type)
+		assert.False(stats[0].Error)
+		assert.True(stats[0].Duration > 0)
+		assert.Equal("db", stats[1].Service)
+		assert.True(stats[1].Error)
+
+		assert.Len(Stats(), 0, "stats are drained")
+	})
+}
+
+func TestStatsBuffer(t *testing.T) {
+	assert := assert.New(t)
+	b := newStatsBuffer(2)
+	for _, res := range []string{"a", "b", "c"} {
+		b.add(&span{Resource: res})
+	}
+	stats := b.drain()
+	assert.Len(stats, 2)
+	assert.Equal("b", stats[1].Resource)
+
+	b.add(&span{Resource: "d"})
+	assert.Equal([]SpanStats{{Resource: "d"}}, b.drain())
+}
@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"encoding/json"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+// defaultTailSamplingBufferSize specifies the default number of completed traces
+// held while waiting for a tail sampling decision.
+const defaultTailSamplingBufferSize = 1000
+
+// keyTailSamplingDecision is set on traces which were kept by a tail sampling rule.
+const keyTailSamplingDecision = "_dd.tail_sampled"
+
+// TailSamplingRule specifies conditions under which a completed trace is always
+// kept, regardless of the sampling decision made when it started. A trace
+// matches the rule when any of its spans matches all of the rule's non-zero
+// fields. TailSamplingRule can be encoded to and decoded from JSON, where
+// MinDuration is represented as a duration string, such as "1.5s".
+type TailSamplingRule struct {
+	// Service is the exact service name to match.
+	Service string `json:"service,omitempty"`
+
+	// Resource is the exact resource name to match.
+	Resource string `json:"resource,omitempty"`
+
+	// MinDuration is the minimum duration a span must have to match.
+	MinDuration time.Duration `json:"min_duration,omitempty"`
+
+	// Tags holds a set of tags which must all be present on a span, with the
+	// given values, for it to match. Numeric tags are not considered.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// jsonTailSamplingRule is the JSON representation of a TailSamplingRule.
+type jsonTailSamplingRule struct {
+	Service     string            `json:"service,omitempty"`
+	Resource    string            `json:"resource,omitempty"`
+	MinDuration string            `json:"min_duration,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r TailSamplingRule) MarshalJSON() ([]byte, error) {
+	v := jsonTailSamplingRule{
+		Service:  r.Service,
+		Resource: r.Resource,
+		Tags:     r.Tags,
+	}
+	if r.MinDuration != 0 {
+		v.MinDuration = r.MinDuration.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *TailSamplingRule) UnmarshalJSON(data []byte) error {
+	var v jsonTailSamplingRule
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var d time.Duration
+	if v.MinDuration != "" {
+		var err error
+		if d, err = time.ParseDuration(v.MinDuration); err != nil {
+			return err
+		}
+	}
+	*r = TailSamplingRule{
+		Service:     v.Service,
+		Resource:    v.Resource,
+		MinDuration: d,
+		Tags:        v.Tags,
+	}
+	return nil
+}
+
+// match returns true when the span matches all the conditions in the rule.
+// The span must be finished.
+func (r *TailSamplingRule) match(s *span) bool {
+	if r.Service != "" && r.Service != s.Service {
+		return false
+	}
+	if r.Resource != "" && r.Resource != s.Resource {
+		return false
+	}
+	if r.MinDuration > 0 && s.Duration < int64(r.MinDuration) {
+		return false
+	}
+	for k, v := range r.Tags {
+		if s.Meta[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// tailSampler holds completed traces in a bounded ring buffer until a tail
+// sampling decision is made for them, either when the tracer flushes or when
+// they are evicted to make room for newer traces. Traces matching any of the
+// rules are kept; others retain the decision made when they started. It is
+// only used from the tracer's worker goroutine and is not safe for concurrent use.
+type tailSampler struct {
+	rules  []TailSamplingRule
+	traces [][]*span // ring buffer of traces awaiting a decision
+	head   int       // index of the oldest trace in traces
+	n      int       // number of traces in the buffer
+}
+
+func newTailSampler(rules []TailSamplingRule, size int) *tailSampler {
+	if size < 1 {
+		size = defaultTailSamplingBufferSize
+	}
+	return &tailSampler{
+		rules:  rules,
+		traces: make([][]*span, size),
+	}
+}
+
+// push adds the trace to the buffer. If the buffer is full, the oldest trace is
+// evicted and a decision is made for it. In that case, push returns the evicted
+// trace if it should be forwarded to the agent, or nil otherwise.
+func (ts *tailSampler) push(trace []*span) []*span {
+	var evicted []*span
+	if ts.n == len(ts.traces) {
+		evicted = ts.traces[ts.head]
+		ts.traces[ts.head] = nil
+		ts.head = (ts.head + 1) % len(ts.traces)
+		ts.n--
+	}
+	ts.traces[(ts.head+ts.n)%len(ts.traces)] = trace
+	ts.n++
+	if evicted == nil {
+		return nil
+	}
+	return ts.decide(evicted)
+}
+
+// flush makes a decision for all the buffered traces, calling fn with each
+// trace which should be forwarded to the agent.
+func (ts *tailSampler) flush(fn func(trace []*span)) {
+	for ; ts.n > 0; ts.n-- {
+		trace := ts.traces[ts.head]
+		ts.traces[ts.head] = nil
+		ts.head = (ts.head + 1) % len(ts.traces)
+		if trace = ts.decide(trace); trace != nil {
+			fn(trace)
+		}
+	}
+}
+
+// decide returns the given trace if it should be forwarded to the agent, or nil
+// otherwise. When the trace matches a rule, it is marked to be kept.
+func (ts *tailSampler) decide(trace []*span) []*span {
+	if len(trace) == 0 {
+		return nil
+	}
+	if !ts.match(trace) {
+		if trace[0].context.drop {
+			// discarded by the local sampler
+			return nil
+		}
+		return trace
+	}
+	// the sampling priority is held by the local root of the trace, or by
+	// the first span of each partially flushed chunk
+	root := trace[0]
+	for _, s := range trace {
+		if _, ok := s.Metrics[keySamplingPriority]; ok {
+			root = s
+			break
+		}
+	}
+	root.setMetric(keySamplingPriority, ext.PriorityUserKeep)
+	root.setMetric(keyTailSamplingDecision, 1)
+	return trace
+}
+
+// match returns true if any span in the trace matches any of the rules.
+func (ts *tailSampler) match(trace []*span) bool {
+	for _, s := range trace {
+		for i := range ts.rules {
+			if ts.rules[i].match(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
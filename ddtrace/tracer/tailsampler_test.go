@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tracer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTailSamplingRuleJSON(t *testing.T) {
+	assert := assert.New(t)
+	rule := TailSamplingRule{
+		Service:     "db",
+		MinDuration: 1500 * time.Millisecond,
+		Tags:        map[string]string{"http.status_code": "500"},
+	}
+	data, err := json.Marshal([]TailSamplingRule{rule})
+	assert.NoError(err)
+	assert.Equal(`[{"service":"db","min_duration":"1.5s","tags":{"http.status_code":"500"}}]`, string(data))
+
+	var rules []TailSamplingRule
+	assert.NoError(json.Unmarshal(data, &rules))
+	assert.Equal([]TailSamplingRule{rule}, rules)
+
+	assert.Error(json.Unmarshal([]byte(`{"min_duration":"soon"}`), &rule))
+}
+
+func TestTailSamplingRuleMatch(t *testing.T) {
+	span := newSpan("http.request", "web", "/users", 1, 1, 0)
+	span.Duration = int64(2 * time.Second)
+	span.setMeta(ext.HTTPCode, "500")
+
+	for _, tt := range []struct {
+		rule  TailSamplingRule
+		match bool
+	}{
+		{TailSamplingRule{}, true},
+		{TailSamplingRule{Service: "web", Resource: "/users"}, true},
+		{TailSamplingRule{Service: "db"}, false},
+		{TailSamplingRule{Resource: "/"}, false},
+		{TailSamplingRule{MinDuration: time.Second}, true},
+		{TailSamplingRule{MinDuration: 3 * time.Second}, false},
+		{TailSamplingRule{Tags: map[string]string{ext.HTTPCode: "500"}}, true},
+		{TailSamplingRule{Tags: map[string]string{ext.HTTPCode: "500", "a": "b"}}, false},
+	} {
+		assert.Equal(t, tt.match, tt.rule.match(span), "%+v", tt.rule)
+	}
+}
+
+func TestTailSamplerBuffer(t *testing.T) {
+	assert := assert.New(t)
+	ts := newTailSampler([]TailSamplingRule{{Resource: "/keep"}}, 2)
+	newTrace := func(resource string, drop bool) []*span {
+		s := newSpan("http.request", "web", resource, 1, 1, 0)
+		s.context.drop = drop
+		return []*span{s}
+	}
+	keep, drop := newTrace("/keep", true), newTrace("/drop", true)
+	other := newTrace("/other", false)
+
+	assert.Nil(ts.push(keep))
+	assert.Nil(ts.push(drop))
+	assert.Equal(keep, ts.push(other), "the oldest trace is evicted")
+	assert.Equal(float64(ext.PriorityUserKeep), keep[0].Metrics[keySamplingPriority])
+	assert.Equal(1., keep[0].Metrics[keyTailSamplingDecision])
+
+	var got [][]*span
+	ts.flush(func(trace []*span) { got = append(got, trace) })
+	assert.Equal([][]*span{other}, got, "dropped traces which do not match are discarded")
+	_, ok := other[0].Metrics[keyTailSamplingDecision]
+	assert.False(ok)
+	assert.Zero(ts.n)
+}
+
+func TestTracerTailSampling(t *testing.T) {
+	assert := assert.New(t)
+	tracer, transport, stop := startTestTracer(
+		WithSampler(NewRateSampler(0)),
+		WithTailSampling([]TailSamplingRule{{Tags: map[string]string{ext.Error: "true"}}, {Resource: "/slow"}}),
+		WithTailSamplingBufferSize(10),
+	)
+	defer stop()
+
+	root := tracer.newRootSpan("http.request", "web", "/slow")
+	tracer.newChildSpan("db.query", root).Finish()
+	root.Finish()
+	tracer.newRootSpan("http.request", "web", "/fast").Finish()
+
+	tracer.flushAndWait(t, 1)
+	traces := transport.Traces()
+	assert.Len(traces, 1)
+	assert.Len(traces[0], 2)
+	for _, s := range traces[0] {
+		assert.Equal(root.TraceID, s.TraceID, "only the matching trace is kept")
+	}
+	assert.Equal(float64(ext.PriorityUserKeep), traces[0][0].Metrics[keySamplingPriority])
+}
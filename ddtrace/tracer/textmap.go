@@ -6,8 +6,11 @@
 package tracer
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -39,6 +42,48 @@ func (c HTTPHeadersCarrier) ForeachKey(handler func(key, val string) error) erro
 	return nil
 }
 
+// InjectHTTP injects the context of the given span into the headers of req
+// using the global tracer's propagator. It is a shorthand for:
+//
+//	tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(req.Header))
+//
+// If the tracer is not started, calling this function is a no-op.
+func InjectHTTP(span ddtrace.Span, req *http.Request) error {
+	if span == nil {
+		return ErrInvalidSpanContext
+	}
+	return Inject(span.Context(), HTTPHeadersCarrier(req.Header))
+}
+
+// ExtractHTTP extracts a SpanContext from the headers of req using the global
+// tracer's propagator. It is a shorthand for:
+//
+//	tracer.Extract(tracer.HTTPHeadersCarrier(req.Header))
+//
+// If the tracer is not started, calling this function is a no-op.
+func ExtractHTTP(req *http.Request) (ddtrace.SpanContext, error) {
+	return Extract(HTTPHeadersCarrier(req.Header))
+}
+
+// RemoteSpanContext returns a span context for a trace continued from another
+// service, for use by Propagator implementations outside of this package.
+// traceIDHigh holds the upper 64 bits of a 128-bit trace ID, which are propagated
+// along in the W3C format. spanID may be zero when the caller didn't send a
+// parent span ID, in which case spans started from the context are roots of the
+// given trace. When priority is nil, no sampling decision is propagated, as with
+// Datadog headers which don't carry a sampling priority.
+func RemoteSpanContext(traceIDHigh, traceID, spanID uint64, priority *int) ddtrace.SpanContext {
+	ctx := &spanContext{
+		traceIDHigh: traceIDHigh,
+		traceID:     traceID,
+		spanID:      spanID,
+	}
+	if priority != nil {
+		ctx.setSamplingPriority(*priority)
+	}
+	return ctx
+}
+
 // TextMapCarrier allows the use of a regular map[string]string as both TextMapWriter
 // and TextMapReader, making it compatible with the provided Propagator.
 type TextMapCarrier map[string]string
@@ -88,6 +133,16 @@ const (
 // It is used with the Synthetics product and usually has the value "synthetics".
 const originHeader = "x-datadog-origin"
 
+// userIDHeader specifies the name of the header holding the user ID set on the
+// trace using SetUser along with WithUserPropagation. It is only extracted when
+// PropagatorConfig.ExtractUserID is set.
+const userIDHeader = "x-datadog-user-id"
+
+// traceTagsHeader specifies the name of the header holding the trace-level tags
+// which are propagated along with the trace, as a comma-separated list of
+// key=value pairs. Only the sampling decision maker tag is currently supported.
+const traceTagsHeader = "x-datadog-tags"
+
 // PropagatorConfig defines the configuration for initializing a propagator.
 type PropagatorConfig struct {
 	// BaggagePrefix specifies the prefix that will be used to store baggage
@@ -105,6 +160,18 @@ type PropagatorConfig struct {
 	// PriorityHeader specifies the map key that will be used to store the sampling priority.
 	// It deafults to DefaultPriorityHeader.
 	PriorityHeader string
+
+	// B3 specifies whether B3 headers should be used in addition to the ones
+	// used by default, when no propagation style is set via the environment.
+	// When true, the B3 multi-header format is injected, and both the multi
+	// and single-header formats are extracted, following Datadog and W3C headers.
+	B3 bool
+
+	// ExtractUserID specifies whether the user ID propagated by upstream services
+	// in the "x-datadog-user-id" header should be extracted and set as the "usr.id"
+	// tag. The header is not authenticated, so it should only be enabled when it is
+	// guaranteed to come from trusted services. It is disabled by default.
+	ExtractUserID bool
 }
 
 // NewPropagator returns a new propagator which uses TextMap to inject
@@ -142,27 +209,47 @@ type chainedPropagator struct {
 
 // getPropagators returns a list of propagators based on the list found in the
 // given environment variable. If the list doesn't contain a value or has invalid
-// values, the default propagator will be returned.
+// values, the default propagators will be returned. By default, injection uses
+// Datadog headers, while extraction also accepts W3C trace context headers. B3
+// headers are added to the defaults when enabled via PropagatorConfig.B3.
 func getPropagators(cfg *PropagatorConfig, env string) []Propagator {
 	dd := &propagator{cfg}
+	defaultPs := []Propagator{dd}
+	if env == headerPropagationStyleExtract {
+		defaultPs = append(defaultPs, &W3CTraceContextPropagator{})
+	}
+	if cfg.B3 {
+		defaultPs = append(defaultPs, &B3MultiPropagator{})
+		if env == headerPropagationStyleExtract {
+			defaultPs = append(defaultPs, &B3SinglePropagator{})
+		}
+	}
 	ps := os.Getenv(env)
 	if ps == "" {
-		return []Propagator{dd}
+		return defaultPs
 	}
 	var list []Propagator
 	for _, v := range strings.Split(ps, ",") {
 		switch strings.ToLower(v) {
 		case "datadog":
 			list = append(list, dd)
-		case "b3":
-			list = append(list, &propagatorB3{})
+		case "b3", "b3multi":
+			list = append(list, &B3MultiPropagator{})
+		case "b3single":
+			list = append(list, &B3SinglePropagator{})
+		case "tracecontext":
+			list = append(list, &W3CTraceContextPropagator{})
+		case "jaeger":
+			list = append(list, NewJaegerPropagator())
+		case "baggage":
+			list = append(list, &BaggagePropagator{})
 		default:
 			// TODO(cgilmour): consider logging something for invalid/unknown styles.
 		}
 	}
 	if len(list) == 0 {
 		// return the default
-		return []Propagator{dd}
+		return defaultPs
 	}
 	return list
 }
@@ -180,22 +267,100 @@ func (p *chainedPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interfac
 	return nil
 }
 
-// Extract implements Propagator.
+// Extract implements Propagator. Span contexts which only carry baggage, such as
+// the ones returned by BaggagePropagator, don't stop the search; their items are
+// added to the span context which is eventually selected.
 func (p *chainedPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	var baggage []*spanContext
 	for _, v := range p.extractors {
 		ctx, err := v.Extract(carrier)
 		if ctx != nil {
+			if sctx, ok := ctx.(*spanContext); ok && sctx.traceID == 0 {
+				baggage = append(baggage, sctx)
+				continue
+			}
 			// first extractor returns
-			return ctx, nil
+			return withBaggage(ctx, baggage), nil
 		}
 		if err == ErrSpanContextNotFound {
 			continue
 		}
 		return nil, err
 	}
+	if len(baggage) > 0 {
+		return withBaggage(baggage[0], baggage[1:]), nil
+	}
 	return nil, ErrSpanContextNotFound
 }
 
+// withBaggage adds the baggage items found in the given list of span contexts to
+// ctx, unless ctx already has them, and returns it.
+func withBaggage(ctx ddtrace.SpanContext, list []*spanContext) ddtrace.SpanContext {
+	sctx, ok := ctx.(*spanContext)
+	if !ok {
+		return ctx
+	}
+	for _, b := range list {
+		b.ForeachBaggageItem(func(k, v string) bool {
+			if sctx.baggageItem(k) == "" {
+				sctx.setBaggageItem(k, v)
+			}
+			return true
+		})
+	}
+	return ctx
+}
+
+// ComposedPropagator implements Propagator by combining several propagators.
+// It is obtained by calling CompositePropagator.
+type ComposedPropagator struct {
+	propagators []Propagator
+	injectAll   bool
+}
+
+var _ Propagator = (*ComposedPropagator)(nil)
+
+// CompositePropagator returns a Propagator which extracts span contexts using
+// the given propagators in order, selecting the first successful extraction.
+// By default, all of the propagators are used when injecting, so that each
+// format is written to the carrier. The result may be passed to WithPropagator:
+//
+//	tracer.Start(tracer.WithPropagator(tracer.CompositePropagator(
+//		tracer.NewPropagator(nil),
+//		&tracer.B3MultiPropagator{},
+//	)))
+func CompositePropagator(propagators ...Propagator) *ComposedPropagator {
+	p := &ComposedPropagator{injectAll: true}
+	for _, v := range propagators {
+		if v != nil {
+			p.propagators = append(p.propagators, v)
+		}
+	}
+	return p
+}
+
+// WithInjectAllFormats specifies whether all propagators should be used when
+// injecting. When disabled, only the first propagator injects the span context,
+// while all of them are still used for extraction. It returns p.
+func (p *ComposedPropagator) WithInjectAllFormats(enabled bool) *ComposedPropagator {
+	p.injectAll = enabled
+	return p
+}
+
+// Inject implements Propagator.
+func (p *ComposedPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	injectors := p.propagators
+	if !p.injectAll && len(injectors) > 1 {
+		injectors = injectors[:1]
+	}
+	return (&chainedPropagator{injectors: injectors}).Inject(spanCtx, carrier)
+}
+
+// Extract implements Propagator.
+func (p *ComposedPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	return (&chainedPropagator{extractors: p.propagators}).Extract(carrier)
+}
+
 // propagator implements Propagator and injects/extracts span contexts
 // using datadog headers. Only TextMap carriers are supported.
 type propagator struct {
@@ -225,6 +390,12 @@ func (p *propagator) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWr
 	if ctx.origin != "" {
 		writer.Set(originHeader, ctx.origin)
 	}
+	if dm := ctx.decisionMaker(); dm != "" {
+		writer.Set(traceTagsHeader, keyDecisionMaker+"="+dm)
+	}
+	if uid := ctx.userID(); uid != "" {
+		writer.Set(userIDHeader, uid)
+	}
 	// propagate OpenTracing baggage
 	for k, v := range ctx.baggage {
 		writer.Set(p.cfg.BaggagePrefix+k, v)
@@ -265,6 +436,16 @@ func (p *propagator) extractTextMap(reader TextMapReader) (ddtrace.SpanContext,
 			ctx.setSamplingPriority(priority)
 		case originHeader:
 			ctx.origin = v
+		case userIDHeader:
+			if p.cfg.ExtractUserID {
+				ctx.setUserID(v)
+			}
+		case traceTagsHeader:
+			for _, tag := range strings.Split(v, ",") {
+				if kv := strings.SplitN(strings.TrimSpace(tag), "=", 2); len(kv) == 2 && kv[0] == keyDecisionMaker {
+					ctx.setDecisionMaker(kv[1])
+				}
+			}
 		default:
 			if strings.HasPrefix(key, p.cfg.BaggagePrefix) {
 				ctx.setBaggageItem(strings.TrimPrefix(key, p.cfg.BaggagePrefix), v)
@@ -285,13 +466,25 @@ const (
 	b3TraceIDHeader = "x-b3-traceid"
 	b3SpanIDHeader  = "x-b3-spanid"
 	b3SampledHeader = "x-b3-sampled"
+	b3FlagsHeader   = "x-b3-flags"
+	b3SingleHeader  = "b3"
 )
 
-// propagatorB3 implements Propagator and injects/extracts span contexts
-// using B3 headers. Only TextMap carriers are supported.
-type propagatorB3 struct{}
+// B3MultiPropagator implements Propagator and injects/extracts span contexts
+// using the B3 multi-header format ("X-B3-TraceId", "X-B3-SpanId" and
+// "X-B3-Sampled"), see https://github.com/openzipkin/b3-propagation.
+// Only TextMap carriers are supported.
+//
+// B3 trace IDs may be 128 bits long, in which case only the lower 64 bits are
+// kept as the Datadog trace ID. Distinct 128-bit trace IDs sharing the same
+// lower 64 bits will therefore be reported as the same trace, which is unlikely
+// when trace IDs are randomly generated.
+type B3MultiPropagator struct{}
+
+var _ Propagator = (*B3MultiPropagator)(nil)
 
-func (p *propagatorB3) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+// Inject implements Propagator.
+func (p *B3MultiPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
 	switch c := carrier.(type) {
 	case TextMapWriter:
 		return p.injectTextMap(spanCtx, c)
@@ -300,7 +493,7 @@ func (p *propagatorB3) Inject(spanCtx ddtrace.SpanContext, carrier interface{})
 	}
 }
 
-func (*propagatorB3) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
+func (*B3MultiPropagator) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
 	ctx, ok := spanCtx.(*spanContext)
 	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
 		return ErrInvalidSpanContext
@@ -317,7 +510,8 @@ func (*propagatorB3) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWr
 	return nil
 }
 
-func (p *propagatorB3) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+// Extract implements Propagator.
+func (p *B3MultiPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
 	switch c := carrier.(type) {
 	case TextMapReader:
 		return p.extractTextMap(c)
@@ -326,28 +520,35 @@ func (p *propagatorB3) Extract(carrier interface{}) (ddtrace.SpanContext, error)
 	}
 }
 
-func (*propagatorB3) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
+func (*B3MultiPropagator) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
 	var ctx spanContext
 	err := reader.ForeachKey(func(k, v string) error {
 		var err error
 		key := strings.ToLower(k)
 		switch key {
 		case b3TraceIDHeader:
-			ctx.traceID, err = strconv.ParseUint(v, 16, 64)
+			ctx.traceID, err = parseB3ID(v)
 			if err != nil {
 				return ErrSpanContextCorrupted
 			}
 		case b3SpanIDHeader:
-			ctx.spanID, err = strconv.ParseUint(v, 16, 64)
+			ctx.spanID, err = parseB3ID(v)
 			if err != nil {
 				return ErrSpanContextCorrupted
 			}
 		case b3SampledHeader:
-			priority, err := strconv.Atoi(v)
+			priority, err := parseB3Sampled(v)
 			if err != nil {
 				return ErrSpanContextCorrupted
 			}
-			ctx.setSamplingPriority(priority)
+			if !ctx.hasSamplingPriority() {
+				ctx.setSamplingPriority(priority)
+			}
+		case b3FlagsHeader:
+			if v == "1" {
+				// debug flag implies an accept decision
+				ctx.setSamplingPriority(ext.PriorityUserKeep)
+			}
 		default:
 		}
 		return nil
@@ -360,3 +561,609 @@ func (*propagatorB3) extractTextMap(reader TextMapReader) (ddtrace.SpanContext,
 	}
 	return &ctx, nil
 }
+
+// B3SinglePropagator implements Propagator and injects/extracts span contexts
+// using the B3 single-header format ("b3: {TraceId}-{SpanId}-{SamplingState}"),
+// see https://github.com/openzipkin/b3-propagation#single-header. Only TextMap
+// carriers are supported. As with B3MultiPropagator, 128-bit trace IDs are
+// truncated to their lower 64 bits.
+type B3SinglePropagator struct{}
+
+var _ Propagator = (*B3SinglePropagator)(nil)
+
+// Inject implements Propagator.
+func (p *B3SinglePropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	switch c := carrier.(type) {
+	case TextMapWriter:
+		return p.injectTextMap(spanCtx, c)
+	default:
+		return ErrInvalidCarrier
+	}
+}
+
+func (*B3SinglePropagator) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
+	ctx, ok := spanCtx.(*spanContext)
+	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
+		return ErrInvalidSpanContext
+	}
+	v := fmt.Sprintf("%016x-%016x", ctx.traceID, ctx.spanID)
+	if ctx.hasSamplingPriority() {
+		if ctx.samplingPriority() >= ext.PriorityAutoKeep {
+			v += "-1"
+		} else {
+			v += "-0"
+		}
+	}
+	writer.Set(b3SingleHeader, v)
+	return nil
+}
+
+// Extract implements Propagator.
+func (p *B3SinglePropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	switch c := carrier.(type) {
+	case TextMapReader:
+		return p.extractTextMap(c)
+	default:
+		return nil, ErrInvalidCarrier
+	}
+}
+
+func (*B3SinglePropagator) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
+	var header string
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) == b3SingleHeader {
+			header = strings.TrimSpace(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(header, "-")
+	if len(parts) < 2 {
+		// either missing, or only carrying a sampling decision
+		return nil, ErrSpanContextNotFound
+	}
+	if len(parts) > 4 {
+		return nil, ErrSpanContextCorrupted
+	}
+	var ctx spanContext
+	if ctx.traceID, err = parseB3ID(parts[0]); err != nil {
+		return nil, ErrSpanContextCorrupted
+	}
+	if ctx.spanID, err = parseB3ID(parts[1]); err != nil {
+		return nil, ErrSpanContextCorrupted
+	}
+	if len(parts) > 2 {
+		priority, err := parseB3Sampled(parts[2])
+		if err != nil {
+			return nil, ErrSpanContextCorrupted
+		}
+		ctx.setSamplingPriority(priority)
+	}
+	if len(parts) > 3 {
+		// parent span ID, not used but must be valid
+		if _, err := parseB3ID(parts[3]); err != nil {
+			return nil, ErrSpanContextCorrupted
+		}
+	}
+	if ctx.traceID == 0 || ctx.spanID == 0 {
+		return nil, ErrSpanContextNotFound
+	}
+	return &ctx, nil
+}
+
+// parseB3ID parses a 64 or 128-bit hex-encoded B3 identifier. For 128-bit
+// identifiers only the lower 64 bits are returned.
+func parseB3ID(v string) (uint64, error) {
+	if len(v) > 32 {
+		return 0, ErrSpanContextCorrupted
+	}
+	if len(v) > 16 {
+		if _, err := strconv.ParseUint(v[:len(v)-16], 16, 64); err != nil {
+			return 0, err
+		}
+		v = v[len(v)-16:]
+	}
+	return strconv.ParseUint(v, 16, 64)
+}
+
+// parseB3Sampled parses a B3 sampling state into a sampling priority.
+func parseB3Sampled(v string) (int, error) {
+	switch strings.ToLower(v) {
+	case "1", "true":
+		return ext.PriorityAutoKeep, nil
+	case "0", "false":
+		return ext.PriorityAutoReject, nil
+	case "d":
+		// debug
+		return ext.PriorityUserKeep, nil
+	default:
+		return 0, ErrSpanContextCorrupted
+	}
+}
+
+const (
+	w3cTraceParentHeader = "traceparent"
+	w3cTraceStateHeader  = "tracestate"
+)
+
+// W3CTraceContextPropagator implements Propagator and injects/extracts span
+// contexts using the W3C Trace Context headers "traceparent" and "tracestate"
+// (https://www.w3.org/TR/trace-context/). Only TextMap carriers are supported.
+//
+// W3C trace IDs are 128 bits long. Only the lower 64 bits are used as the Datadog
+// trace ID, while the upper 64 bits are retained in the span context so that the
+// original trace ID is propagated unchanged to downstream services. Vendor-specific
+// entries found in "tracestate" are propagated as well, and the "dd" entry is
+// used to carry the sampling priority and origin.
+//
+// It is used for extraction by default, and can be enabled for injection by
+// adding "tracecontext" to the DD_PROPAGATION_STYLE_INJECT environment variable.
+type W3CTraceContextPropagator struct{}
+
+var _ Propagator = (*W3CTraceContextPropagator)(nil)
+
+// Inject implements Propagator.
+func (p *W3CTraceContextPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	switch c := carrier.(type) {
+	case TextMapWriter:
+		return p.injectTextMap(spanCtx, c)
+	default:
+		return ErrInvalidCarrier
+	}
+}
+
+func (*W3CTraceContextPropagator) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
+	ctx, ok := spanCtx.(*spanContext)
+	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
+		return ErrInvalidSpanContext
+	}
+	flags := "00"
+	if ctx.hasSamplingPriority() && ctx.samplingPriority() >= ext.PriorityAutoKeep {
+		flags = "01"
+	}
+	writer.Set(w3cTraceParentHeader, fmt.Sprintf("00-%016x%016x-%016x-%s", ctx.traceIDHigh, ctx.traceID, ctx.spanID, flags))
+	writer.Set(w3cTraceStateHeader, composeTracestate(ctx))
+	return nil
+}
+
+// maxTracestateMembers is the maximum number of list members allowed in the
+// tracestate header.
+const maxTracestateMembers = 32
+
+// composeTracestate returns the value of the tracestate header for ctx. The "dd"
+// list member is placed first, followed by any members received from upstream.
+func composeTracestate(ctx *spanContext) string {
+	var b strings.Builder
+	b.WriteString("dd=")
+	if ctx.hasSamplingPriority() {
+		b.WriteString("s:")
+		b.WriteString(strconv.Itoa(ctx.samplingPriority()))
+	}
+	if origin := ctx.origin; origin != "" {
+		if ctx.hasSamplingPriority() {
+			b.WriteByte(';')
+		}
+		b.WriteString("o:")
+		b.WriteString(tracestateValueReplacer.Replace(origin))
+	}
+	if b.Len() == len("dd=") {
+		// nothing to carry in our own entry
+		b.Reset()
+	}
+	n := 1
+	for _, member := range strings.Split(ctx.tracestate, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		if n >= maxTracestateMembers {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(member)
+		n++
+	}
+	return b.String()
+}
+
+// tracestateValueReplacer replaces characters which are not allowed in the values
+// of the "dd" tracestate list member.
+var tracestateValueReplacer = strings.NewReplacer(",", "_", ";", "_", "=", "_", "~", "_")
+
+// Extract implements Propagator.
+func (p *W3CTraceContextPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	switch c := carrier.(type) {
+	case TextMapReader:
+		return p.extractTextMap(c)
+	default:
+		return nil, ErrInvalidCarrier
+	}
+}
+
+func (*W3CTraceContextPropagator) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
+	var traceparent, tracestate string
+	err := reader.ForeachKey(func(k, v string) error {
+		switch strings.ToLower(k) {
+		case w3cTraceParentHeader:
+			traceparent = v
+		case w3cTraceStateHeader:
+			if tracestate != "" {
+				// multiple tracestate headers are combined
+				tracestate += ","
+			}
+			tracestate += v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if traceparent == "" {
+		return nil, ErrSpanContextNotFound
+	}
+	var ctx spanContext
+	sampled, err := parseTraceparent(&ctx, traceparent)
+	if err != nil {
+		return nil, err
+	}
+	priority := ext.PriorityAutoReject
+	if sampled {
+		priority = ext.PriorityAutoKeep
+	}
+	var members []string
+	for _, member := range strings.Split(tracestate, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		if !strings.HasPrefix(member, "dd=") {
+			members = append(members, member)
+			continue
+		}
+		for _, field := range strings.Split(strings.TrimPrefix(member, "dd="), ";") {
+			switch {
+			case strings.HasPrefix(field, "s:"):
+				p, err := strconv.Atoi(strings.TrimPrefix(field, "s:"))
+				if err != nil {
+					continue
+				}
+				// the sampled flag takes precedence over a conflicting priority
+				if sampled == (p > 0) {
+					priority = p
+				}
+			case strings.HasPrefix(field, "o:"):
+				ctx.origin = strings.TrimPrefix(field, "o:")
+			}
+		}
+	}
+	ctx.tracestate = strings.Join(members, ",")
+	ctx.setSamplingPriority(priority)
+	return &ctx, nil
+}
+
+// parseTraceparent parses the given traceparent header value into ctx and reports
+// whether the sampled flag was set.
+func parseTraceparent(ctx *spanContext, v string) (sampled bool, err error) {
+	// version-traceid-parentid-flags, e.g.:
+	// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	v = strings.ToLower(strings.TrimSpace(v))
+	if len(v) < 55 {
+		return false, ErrSpanContextCorrupted
+	}
+	version := v[0:2]
+	if version == "ff" || (version == "00" && len(v) != 55) || (len(v) > 55 && v[55] != '-') {
+		return false, ErrSpanContextCorrupted
+	}
+	if v[2] != '-' || v[35] != '-' || v[52] != '-' {
+		return false, ErrSpanContextCorrupted
+	}
+	if _, err := strconv.ParseUint(version, 16, 8); err != nil {
+		return false, ErrSpanContextCorrupted
+	}
+	if ctx.traceIDHigh, err = strconv.ParseUint(v[3:19], 16, 64); err != nil {
+		return false, ErrSpanContextCorrupted
+	}
+	if ctx.traceID, err = strconv.ParseUint(v[19:35], 16, 64); err != nil {
+		return false, ErrSpanContextCorrupted
+	}
+	if ctx.spanID, err = strconv.ParseUint(v[36:52], 16, 64); err != nil {
+		return false, ErrSpanContextCorrupted
+	}
+	flags, err := strconv.ParseUint(v[53:55], 16, 8)
+	if err != nil {
+		return false, ErrSpanContextCorrupted
+	}
+	if (ctx.traceIDHigh == 0 && ctx.traceID == 0) || ctx.spanID == 0 {
+		// all-zero IDs are invalid
+		return false, ErrSpanContextCorrupted
+	}
+	if ctx.traceID == 0 {
+		// a valid 128-bit trace ID whose lower half is zero can not be
+		// represented as a Datadog trace ID.
+		return false, ErrSpanContextNotFound
+	}
+	return flags&0x01 == 1, nil
+}
+
+const (
+	jaegerTraceHeader   = "uber-trace-id"
+	jaegerBaggagePrefix = "uberctx-"
+
+	jaegerFlagSampled = 1
+	jaegerFlagDebug   = 2
+)
+
+// JaegerPropagator implements Propagator and injects/extracts span contexts
+// using the Jaeger format ("uber-trace-id: {trace-id}:{span-id}:{parent-span-id}:{flags}"),
+// along with baggage items using "uberctx-" prefixed headers, see
+// https://www.jaegertracing.io/docs/latest/client-libraries/#propagation-format.
+// Only TextMap carriers are supported.
+//
+// Jaeger trace IDs may be 128 bits long, in which case only the lower 64 bits
+// are kept as the Datadog trace ID, and the upper 64 bits are propagated along.
+type JaegerPropagator struct {
+	baggageTags bool
+}
+
+var _ Propagator = (*JaegerPropagator)(nil)
+
+// JaegerOption represents an option that can be passed to NewJaegerPropagator.
+type JaegerOption func(*JaegerPropagator)
+
+// WithJaegerBaggageExtraction specifies whether extracted baggage items should
+// also be set as tags on the first span started from the extracted context.
+func WithJaegerBaggageExtraction(enabled bool) JaegerOption {
+	return func(p *JaegerPropagator) {
+		p.baggageTags = enabled
+	}
+}
+
+// NewJaegerPropagator returns a new JaegerPropagator configured with the given
+// options. It may be used with WithPropagator, or through the "jaeger" style in
+// the DD_PROPAGATION_STYLE_INJECT and DD_PROPAGATION_STYLE_EXTRACT environment
+// variables, to be used alongside the Datadog headers.
+func NewJaegerPropagator(opts ...JaegerOption) *JaegerPropagator {
+	p := new(JaegerPropagator)
+	for _, fn := range opts {
+		fn(p)
+	}
+	return p
+}
+
+// Inject implements Propagator.
+func (p *JaegerPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	switch c := carrier.(type) {
+	case TextMapWriter:
+		return p.injectTextMap(spanCtx, c)
+	default:
+		return ErrInvalidCarrier
+	}
+}
+
+func (*JaegerPropagator) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
+	ctx, ok := spanCtx.(*spanContext)
+	if !ok || ctx.traceID == 0 || ctx.spanID == 0 {
+		return ErrInvalidSpanContext
+	}
+	var flags int
+	if ctx.samplingPriority() >= ext.PriorityAutoKeep {
+		flags = jaegerFlagSampled
+	}
+	traceID := strconv.FormatUint(ctx.traceID, 16)
+	if ctx.traceIDHigh != 0 {
+		traceID = fmt.Sprintf("%x%016x", ctx.traceIDHigh, ctx.traceID)
+	}
+	// the parent span ID is deprecated and always set to 0
+	writer.Set(jaegerTraceHeader, fmt.Sprintf("%s:%x:0:%x", traceID, ctx.spanID, flags))
+	ctx.ForeachBaggageItem(func(k, v string) bool {
+		writer.Set(jaegerBaggagePrefix+k, url.QueryEscape(v))
+		return true
+	})
+	return nil
+}
+
+// Extract implements Propagator.
+func (p *JaegerPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	switch c := carrier.(type) {
+	case TextMapReader:
+		return p.extractTextMap(c)
+	default:
+		return nil, ErrInvalidCarrier
+	}
+}
+
+func (p *JaegerPropagator) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
+	var (
+		ctx   spanContext
+		found bool
+	)
+	err := reader.ForeachKey(func(k, v string) error {
+		key := strings.ToLower(k)
+		switch {
+		case key == jaegerTraceHeader:
+			found = true
+			return parseJaegerTraceID(&ctx, v)
+		case strings.HasPrefix(key, jaegerBaggagePrefix):
+			if uv, err := url.QueryUnescape(v); err == nil {
+				v = uv
+			}
+			key = strings.TrimPrefix(key, jaegerBaggagePrefix)
+			ctx.setBaggageItem(key, v)
+			if p.baggageTags {
+				if ctx.tags == nil {
+					ctx.tags = make(map[string]string)
+				}
+				ctx.tags[key] = v
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrSpanContextNotFound
+	}
+	return &ctx, nil
+}
+
+// parseJaegerTraceID parses the given "uber-trace-id" header value into ctx.
+func parseJaegerTraceID(ctx *spanContext, v string) error {
+	if uv, err := url.QueryUnescape(v); err == nil {
+		// some clients URL-encode the header value
+		v = uv
+	}
+	parts := strings.Split(v, ":")
+	if len(parts) != 4 {
+		return ErrSpanContextCorrupted
+	}
+	tid := parts[0]
+	if tid == "" || len(tid) > 32 {
+		return ErrSpanContextCorrupted
+	}
+	if len(tid) > 16 {
+		high, err := strconv.ParseUint(tid[:len(tid)-16], 16, 64)
+		if err != nil {
+			return ErrSpanContextCorrupted
+		}
+		ctx.traceIDHigh = high
+		tid = tid[len(tid)-16:]
+	}
+	var err error
+	if ctx.traceID, err = strconv.ParseUint(tid, 16, 64); err != nil {
+		return ErrSpanContextCorrupted
+	}
+	if ctx.spanID, err = strconv.ParseUint(parts[1], 16, 64); err != nil {
+		return ErrSpanContextCorrupted
+	}
+	if _, err := strconv.ParseUint(parts[2], 16, 64); err != nil {
+		return ErrSpanContextCorrupted
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return ErrSpanContextCorrupted
+	}
+	if ctx.traceID == 0 || ctx.spanID == 0 {
+		return ErrSpanContextCorrupted
+	}
+	switch {
+	case flags&jaegerFlagDebug != 0:
+		ctx.setSamplingPriority(ext.PriorityUserKeep)
+	case flags&jaegerFlagSampled != 0:
+		ctx.setSamplingPriority(ext.PriorityAutoKeep)
+	default:
+		ctx.setSamplingPriority(ext.PriorityAutoReject)
+	}
+	return nil
+}
+
+const (
+	baggageHeader = "baggage"
+
+	// baggageMaxMembers and baggageMaxBytes are the limits up to which the
+	// W3C specification requires baggage to be propagated.
+	baggageMaxMembers = 64
+	baggageMaxBytes   = 8192
+)
+
+// BaggagePropagator implements Propagator and injects/extracts baggage items
+// using the W3C Baggage header ("baggage: key1=value1,key2=value2"), see
+// https://www.w3.org/TR/baggage. It doesn't propagate trace and span IDs: it
+// is meant to be used alongside other propagators, for example by adding
+// "baggage" to the DD_PROPAGATION_STYLE_INJECT and DD_PROPAGATION_STYLE_EXTRACT
+// environment variables, or through CompositePropagator. When used on its own,
+// spans started from the extracted context begin a new trace carrying the
+// extracted items. Only TextMap carriers are supported.
+type BaggagePropagator struct{}
+
+var _ Propagator = (*BaggagePropagator)(nil)
+
+// Inject implements Propagator.
+func (p *BaggagePropagator) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(TextMapWriter)
+	if !ok {
+		return ErrInvalidCarrier
+	}
+	if spanCtx == nil {
+		return ErrInvalidSpanContext
+	}
+	var keys []string
+	items := make(map[string]string)
+	spanCtx.ForeachBaggageItem(func(k, v string) bool {
+		keys = append(keys, k)
+		items[k] = v
+		return true
+	})
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i == baggageMaxMembers {
+			break
+		}
+		member := baggageEscape(k) + "=" + baggageEscape(items[k])
+		if b.Len()+len(member)+1 > baggageMaxBytes {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(member)
+	}
+	writer.Set(baggageHeader, b.String())
+	return nil
+}
+
+// baggageEscape percent-encodes s for use as a baggage key or value.
+func baggageEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// Extract implements Propagator. The returned span context only carries the
+// extracted baggage items, and ErrSpanContextNotFound is returned when there
+// are none. Properties of list members are ignored.
+func (p *BaggagePropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	reader, ok := carrier.(TextMapReader)
+	if !ok {
+		return nil, ErrInvalidCarrier
+	}
+	var ctx spanContext
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) != baggageHeader {
+			return nil
+		}
+		for _, member := range strings.Split(v, ",") {
+			if i := strings.IndexByte(member, ';'); i >= 0 {
+				// drop properties
+				member = member[:i]
+			}
+			kv := strings.SplitN(member, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key, err := url.PathUnescape(strings.TrimSpace(kv[0]))
+			if err != nil || key == "" {
+				continue
+			}
+			value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+			if err != nil {
+				continue
+			}
+			ctx.setBaggageItem(key, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ctx.baggage) == 0 {
+		return nil, ErrSpanContextNotFound
+	}
+	return &ctx, nil
+}
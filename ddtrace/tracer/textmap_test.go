@@ -7,7 +7,9 @@ package tracer
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"testing"
@@ -40,6 +42,63 @@ func TestHTTPHeadersCarrierForeachKey(t *testing.T) {
 	assert.Equal("y", h.Get("B"))
 }
 
+func TestInjectExtractHTTP(t *testing.T) {
+	Start(withTransport(newDefaultTransport()))
+	defer Stop()
+
+	assert := assert.New(t)
+	span := StartSpan("web.request")
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Nil(InjectHTTP(span, req))
+	assert.Equal(strconv.FormatUint(span.Context().TraceID(), 10), req.Header.Get(DefaultTraceIDHeader))
+
+	sctx, err := ExtractHTTP(req)
+	assert.Nil(err)
+	assert.Equal(span.Context().TraceID(), sctx.TraceID())
+	assert.Equal(span.Context().SpanID(), sctx.SpanID())
+
+	assert.Equal(ErrInvalidSpanContext, InjectHTTP(nil, req))
+	_, err = ExtractHTTP(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(ErrSpanContextNotFound, err)
+}
+
+func TestRemoteSpanContext(t *testing.T) {
+	t.Run("priority", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer()
+		keep := ext.PriorityAutoKeep
+		ctx := RemoteSpanContext(0x4bf92f3577b34da6, 0xa3ce929d0e0e4736, 0x00f067aa0ba902b7, &keep)
+		child := tracer.StartSpan("child", ChildOf(ctx)).(*span)
+		assert.Equal(uint64(0xa3ce929d0e0e4736), child.TraceID)
+		assert.Equal(uint64(0x00f067aa0ba902b7), child.ParentID)
+		assert.Equal(float64(ext.PriorityAutoKeep), child.Metrics[keySamplingPriority])
+
+		headers := TextMapCarrier(map[string]string{})
+		assert.Nil((&W3CTraceContextPropagator{}).Inject(child.Context(), headers))
+		assert.Equal(fmt.Sprintf("00-4bf92f3577b34da6a3ce929d0e0e4736-%016x-01", child.SpanID), headers[w3cTraceParentHeader])
+	})
+
+	t.Run("no-priority", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer()
+		ctx := RemoteSpanContext(0, 1, 2, nil)
+		assert.False(ctx.(*spanContext).hasSamplingPriority())
+		child := tracer.StartSpan("child", ChildOf(ctx)).(*span)
+		assert.Equal(uint64(1), child.TraceID)
+		assert.Equal(uint64(2), child.ParentID)
+		assert.False(child.context.hasSamplingPriority())
+	})
+
+	t.Run("no-parent", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer()
+		root := tracer.StartSpan("root", ChildOf(RemoteSpanContext(0, 1, 0, nil))).(*span)
+		assert.Equal(uint64(1), root.TraceID)
+		assert.Equal(uint64(0), root.ParentID)
+		assert.Equal(root, root.context.trace.root)
+	})
+}
+
 func TestHTTPHeadersCarrierForeachKeyError(t *testing.T) {
 	want := errors.New("random error")
 	h := http.Header{}
@@ -173,6 +232,42 @@ func TestTextMapPropagatorOrigin(t *testing.T) {
 	}
 }
 
+func TestTextMapPropagatorUserID(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	root := tracer.StartSpan("web.request").(*span)
+	root.SetUser("user-1")
+	dst := map[string]string{}
+	assert.Nil(tracer.Inject(root.Context(), TextMapCarrier(dst)))
+	_, ok := dst[userIDHeader]
+	assert.False(ok)
+
+	root.SetUser("user-2", WithUserPropagation(true))
+	child := tracer.StartSpan("db.query", ChildOf(root.Context())).(*span)
+	assert.Nil(tracer.Inject(child.Context(), TextMapCarrier(dst)))
+	assert.Equal("user-2", dst[userIDHeader])
+
+	// the header is ignored unless extraction is enabled
+	ctx, err := tracer.Extract(TextMapCarrier(dst))
+	assert.Nil(err)
+	assert.Empty(ctx.(*spanContext).userID())
+	remote := tracer.StartSpan("http.request", ChildOf(ctx)).(*span)
+	_, ok = remote.Meta[ext.UserID]
+	assert.False(ok)
+	dst2 := map[string]string{}
+	assert.Nil(tracer.Inject(remote.Context(), TextMapCarrier(dst2)))
+	_, ok = dst2[userIDHeader]
+	assert.False(ok)
+
+	trusted := NewPropagator(&PropagatorConfig{ExtractUserID: true})
+	ctx, err = trusted.Extract(TextMapCarrier(dst))
+	assert.Nil(err)
+	remote = tracer.StartSpan("http.request", ChildOf(ctx)).(*span)
+	assert.Equal("user-2", remote.Meta[ext.UserID])
+}
+
 func TestTextMapPropagatorInjectExtract(t *testing.T) {
 	propagator := NewPropagator(&PropagatorConfig{
 		BaggagePrefix: "bg-",
@@ -281,4 +376,447 @@ func TestB3(t *testing.T) {
 		assert.True(sctx.hasSamplingPriority())
 		assert.Equal(sctx.samplingPriority(), 2)
 	})
+
+	t.Run("extract-128bit", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_EXTRACT", "b3multi")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_EXTRACT")
+
+		headers := TextMapCarrier(map[string]string{
+			b3TraceIDHeader: "463ac35c9f6413ad48485a3953bb6124",
+			b3SpanIDHeader:  "a2fb4a1d1a96d312",
+			b3SampledHeader: "true",
+		})
+
+		tracer := newTracer()
+		assert := assert.New(t)
+		ctx, err := tracer.Extract(headers)
+		assert.Nil(err)
+		sctx, ok := ctx.(*spanContext)
+		assert.True(ok)
+
+		assert.Equal(uint64(0x48485a3953bb6124), sctx.traceID)
+		assert.Equal(uint64(0xa2fb4a1d1a96d312), sctx.spanID)
+		assert.Equal(ext.PriorityAutoKeep, sctx.samplingPriority())
+	})
+
+	t.Run("extract-debug", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_EXTRACT", "b3")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_EXTRACT")
+
+		headers := TextMapCarrier(map[string]string{
+			b3TraceIDHeader: "1",
+			b3SpanIDHeader:  "1",
+			b3FlagsHeader:   "1",
+		})
+
+		tracer := newTracer()
+		assert := assert.New(t)
+		ctx, err := tracer.Extract(headers)
+		assert.Nil(err)
+		assert.Equal(ext.PriorityUserKeep, ctx.(*spanContext).samplingPriority())
+	})
+
+	t.Run("extract-invalid", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_EXTRACT", "b3")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_EXTRACT")
+
+		tracer := newTracer()
+		for _, headers := range []TextMapCarrier{
+			{b3TraceIDHeader: "xyz", b3SpanIDHeader: "1"},
+			{b3TraceIDHeader: "1", b3SpanIDHeader: "1", b3SampledHeader: "maybe"},
+			{b3TraceIDHeader: "463ac35c9f6413ad48485a3953bb6124ff", b3SpanIDHeader: "1"},
+		} {
+			_, err := tracer.Extract(headers)
+			assert.Equal(t, ErrSpanContextCorrupted, err)
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_INJECT", "b3single")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_INJECT")
+		os.Setenv("DD_PROPAGATION_STYLE_EXTRACT", "b3single")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_EXTRACT")
+
+		tracer := newTracer()
+		root := tracer.StartSpan("web.request").(*span)
+		root.SetTag(ext.SamplingPriority, ext.PriorityUserKeep)
+		ctx := root.Context().(*spanContext)
+		headers := TextMapCarrier(map[string]string{})
+		assert := assert.New(t)
+		assert.Nil(tracer.Inject(ctx, headers))
+		assert.Equal(fmt.Sprintf("%016x-%016x-1", root.TraceID, root.SpanID), headers[b3SingleHeader])
+		assert.Len(headers, 1)
+
+		sctx, err := tracer.Extract(headers)
+		assert.Nil(err)
+		assert.Equal(root.TraceID, sctx.(*spanContext).traceID)
+		assert.Equal(root.SpanID, sctx.(*spanContext).spanID)
+		assert.Equal(ext.PriorityAutoKeep, sctx.(*spanContext).samplingPriority())
+	})
+
+	t.Run("single-extract", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_EXTRACT", "b3single")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_EXTRACT")
+
+		tracer := newTracer()
+		for v, want := range map[string]struct {
+			traceID, spanID uint64
+			priority        int
+			err             error
+		}{
+			"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90": {0x64fe8b2a57d3eff7, 0xe457b5a2e4d86bd1, 1, nil},
+			"a3ce929d0e0e4736-00f067aa0ba902b7-d":                                  {0xa3ce929d0e0e4736, 0x00f067aa0ba902b7, 2, nil},
+			"a3ce929d0e0e4736-00f067aa0ba902b7-0":                                  {0xa3ce929d0e0e4736, 0x00f067aa0ba902b7, 0, nil},
+			"0":                                                                    {err: ErrSpanContextNotFound},
+			"a3ce929d0e0e4736-xyz":                                                 {err: ErrSpanContextCorrupted},
+			"a3ce929d0e0e4736-00f067aa0ba902b7-2":                                  {err: ErrSpanContextCorrupted},
+			"a3ce929d0e0e4736-00f067aa0ba902b7-1-q-1":                              {err: ErrSpanContextCorrupted},
+		} {
+			ctx, err := tracer.Extract(TextMapCarrier{b3SingleHeader: v})
+			if want.err != nil {
+				assert.Equal(t, want.err, err, v)
+				continue
+			}
+			assert.Nil(t, err, v)
+			sctx := ctx.(*spanContext)
+			assert.Equal(t, want.traceID, sctx.traceID, v)
+			assert.Equal(t, want.spanID, sctx.spanID, v)
+			assert.Equal(t, want.priority, sctx.samplingPriority(), v)
+		}
+	})
+
+	t.Run("option", func(t *testing.T) {
+		tracer := newTracer(WithB3Propagation())
+		root := tracer.StartSpan("web.request").(*span)
+		root.SetTag(ext.SamplingPriority, ext.PriorityAutoKeep)
+		headers := TextMapCarrier(map[string]string{})
+		assert := assert.New(t)
+		assert.Nil(tracer.Inject(root.Context(), headers))
+		assert.Equal(strconv.FormatUint(root.TraceID, 16), headers[b3TraceIDHeader])
+		assert.Equal(strconv.FormatUint(root.TraceID, 10), headers[DefaultTraceIDHeader])
+
+		ctx, err := tracer.Extract(TextMapCarrier{b3SingleHeader: "a3ce929d0e0e4736-00f067aa0ba902b7-1"})
+		assert.Nil(err)
+		assert.Equal(uint64(0xa3ce929d0e0e4736), ctx.(*spanContext).traceID)
+
+		// without the option, B3 headers are ignored
+		_, err = newTracer().Extract(TextMapCarrier{b3SingleHeader: "a3ce929d0e0e4736-00f067aa0ba902b7-1"})
+		assert.Equal(ErrSpanContextNotFound, err)
+	})
+}
+
+func TestW3CTraceContext(t *testing.T) {
+	t.Run("extract", func(t *testing.T) {
+		headers := TextMapCarrier(map[string]string{
+			w3cTraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			w3cTraceStateHeader:  "dd=s:2;o:synthetics,congo=t61rcWkgMzE",
+		})
+
+		tracer := newTracer()
+		assert := assert.New(t)
+		ctx, err := tracer.Extract(headers)
+		assert.Nil(err)
+		sctx, ok := ctx.(*spanContext)
+		assert.True(ok)
+
+		assert.Equal(uint64(0xa3ce929d0e0e4736), sctx.traceID)
+		assert.Equal(uint64(0x4bf92f3577b34da6), sctx.traceIDHigh)
+		assert.Equal(uint64(0x00f067aa0ba902b7), sctx.spanID)
+		assert.Equal(2, sctx.samplingPriority())
+		assert.Equal("synthetics", sctx.origin)
+		assert.Equal("congo=t61rcWkgMzE", sctx.tracestate)
+	})
+
+	t.Run("extract-flags", func(t *testing.T) {
+		for tp, want := range map[string]int{
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": 1,
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00": 0,
+		} {
+			ctx, err := (&W3CTraceContextPropagator{}).Extract(TextMapCarrier(map[string]string{
+				w3cTraceParentHeader: tp,
+				w3cTraceStateHeader:  "dd=s:2",
+			}))
+			assert.Nil(t, err)
+			// a priority conflicting with the sampled flag is ignored
+			if want == 1 {
+				want = 2
+			}
+			assert.Equal(t, want, ctx.(*spanContext).samplingPriority(), tp)
+		}
+	})
+
+	t.Run("extract-invalid", func(t *testing.T) {
+		for _, tp := range []string{
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+			"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+		} {
+			_, err := (&W3CTraceContextPropagator{}).Extract(TextMapCarrier(map[string]string{
+				w3cTraceParentHeader: tp,
+			}))
+			assert.Equal(t, ErrSpanContextCorrupted, err, tp)
+		}
+		_, err := (&W3CTraceContextPropagator{}).Extract(TextMapCarrier(map[string]string{}))
+		assert.Equal(t, ErrSpanContextNotFound, err)
+	})
+
+	t.Run("extract-future-version", func(t *testing.T) {
+		ctx, err := (&W3CTraceContextPropagator{}).Extract(TextMapCarrier(map[string]string{
+			w3cTraceParentHeader: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-will-be-like",
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(0x00f067aa0ba902b7), ctx.SpanID())
+	})
+
+	t.Run("inject", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_INJECT", "tracecontext")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_INJECT")
+
+		tracer := newTracer()
+		root := tracer.StartSpan("web.request").(*span)
+		root.SetTag(ext.SamplingPriority, ext.PriorityUserKeep)
+		root.SpanID = 0x00f067aa0ba902b7
+		root.context.spanID = root.SpanID
+		root.context.traceID = 0xa3ce929d0e0e4736
+		headers := TextMapCarrier(map[string]string{})
+		err := tracer.Inject(root.Context(), headers)
+
+		assert := assert.New(t)
+		assert.Nil(err)
+		assert.Equal("00-0000000000000000a3ce929d0e0e4736-00f067aa0ba902b7-01", headers[w3cTraceParentHeader])
+		assert.Equal("dd=s:2", headers[w3cTraceStateHeader])
+		assert.Empty(headers[DefaultTraceIDHeader])
+	})
+
+	t.Run("round-trip", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_INJECT", "datadog,tracecontext")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_INJECT")
+
+		tracer := newTracer()
+		assert := assert.New(t)
+		ctx, err := tracer.Extract(TextMapCarrier(map[string]string{
+			w3cTraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			w3cTraceStateHeader:  "rojo=00f067aa0ba902b7,dd=o:synthetics,congo=t61rcWkgMzE",
+		}))
+		assert.Nil(err)
+		child := tracer.StartSpan("child", ChildOf(ctx)).(*span)
+
+		headers := TextMapCarrier(map[string]string{})
+		assert.Nil(tracer.Inject(child.Context(), headers))
+		assert.Equal(fmt.Sprintf("00-4bf92f3577b34da6a3ce929d0e0e4736-%016x-00", child.SpanID), headers[w3cTraceParentHeader])
+		assert.Equal("dd=s:0;o:synthetics,rojo=00f067aa0ba902b7,congo=t61rcWkgMzE", headers[w3cTraceStateHeader])
+		assert.Equal(strconv.FormatUint(uint64(0xa3ce929d0e0e4736), 10), headers[DefaultTraceIDHeader])
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		tracer := newTracer()
+		assert := assert.New(t)
+		ctx, err := tracer.Extract(TextMapCarrier(map[string]string{
+			DefaultTraceIDHeader:  "1",
+			DefaultParentIDHeader: "2",
+			w3cTraceParentHeader:  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		}))
+		assert.Nil(err)
+		// Datadog headers take precedence
+		assert.Equal(uint64(1), ctx.TraceID())
+		assert.Equal(uint64(2), ctx.SpanID())
+	})
+}
+
+func TestJaeger(t *testing.T) {
+	t.Run("inject", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_INJECT", "jaeger")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_INJECT")
+
+		tracer := newTracer()
+		root := tracer.StartSpan("web.request").(*span)
+		root.SetTag(ext.SamplingPriority, ext.PriorityUserKeep)
+		root.SetBaggageItem("user", "a b")
+		headers := TextMapCarrier(map[string]string{})
+		err := tracer.Inject(root.Context(), headers)
+
+		assert := assert.New(t)
+		assert.Nil(err)
+		assert.Equal(fmt.Sprintf("%x:%x:0:1", root.TraceID, root.SpanID), headers[jaegerTraceHeader])
+		assert.Equal("a+b", headers["uberctx-user"])
+		assert.NotContains(headers, DefaultTraceIDHeader)
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		assert := assert.New(t)
+		p := NewJaegerPropagator()
+		ctx, err := p.Extract(TextMapCarrier(map[string]string{
+			"Uber-Trace-Id":  "4bf92f3577b34da6a3ce929d0e0e4736%3A00f067aa0ba902b7%3A0%3A3",
+			"uberctx-user":   "a+b",
+			"uberctx-tenant": "t1",
+		}))
+		assert.Nil(err)
+		sctx := ctx.(*spanContext)
+		assert.Equal(uint64(0xa3ce929d0e0e4736), sctx.traceID)
+		assert.Equal(uint64(0x4bf92f3577b34da6), sctx.traceIDHigh)
+		assert.Equal(uint64(0x00f067aa0ba902b7), sctx.spanID)
+		assert.Equal(ext.PriorityUserKeep, sctx.samplingPriority())
+		assert.Equal(map[string]string{"user": "a b", "tenant": "t1"}, sctx.baggage)
+		assert.Nil(sctx.tags)
+
+		for flags, prio := range map[string]int{"0": ext.PriorityAutoReject, "1": ext.PriorityAutoKeep} {
+			ctx, err := p.Extract(TextMapCarrier(map[string]string{jaegerTraceHeader: "1:2:0:" + flags}))
+			assert.Nil(err)
+			assert.Equal(prio, ctx.(*spanContext).samplingPriority())
+		}
+	})
+
+	t.Run("baggage-tags", func(t *testing.T) {
+		tracer := newTracer(WithPropagator(NewJaegerPropagator(WithJaegerBaggageExtraction(true))))
+		assert := assert.New(t)
+		ctx, err := tracer.Extract(TextMapCarrier(map[string]string{
+			jaegerTraceHeader: "1:2:0:1",
+			"uberctx-user":    "bob",
+		}))
+		assert.Nil(err)
+		child := tracer.StartSpan("child", ChildOf(ctx)).(*span)
+		assert.Equal(uint64(1), child.TraceID)
+		assert.Equal(uint64(2), child.ParentID)
+		assert.Equal("bob", child.Meta["user"])
+		assert.Equal("bob", child.BaggageItem("user"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		p := NewJaegerPropagator()
+		for _, v := range []string{"1:2:0", "x:2:0:1", "1:x:0:1", "1:2:0:x", "0:0:0:1", "1:2:x:1",
+			"4bf92f3577b34da60000000000000000:2:0:1"} {
+			_, err := p.Extract(TextMapCarrier(map[string]string{jaegerTraceHeader: v}))
+			assert.Equal(t, ErrSpanContextCorrupted, err, v)
+		}
+		_, err := p.Extract(TextMapCarrier(map[string]string{}))
+		assert.Equal(t, ErrSpanContextNotFound, err)
+		assert.Equal(t, ErrInvalidSpanContext, p.Inject(&spanContext{}, TextMapCarrier{}))
+	})
+}
+
+func TestCompositePropagator(t *testing.T) {
+	dd := NewPropagator(nil)
+	w3c := &W3CTraceContextPropagator{}
+	headers := TextMapCarrier(map[string]string{
+		DefaultTraceIDHeader:  "1",
+		DefaultParentIDHeader: "2",
+		w3cTraceParentHeader:  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+
+	t.Run("extract-order", func(t *testing.T) {
+		assert := assert.New(t)
+		ctx, err := CompositePropagator(dd, w3c).Extract(headers)
+		assert.Nil(err)
+		assert.Equal(uint64(1), ctx.TraceID())
+		assert.Equal(uint64(2), ctx.SpanID())
+
+		ctx, err = CompositePropagator(w3c, dd).Extract(headers)
+		assert.Nil(err)
+		assert.Equal(uint64(0xa3ce929d0e0e4736), ctx.TraceID())
+		assert.Equal(uint64(0x00f067aa0ba902b7), ctx.SpanID())
+	})
+
+	t.Run("extract-fallback", func(t *testing.T) {
+		assert := assert.New(t)
+		p := CompositePropagator(&B3MultiPropagator{}, nil, w3c)
+		ctx, err := p.Extract(headers)
+		assert.Nil(err)
+		assert.Equal(uint64(0xa3ce929d0e0e4736), ctx.TraceID())
+
+		_, err = p.Extract(TextMapCarrier{})
+		assert.Equal(ErrSpanContextNotFound, err)
+		_, err = p.Extract(TextMapCarrier{w3cTraceParentHeader: "00-x"})
+		assert.Equal(ErrSpanContextCorrupted, err)
+	})
+
+	t.Run("inject", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer(WithPropagator(CompositePropagator(dd, &B3MultiPropagator{})))
+		root := tracer.StartSpan("web.request").(*span)
+		out := TextMapCarrier{}
+		assert.Nil(tracer.Inject(root.Context(), out))
+		assert.Equal(strconv.FormatUint(root.TraceID, 10), out[DefaultTraceIDHeader])
+		assert.Equal(strconv.FormatUint(root.TraceID, 16), out[b3TraceIDHeader])
+
+		out = TextMapCarrier{}
+		p := CompositePropagator(&B3MultiPropagator{}, dd).WithInjectAllFormats(false)
+		assert.Nil(p.Inject(root.Context(), out))
+		assert.Equal(strconv.FormatUint(root.TraceID, 16), out[b3TraceIDHeader])
+		assert.NotContains(out, DefaultTraceIDHeader)
+	})
+}
+
+func TestBaggagePropagator(t *testing.T) {
+	t.Run("inject", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer(WithPropagator(&BaggagePropagator{}))
+		root := tracer.StartSpan("web.request")
+		root.SetBaggageItem("user id", "a,b=c")
+		root.SetBaggageItem("tenant", "t1")
+		out := TextMapCarrier{}
+		assert.Nil(tracer.Inject(root.Context(), out))
+		assert.Equal("tenant=t1,user%20id=a%2Cb%3Dc", out[baggageHeader])
+		assert.NotContains(out, DefaultTraceIDHeader)
+
+		out = TextMapCarrier{}
+		assert.Nil(tracer.Inject(tracer.StartSpan("empty").Context(), out))
+		assert.NotContains(out, baggageHeader)
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer(WithPropagator(&BaggagePropagator{}))
+		ctx, err := tracer.Extract(TextMapCarrier{
+			"Baggage": "user%20id=a%2Cb%3Dc;prop=1, tenant = t1 ,invalid",
+		})
+		assert.Nil(err)
+		assert.Equal(map[string]string{"user id": "a,b=c", "tenant": "t1"}, ctx.(*spanContext).baggage)
+
+		// a new trace is started which carries the baggage
+		root := tracer.StartSpan("web.request", ChildOf(ctx)).(*span)
+		assert.NotZero(root.TraceID)
+		assert.Zero(root.ParentID)
+		assert.Equal("t1", root.BaggageItem("tenant"))
+
+		_, err = tracer.Extract(TextMapCarrier{})
+		assert.Equal(ErrSpanContextNotFound, err)
+	})
+
+	t.Run("chained", func(t *testing.T) {
+		os.Setenv("DD_PROPAGATION_STYLE_INJECT", "datadog,baggage")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_INJECT")
+		os.Setenv("DD_PROPAGATION_STYLE_EXTRACT", "baggage,datadog")
+		defer os.Unsetenv("DD_PROPAGATION_STYLE_EXTRACT")
+		assert := assert.New(t)
+
+		tracer := newTracer()
+		ctx, err := tracer.Extract(TextMapCarrier{
+			DefaultTraceIDHeader:  "1",
+			DefaultParentIDHeader: "2",
+			baggageHeader:         "tenant=t1",
+		})
+		assert.Nil(err)
+		assert.Equal(uint64(1), ctx.TraceID())
+		child := tracer.StartSpan("child", ChildOf(ctx))
+		assert.Equal("t1", child.BaggageItem("tenant"))
+
+		out := TextMapCarrier{}
+		assert.Nil(tracer.Inject(child.Context(), out))
+		assert.Equal("1", out[DefaultTraceIDHeader])
+		assert.Equal("tenant=t1", out[baggageHeader])
+	})
+
+	t.Run("as-tags", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer := newTracer(WithBaggageAsSpanTags(true))
+		root := tracer.StartSpan("root")
+		root.SetBaggageItem("tenant", "t1")
+		child := tracer.StartSpan("child", ChildOf(root.Context()), Tag("user", "bob")).(*span)
+		assert.Equal("t1", child.Meta["tenant"])
+		assert.Equal("bob", child.Meta["user"])
+	})
 }
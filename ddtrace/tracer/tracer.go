@@ -9,11 +9,13 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 
 	"github.com/DataDog/datadog-go/statsd"
@@ -33,8 +35,9 @@ type tracer struct {
 	*config
 	*payload
 
-	// flushChan triggers a flush of the buffered payload. If the sent channel is
-	// not nil (only in tests), it will receive confirmation of a finished flush.
+	// flushChan triggers a flush of the buffered payload. It holds a single
+	// pending request, so that flushes can be requested without blocking,
+	// including from the worker itself.
 	flushChan chan struct{}
 
 	// exitChan requests that the tracer stops.
@@ -74,12 +77,26 @@ type tracer struct {
 	// rules for applying a sampling rate to spans that match the designated service
 	// or operation name.
 	rulesSampling *rulesSampler
+
+	// tailSampling holds the tail sampler, if tail sampling is enabled. Its
+	// buffer is only accessed from the worker goroutine.
+	tailSampling *tailSampler
+
+	// stats holds the stats of finished spans, if enabled via WithSpanStats.
+	stats *statsBuffer
+
+	// spool holds the payloads flushed while the agent is unreachable, if
+	// enabled via WithSpoolDir.
+	spool *spooler
+
+	// agentDown is set to 1 (atomically) when the agent health check fails.
+	agentDown int32
 }
 
 const (
-	// flushInterval is the interval at which the payload contents will be flushed
-	// to the transport.
-	flushInterval = 2 * time.Second
+	// defaultFlushInterval is the default interval at which the payload contents
+	// will be flushed to the transport.
+	defaultFlushInterval = 2 * time.Second
 
 	// statsInterval is the interval at which health metrics will be sent with the
 	// statsd client.
@@ -105,11 +122,14 @@ func Start(opts ...StartOption) {
 	if internal.Testing {
 		return // mock tracer active
 	}
-	internal.SetGlobalTracer(newTracer(opts...))
+	t := newTracer(opts...)
+	internal.SetGlobalTracer(t)
+	globalconfig.SetStatsd(t.config.statsd)
 }
 
 // Stop stops the started tracer. Subsequent calls are valid but become no-op.
 func Stop() {
+	globalconfig.SetStatsd(nil)
 	internal.SetGlobalTracer(&internal.NoopTracer{})
 	log.Flush()
 }
@@ -139,7 +159,7 @@ func Inject(ctx ddtrace.SpanContext, carrier interface{}) error {
 	return internal.GetGlobalTracer().Inject(ctx, carrier)
 }
 
-// payloadQueueSize is the buffer size of the trace channel.
+// payloadQueueSize is the default buffer size of the trace channel.
 const payloadQueueSize = 1000
 
 func newTracer(opts ...StartOption) *tracer {
@@ -148,11 +168,18 @@ func newTracer(opts ...StartOption) *tracer {
 	for _, fn := range opts {
 		fn(c)
 	}
+	if c.transport == nil && c.agentlessAPIKey != "" {
+		c.transport = newAgentlessTransport(agentlessURL(os.Getenv("DD_SITE")), c.agentlessAPIKey, c.httpRoundTripper)
+	}
 	if c.transport == nil {
-		c.transport = newTransport(c.agentAddr, c.httpRoundTripper)
+		rt := c.httpRoundTripper
+		if c.udsPath = c.agentSocket(); c.udsPath != "" {
+			rt = newUDSRoundTripper(c.udsPath)
+		}
+		c.transport = newTransport(c.agentAddr, rt)
 	}
 	if c.propagator == nil {
-		c.propagator = NewPropagator(nil)
+		c.propagator = NewPropagator(&PropagatorConfig{B3: c.propagateB3})
 	}
 	if c.logger != nil {
 		log.UseLogger(c.logger)
@@ -173,15 +200,21 @@ func newTracer(opts ...StartOption) *tracer {
 	t := &tracer{
 		config:           c,
 		payload:          newPayload(),
-		flushChan:        make(chan struct{}),
+		flushChan:        make(chan struct{}, 1),
 		exitChan:         make(chan struct{}),
-		payloadChan:      make(chan []*span, payloadQueueSize),
+		payloadChan:      make(chan []*span, c.maxBufferSize),
 		stopped:          make(chan struct{}),
 		rulesSampling:    newRulesSampler(c.samplingRules),
 		climit:           make(chan struct{}, concurrentConnectionLimit),
 		prioritySampling: newPrioritySampler(),
 		pid:              strconv.Itoa(os.Getpid()),
 	}
+	if len(c.tailSamplingRules) > 0 {
+		t.tailSampling = newTailSampler(c.tailSamplingRules, c.tailSamplingBufferSize)
+	}
+	if c.spanStats {
+		t.stats = newStatsBuffer(spanStatsBufferSize)
+	}
 	t.config.statsd.Incr("datadog.tracer.started", nil, 1)
 	if c.runtimeMetrics {
 		log.Debug("Runtime metrics enabled.")
@@ -191,6 +224,20 @@ func newTracer(opts ...StartOption) *tracer {
 			t.reportRuntimeMetrics(defaultMetricsReportInterval)
 		}()
 	}
+	if c.healthCheckInterval > 0 {
+		if c.spoolDir != "" {
+			if s, err := newSpooler(c.spoolDir, c.maxSpoolBytes); err != nil {
+				log.Error("unable to use spool directory %q: %v", c.spoolDir, err)
+			} else {
+				t.spool = s
+			}
+		}
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.checkAgentHealth(c.healthCheckInterval)
+		}()
+	}
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
@@ -209,7 +256,7 @@ func newTracer(opts ...StartOption) *tracer {
 // as periodically flushes traces to the transport.
 func (t *tracer) worker() {
 	defer t.config.statsd.Close()
-	ticker := time.NewTicker(flushInterval)
+	ticker := time.NewTicker(t.config.flushInterval)
 	defer ticker.Stop()
 
 	for {
@@ -254,7 +301,14 @@ func (t *tracer) pushTrace(trace []*span) {
 	select {
 	case t.payloadChan <- trace:
 	default:
+		t.config.statsd.Incr("datadog.tracer.buffer_overflow", nil, 1)
 		log.Error("payload queue full, dropping %d traces", len(trace))
+		// request an immediate flush rather than waiting for the next tick
+		select {
+		case t.flushChan <- struct{}{}:
+		default:
+			// flush already queued
+		}
 	}
 	if t.syncPush != nil {
 		// only in tests
@@ -274,12 +328,24 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 	} else {
 		startTime = opts.StartTime.UnixNano()
 	}
-	var context *spanContext
+	var current *span
+	if t.config.autoContext {
+		current = autoSpan()
+		if opts.Parent == nil && current != nil {
+			opts.Parent = current.context
+		}
+	}
+	var context, baggage *spanContext
 	if opts.Parent != nil {
 		if ctx, ok := opts.Parent.(*spanContext); ok {
 			context = ctx
 		}
 	}
+	if context != nil && context.traceID == 0 {
+		// the parent only carries baggage (e.g. as extracted by BaggagePropagator),
+		// so this is a new trace which inherits it.
+		context, baggage = nil, context
+	}
 	id := opts.SpanID
 	if id == 0 {
 		id = random.Uint64()
@@ -294,6 +360,14 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 		Start:    startTime,
 		taskEnd:  startExecutionTracerTask(operationName),
 	}
+	if t.config.slowSpanThreshold > 0 {
+		// measure the actual duration, regardless of the given start time
+		span.startMono = time.Now()
+	}
+	if t.config.autoContext {
+		setAutoSpan(span, current)
+	}
+	span.conditionalTags = opts.ConditionalTags
 	if context != nil {
 		// this is a child span
 		span.TraceID = context.traceID
@@ -312,9 +386,27 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 				// mark origin
 				span.setMeta(keyOrigin, context.origin)
 			}
+			for k, v := range context.tags {
+				span.setMeta(k, v)
+			}
 		}
 	}
 	span.context = newSpanContext(span, context)
+	if baggage != nil {
+		baggage.ForeachBaggageItem(func(k, v string) bool {
+			span.context.setBaggageItem(k, v)
+			return true
+		})
+	}
+	for k, v := range opts.Baggage {
+		span.context.setBaggageItem(k, v)
+	}
+	if t.config.baggageAsTags {
+		span.context.ForeachBaggageItem(func(k, v string) bool {
+			span.setMeta(k, v)
+			return true
+		})
+	}
 	if context == nil || context.span == nil {
 		// this is either a root span or it has a remote parent, we should add the PID.
 		span.setMeta(ext.Pid, t.pid)
@@ -338,6 +430,14 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 	if context == nil {
 		// this is a brand new trace, sample it
 		t.sample(span)
+	} else if context.span == nil {
+		// remote parent; record the upstream sampling decision maker
+		if dm := span.context.decisionMaker(); dm != "" {
+			span.setMeta(keyDecisionMaker, dm)
+		}
+		if uid := span.context.userID(); uid != "" {
+			span.setMeta(ext.UserID, uid)
+		}
 	}
 	return span
 }
@@ -363,6 +463,9 @@ func (t *tracer) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
 
 // flush will push any currently buffered traces to the server.
 func (t *tracer) flushPayload() {
+	if t.tailSampling != nil {
+		t.tailSampling.flush(t.encodeTrace)
+	}
 	if t.payload.itemCount() == 0 {
 		return
 	}
@@ -375,9 +478,27 @@ func (t *tracer) flushPayload() {
 			t.config.statsd.Timing("datadog.tracer.flush_duration", time.Since(start), nil, 1)
 		}(time.Now())
 		size, count := p.size(), p.itemCount()
+		var data []byte
+		if t.spool != nil {
+			// sending reads p without modifying its buffer, so its contents
+			// can still be spooled if sending fails
+			data = p.buf.Bytes()
+			if atomic.LoadInt32(&t.agentDown) == 1 {
+				t.spoolPayload(data, count)
+				return
+			}
+		}
 		log.Debug("Sending payload: size: %d traces: %d\n", size, count)
 		rc, err := t.config.transport.send(p)
 		if err != nil {
+			if isConnError(err) {
+				t.config.statsd.Incr("datadog.tracer.connection_errors", []string{"transport:" + t.config.transportName()}, 1)
+			}
+			if data != nil && isRetryable(err) {
+				log.Warn("spooling %d traces: %v", count, err)
+				t.spoolPayload(data, count)
+				return
+			}
 			t.config.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:send_failed"}, 1)
 			log.Error("lost %d traces: %v", count, err)
 		} else {
@@ -391,9 +512,24 @@ func (t *tracer) flushPayload() {
 	t.payload = newPayload()
 }
 
-// pushPayload pushes the trace onto the payload. If the payload becomes
-// larger than the threshold as a result, it sends a flush request.
+// pushPayload pushes the trace onto the payload, or onto the tail sampler's
+// buffer when tail sampling is enabled.
 func (t *tracer) pushPayload(trace []*span) {
+	if t.tailSampling != nil {
+		trace = t.tailSampling.push(trace)
+	}
+	if trace != nil {
+		t.encodeTrace(trace)
+	}
+	if t.syncPush != nil {
+		// only in tests
+		t.syncPush <- struct{}{}
+	}
+}
+
+// encodeTrace encodes the trace into the payload. If the payload becomes
+// larger than the threshold as a result, it sends a flush request.
+func (t *tracer) encodeTrace(trace []*span) {
 	if err := t.payload.push(trace); err != nil {
 		t.config.statsd.Incr("datadog.tracer.traces_dropped", []string{"reason:encoding_error"}, 1)
 		log.Error("error encoding msgpack: %v", err)
@@ -406,10 +542,6 @@ func (t *tracer) pushPayload(trace []*span) {
 			// flush already queued
 		}
 	}
-	if t.syncPush != nil {
-		// only in tests
-		t.syncPush <- struct{}{}
-	}
 }
 
 // sampleRateMetricKey is the metric key holding the applied sample rate. Has to be the same as the Agent.
@@ -429,8 +561,40 @@ func (t *tracer) sample(span *span) {
 	if rs, ok := sampler.(RateSampler); ok && rs.Rate() < 1 {
 		span.setMetric(sampleRateMetricKey, rs.Rate())
 	}
+	if ps := t.config.prioritySampler; ps != nil {
+		if d := ps.Sample(span); d != SamplingDecisionDeferToAgent {
+			span.SetTag(ext.SamplingPriority, d.Priority)
+			if d.Reason != "" {
+				span.SetTag(keySamplingReason, d.Reason)
+			}
+			return
+		}
+	}
+	if t.config.consistentSampling {
+		t.sampleConsistent(span, t.config.consistentSamplingRate)
+		return
+	}
 	if t.rulesSampling.apply(span) {
 		return
 	}
 	t.prioritySampling.apply(span)
 }
+
+// samplingMechanismRule is the "_dd.p.dm" value of sampling decisions made
+// using a user-defined rate.
+const samplingMechanismRule = "-3"
+
+// sampleConsistent makes a sampling decision for the given root span using a
+// hash of its trace ID, so that any service sampling the same trace at the same
+// rate comes to the same decision. The decision maker is recorded and propagated
+// so that downstream services can identify it.
+func (t *tracer) sampleConsistent(span *span, rate float64) {
+	if sampledByRate(span.TraceID, rate) {
+		span.SetTag(ext.SamplingPriority, ext.PriorityAutoKeep)
+	} else {
+		span.SetTag(ext.SamplingPriority, ext.PriorityAutoReject)
+	}
+	span.SetTag(keyRulesSamplerAppliedRate, rate)
+	span.SetTag(keyDecisionMaker, samplingMechanismRule)
+	span.context.setDecisionMaker(samplingMechanismRule)
+}
@@ -7,6 +7,7 @@ package tracer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -682,6 +683,168 @@ func TestTracerAtomicFlush(t *testing.T) {
 	assert.Len(traces[0], 4, "all spans should show up at once")
 }
 
+func TestTracerPartialFlush(t *testing.T) {
+	assert := assert.New(t)
+	tracer, transport, stop := startTestTracer(WithPartialFlush(2))
+	defer stop()
+
+	root := tracer.newRootSpan("pylons.request", "pylons", "/")
+	root.SetTag(ext.SamplingPriority, ext.PriorityUserKeep)
+	span1 := tracer.newChildSpan("redis.command.1", root)
+	span2 := tracer.newChildSpan("redis.command.2", root)
+	span3 := tracer.newChildSpan("redis.command.3", root)
+	span2.Finish()
+	span1.Finish()
+
+	tracer.flushAndWait(t, 1)
+	traces := transport.Traces()
+	assert.Len(traces, 1)
+	assert.Len(traces[0], 2, "finished spans should be flushed early")
+	assert.ElementsMatch([]uint64{span1.SpanID, span2.SpanID}, []uint64{traces[0][0].SpanID, traces[0][1].SpanID})
+	assert.Equal(float64(ext.PriorityUserKeep), traces[0][0].Metrics[keySamplingPriority])
+
+	span3.Finish()
+	root.Finish()
+
+	tracer.flushAndWait(t, 1)
+	traces = transport.Traces()
+	assert.Len(traces, 1)
+	assert.Len(traces[0], 2, "remaining spans should be flushed with the root")
+	for _, s := range traces[0] {
+		if s.SpanID == root.SpanID {
+			assert.Equal(float64(ext.PriorityUserKeep), s.Metrics[keySamplingPriority])
+		}
+	}
+}
+
+func TestTracerPartialFlushDisabled(t *testing.T) {
+	tracer, transport, stop := startTestTracer(WithPartialFlush(0))
+	defer stop()
+
+	root := tracer.newRootSpan("pylons.request", "pylons", "/")
+	for i := 0; i < 5; i++ {
+		tracer.newChildSpan("redis.command", root).Finish()
+	}
+	tracer.flushChan <- struct{}{}
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, transport.Traces(), 0)
+
+	root.Finish()
+	tracer.flushAndWait(t, 1)
+	traces := transport.Traces()
+	assert.Len(t, traces, 1)
+	assert.Len(t, traces[0], 6)
+}
+
+func TestTracerErrorSampling(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, transport, stop := startTestTracer(WithErrorSampling(1))
+		defer stop()
+
+		root := tracer.newRootSpan("pylons.request", "pylons", "/")
+		root.SetTag(ext.SamplingPriority, ext.PriorityAutoReject)
+		child := tracer.newChildSpan("redis.command", root)
+		child.Finish(WithError(errors.New("boom")))
+		root.Finish()
+
+		tracer.flushAndWait(t, 1)
+		traces := transport.Traces()
+		assert.Len(traces, 1)
+		for _, s := range traces[0] {
+			if s.SpanID == root.SpanID {
+				assert.Equal(float64(ext.PriorityUserKeep), s.Metrics[keySamplingPriority])
+			}
+		}
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, stop := startTestTracer(WithErrorSampling(1))
+		defer stop()
+
+		ctx, err := tracer.Extract(TextMapCarrier{
+			DefaultTraceIDHeader:  "1",
+			DefaultParentIDHeader: "1",
+			DefaultPriorityHeader: "0",
+		})
+		assert.NoError(err)
+		span := tracer.StartSpan("web.request", ChildOf(ctx)).(*span)
+		span.SetTag(ext.Error, true)
+		assert.Equal(ext.PriorityAutoReject, span.context.samplingPriority(), "inherited priorities are locked")
+		assert.Equal("2", span.Meta[keyUserPriority])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tracer, _, stop := startTestTracer(WithErrorSampling(0))
+		defer stop()
+
+		root := tracer.newRootSpan("pylons.request", "pylons", "/")
+		root.SetTag(ext.SamplingPriority, ext.PriorityAutoReject)
+		root.SetTag(ext.Error, errors.New("boom"))
+		assert.Equal(t, ext.PriorityAutoReject, root.context.samplingPriority())
+		assert.NotContains(t, root.Meta, keyUserPriority)
+	})
+}
+
+func TestTracerSlowSpanSampling(t *testing.T) {
+	t.Run("slow", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, _, stop := startTestTracer(WithSlowSpanSampling(10 * time.Millisecond))
+		defer stop()
+
+		root := tracer.newRootSpan("pylons.request", "pylons", "/")
+		root.SetTag(ext.SamplingPriority, ext.PriorityAutoReject)
+		fast := tracer.newChildSpan("redis.command", root)
+		fast.Finish()
+		assert.Equal(ext.PriorityAutoReject, root.context.samplingPriority())
+
+		slow := tracer.StartSpan("redis.command", ChildOf(root.Context()), StartTime(time.Now().Add(time.Hour))).(*span)
+		time.Sleep(15 * time.Millisecond)
+		slow.Finish(FinishTime(time.Now().Add(time.Hour)))
+		assert.Equal(ext.PriorityUserKeep, root.context.samplingPriority(), "the measured duration is used")
+		root.Finish()
+	})
+
+	t.Run("partial-flush", func(t *testing.T) {
+		assert := assert.New(t)
+		tracer, transport, stop := startTestTracer(
+			WithSlowSpanSampling(10*time.Millisecond),
+			WithErrorSampling(1),
+			WithPartialFlush(1),
+		)
+		defer stop()
+
+		root := tracer.newRootSpan("pylons.request", "pylons", "/")
+		root.SetTag(ext.SamplingPriority, ext.PriorityAutoReject)
+		slow := tracer.newChildSpan("redis.command", root)
+		time.Sleep(15 * time.Millisecond)
+		slow.Finish()
+
+		tracer.flushAndWait(t, 1)
+		traces := transport.Traces()
+		assert.Len(traces, 1)
+		assert.Equal(slow.SpanID, traces[0][0].SpanID)
+		assert.Equal(float64(ext.PriorityUserKeep), traces[0][0].Metrics[keySamplingPriority], "flushed chunks carry the upgraded priority")
+
+		root.Finish()
+		tracer.flushAndWait(t, 1)
+		traces = transport.Traces()
+		assert.Len(traces, 1)
+		assert.Equal(root.SpanID, traces[0][0].SpanID)
+		assert.Equal(float64(ext.PriorityUserKeep), traces[0][0].Metrics[keySamplingPriority])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tracer, _, stop := startTestTracer()
+		defer stop()
+
+		root := tracer.newRootSpan("pylons.request", "pylons", "/")
+		assert.True(t, root.startMono.IsZero())
+		root.Finish()
+	})
+}
+
 // TestTracerTraceMaxSize tests a bug that was encountered in environments
 // creating a large volume of spans that reached the trace cap value (traceMaxSize).
 // The bug was that once the cap is reached, no more spans are pushed onto
@@ -872,6 +1035,7 @@ func TestWorker(t *testing.T) {
 
 func newTracerChannels() *tracer {
 	return &tracer{
+		config:      &config{statsd: &testStatsdClient{}},
 		payload:     newPayload(),
 		payloadChan: make(chan []*span, payloadQueueSize),
 		flushChan:   make(chan struct{}, 1),
@@ -925,10 +1089,45 @@ func TestPushTrace(t *testing.T) {
 		tracer.pushTrace(make([]*span, i))
 	}
 	assert.Len(tracer.payloadChan, payloadQueueSize)
+	assert.Len(tracer.flushChan, 1, "flush requested on overflow")
+	assert.Equal(int64(2), tracer.config.statsd.(*testStatsdClient).Counts()["datadog.tracer.buffer_overflow"])
 	log.Flush()
 	assert.True(len(tp.Lines()) >= 2)
 }
 
+func TestPushTraceOverflowFlush(t *testing.T) {
+	assert := assert.New(t)
+	statsd := &testStatsdClient{}
+	tracer := newTracer(withTransport(newDummyTransport()), withStatsdClient(statsd), WithMaxBufferSize(2))
+	defer tracer.Stop()
+
+	// keep the worker busy flushing while the queue fills up, by taking all
+	// the connections it could send the payload with
+	for i := 0; i < cap(tracer.climit); i++ {
+		tracer.climit <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(tracer.climit); i++ {
+			<-tracer.climit
+		}
+	}()
+	tracer.pushTrace([]*span{newBasicSpan("pylons.request")})
+	for len(tracer.payloadChan) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	tracer.flushChan <- struct{}{}
+	for len(tracer.flushChan) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		tracer.pushTrace([]*span{newBasicSpan("pylons.request")})
+	}
+	assert.Len(tracer.payloadChan, 2)
+	assert.Len(tracer.flushChan, 1, "flush requested on overflow")
+	assert.Equal(int64(1), statsd.Counts()["datadog.tracer.buffer_overflow"])
+}
+
 func TestTracerFlush(t *testing.T) {
 	// https://github.com/DataDog/dd-trace-go/issues/377
 	tracer, transport, stop := startTestTracer()
@@ -1172,36 +1371,6 @@ func cpspan(s *span) *span {
 	}
 }
 
-func TestTakeStackTrace(t *testing.T) {
-	t.Run("n=12", func(t *testing.T) {
-		val := takeStacktrace(12, 0)
-		// top frame should be runtime.main or runtime.goexit, in case of tests that's goexit
-		assert.Contains(t, val, "runtime.goexit")
-		assert.Contains(t, val, "testing.tRunner")
-		assert.Contains(t, val, "tracer.TestTakeStackTrace")
-	})
-
-	t.Run("n=15,skip=2", func(t *testing.T) {
-		val := takeStacktrace(3, 2)
-		// top frame should be runtime.main or runtime.goexit, in case of tests that's goexit
-		assert.Contains(t, val, "runtime.goexit")
-		numFrames := strings.Count(val, "\n\t")
-		assert.Equal(t, 1, numFrames)
-	})
-
-	t.Run("n=1", func(t *testing.T) {
-		val := takeStacktrace(1, 0)
-		assert.Contains(t, val, "tracer.TestTakeStackTrace", "should contain this function")
-		// each frame consists of two strings separated by \n\t, thus number of frames == number of \n\t
-		numFrames := strings.Count(val, "\n\t")
-		assert.Equal(t, 1, numFrames)
-	})
-
-	t.Run("invalid", func(t *testing.T) {
-		assert.Empty(t, takeStacktrace(100, 115))
-	})
-}
-
 // BenchmarkTracerStackFrames tests the performance of taking stack trace.
 func BenchmarkTracerStackFrames(b *testing.B) {
 	tracer, _, stop := startTestTracer(WithSampler(NewRateSampler(0)))
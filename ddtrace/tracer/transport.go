@@ -7,15 +7,19 @@ package tracer
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
@@ -47,6 +51,101 @@ const (
 	traceCountHeader   = "X-Datadog-Trace-Count" // header containing the number of traces in the payload
 )
 
+// defaultSocketAPM specifies the unix domain socket on which the agent receives
+// traces by default. It is used when it exists and no agent address is configured.
+var defaultSocketAPM = "/var/run/datadog/apm.socket"
+
+const (
+	// udsSendBufferSize is the size of the socket send buffer (SO_SNDBUF)
+	// requested for connections to the agent's unix domain socket.
+	udsSendBufferSize = 1024 * 1024 // 1 MB
+
+	// udsDialAttempts is the number of attempts made to connect to the agent's
+	// unix domain socket when the connection fails with EAGAIN, which happens
+	// when the agent's listen backlog is full.
+	udsDialAttempts = 3
+
+	// udsRetryInterval is the time waited between failed connection attempts.
+	udsRetryInterval = 10 * time.Millisecond
+)
+
+// newUDSRoundTripper returns an http.RoundTripper sending requests to the agent
+// over the unix domain socket found at path.
+func newUDSRoundTripper(path string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+			return dialUDS(path)
+		},
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// dialUDS connects to the unix domain socket found at path, retrying when the
+// connection fails with EAGAIN.
+func dialUDS(path string) (net.Conn, error) {
+	addr := &net.UnixAddr{Name: path, Net: "unix"}
+	var (
+		conn *net.UnixConn
+		err  error
+	)
+	for i := 0; i < udsDialAttempts; i++ {
+		if i > 0 {
+			time.Sleep(udsRetryInterval)
+		}
+		conn, err = net.DialUnix("unix", nil, addr)
+		if !isErrno(err, syscall.EAGAIN) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	// best effort; the system default is used if this fails
+	conn.SetWriteBuffer(udsSendBufferSize)
+	return conn, nil
+}
+
+// isErrno reports whether err is a network operation error caused by errno.
+func isErrno(err error, errno syscall.Errno) bool {
+	opErr, ok := err.(*net.OpError)
+	if !ok {
+		return false
+	}
+	sysErr, ok := opErr.Err.(*os.SyscallError)
+	return ok && sysErr.Err == errno
+}
+
+// isConnError reports whether err, as returned by the transport, was caused by
+// failing to connect to or communicate with the agent.
+func isConnError(err error) bool {
+	if uerr, ok := err.(*url.Error); ok {
+		err = uerr.Err
+	}
+	_, ok := err.(*net.OpError)
+	return ok
+}
+
+// statusError is returned by transports when the agent responds with an error status.
+type statusError struct {
+	code int    // HTTP status code
+	msg  string // error message
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// isRetryable reports whether a payload which failed to be sent with err may be
+// accepted if it is sent again later. Payloads which were rejected by the agent
+// with a client error status, other than timeouts and rate limiting, are not.
+func isRetryable(err error) bool {
+	se, ok := err.(*statusError)
+	if !ok || se.code < 400 || se.code >= 500 {
+		return true
+	}
+	return se.code == http.StatusRequestTimeout || se.code == http.StatusTooManyRequests
+}
+
 // transport is an interface for span submission to the agent.
 type transport interface {
 	// send sends the payload p to the agent using the transport set up.
@@ -78,6 +177,7 @@ func newDefaultTransport() transport {
 
 type httpTransport struct {
 	traceURL string            // the delivery URL for traces
+	infoURL  string            // the URL of the agent's info endpoint
 	client   *http.Client      // the HTTP client used in the POST
 	headers  map[string]string // the Transport headers
 }
@@ -85,28 +185,36 @@ type httpTransport struct {
 // newHTTPTransport returns an httpTransport for the given endpoint
 func newHTTPTransport(addr string, roundTripper http.RoundTripper) *httpTransport {
 	// initialize the default EncoderPool with Encoder headers
-	defaultHeaders := map[string]string{
+	defaultHeaders := metaHeaders()
+	defaultHeaders["Content-Type"] = "application/msgpack"
+	return &httpTransport{
+		traceURL: fmt.Sprintf("http://%s/v0.4/traces", resolveAddr(addr)),
+		infoURL:  fmt.Sprintf("http://%s/info", resolveAddr(addr)),
+		client: &http.Client{
+			Transport: roundTripper,
+			Timeout:   defaultHTTPTimeout,
+		},
+		headers: defaultHeaders,
+	}
+}
+
+// metaHeaders returns the headers describing the tracer and its environment,
+// to be sent along with traces.
+func metaHeaders() map[string]string {
+	headers := map[string]string{
 		"Datadog-Meta-Lang":             "go",
 		"Datadog-Meta-Lang-Version":     strings.TrimPrefix(runtime.Version(), "go"),
 		"Datadog-Meta-Lang-Interpreter": runtime.Compiler + "-" + runtime.GOARCH + "-" + runtime.GOOS,
 		"Datadog-Meta-Tracer-Version":   version.Tag,
-		"Content-Type":                  "application/msgpack",
 	}
 	f, err := os.Open("/proc/self/cgroup")
 	if err == nil {
 		if id, ok := readContainerID(f); ok {
-			defaultHeaders["Datadog-Container-ID"] = id
+			headers["Datadog-Container-ID"] = id
 		}
 		f.Close()
 	}
-	return &httpTransport{
-		traceURL: fmt.Sprintf("http://%s/v0.4/traces", resolveAddr(addr)),
-		client: &http.Client{
-			Transport: roundTripper,
-			Timeout:   defaultHTTPTimeout,
-		},
-		headers: defaultHeaders,
-	}
+	return headers
 }
 
 var (
@@ -155,13 +263,27 @@ func (t *httpTransport) send(p *payload) (body io.ReadCloser, err error) {
 		response.Body.Close()
 		txt := http.StatusText(code)
 		if n > 0 {
-			return nil, fmt.Errorf("%s (Status: %s)", msg[:n], txt)
+			return nil, &statusError{code: code, msg: fmt.Sprintf("%s (Status: %s)", msg[:n], txt)}
 		}
-		return nil, fmt.Errorf("%s", txt)
+		return nil, &statusError{code: code, msg: txt}
 	}
 	return response.Body, nil
 }
 
+// ping implements pinger by requesting the agent's info endpoint.
+func (t *httpTransport) ping() error {
+	resp, err := t.client.Get(t.infoURL)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s", http.StatusText(resp.StatusCode))
+	}
+	return nil
+}
+
 // resolveAddr resolves the given agent address and fills in any missing host
 // and port using the defaults. Some environment variable settings will
 // take precedence over configuration.
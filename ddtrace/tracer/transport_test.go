@@ -19,6 +19,8 @@ import (
 	"testing"
 	"time"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -306,3 +308,98 @@ func TestTransportHTTPRace(t *testing.T) {
 	srv.Shutdown(ctx)
 	<-done
 }
+
+func TestUDSTransport(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "uds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := dir + "/apm.socket"
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mockDatadogAPIHandler{t: t}}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	transport := newHTTPTransport(defaultAddress, newUDSRoundTripper(path))
+	p, err := encode(getTestTrace(1, 1))
+	assert.NoError(err)
+	body, err := transport.send(p)
+	assert.NoError(err)
+	body.Close()
+
+	_, err = newHTTPTransport(defaultAddress, newUDSRoundTripper(dir+"/missing.socket")).send(newPayload())
+	assert.True(isConnError(err))
+}
+
+func TestAgentSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(old string) { defaultSocketAPM = old }(defaultSocketAPM)
+	defaultSocketAPM = dir + "/apm.socket"
+
+	newConfig := func(opts ...StartOption) *config {
+		var c config
+		defaults(&c)
+		for _, fn := range opts {
+			fn(&c)
+		}
+		return &c
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		assert.Equal(t, "", newConfig().agentSocket())
+		assert.Equal(t, "tcp", newConfig().transportName())
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+		c := newConfig(WithUDSPath("/tmp/apm.socket"), WithAgentAddr("host:1234"))
+		assert.Equal(t, "/tmp/apm.socket", c.agentSocket())
+	})
+
+	if err := ioutil.WriteFile(defaultSocketAPM, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("detected", func(t *testing.T) {
+		assert.Equal(t, defaultSocketAPM, newConfig().agentSocket())
+	})
+
+	t.Run("agent-addr", func(t *testing.T) {
+		assert.Equal(t, "", newConfig(WithAgentAddr("host:1234")).agentSocket())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		os.Setenv("DD_AGENT_HOST", "host")
+		defer os.Unsetenv("DD_AGENT_HOST")
+		assert.Equal(t, "", newConfig().agentSocket())
+	})
+}
+
+func TestUDSConnectionErrors(t *testing.T) {
+	var tg testStatsdClient
+	tracer := newTracer(WithUDSPath("/nonexistent/apm.socket"), withStatsdClient(&tg))
+	internal.SetGlobalTracer(tracer)
+	defer internal.SetGlobalTracer(&internal.NoopTracer{})
+	assert.Equal(t, "uds", tracer.config.transportName())
+
+	tracer.StartSpan("operation").Finish()
+	tracer.Stop() // flushes the trace and waits for it to be sent
+
+	var calls []testStatsdCall
+	for _, c := range tg.IncrCalls() {
+		if c.name == "datadog.tracer.connection_errors" {
+			calls = append(calls, c)
+		}
+	}
+	if assert.Len(t, calls, 1) {
+		assert.Equal(t, []string{"transport:uds"}, calls[0].tags)
+	}
+}
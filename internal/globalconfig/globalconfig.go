@@ -19,6 +19,15 @@ var cfg = &config{
 type config struct {
 	mu            sync.RWMutex
 	analyticsRate float64
+	statsd        StatsdClient
+}
+
+// StatsdClient is the subset of the tracer's statsd client which integrations may
+// use to report metrics.
+type StatsdClient interface {
+	Count(name string, value int64, tags []string, rate float64) error
+	Gauge(name string, value float64, tags []string, rate float64) error
+	Distribution(name string, value float64, tags []string, rate float64) error
 }
 
 // AnalyticsRate returns the sampling rate at which events should be marked. It uses
@@ -36,3 +45,18 @@ func SetAnalyticsRate(rate float64) {
 	cfg.analyticsRate = rate
 	cfg.mu.Unlock()
 }
+
+// Statsd returns the statsd client of the running tracer, or nil if the
+// tracer isn't started.
+func Statsd() StatsdClient {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return cfg.statsd
+}
+
+// SetStatsd sets the statsd client returned by Statsd.
+func SetStatsd(c StatsdClient) {
+	cfg.mu.Lock()
+	cfg.statsd = c
+	cfg.mu.Unlock()
+}